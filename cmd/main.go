@@ -1,23 +1,41 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
 	"protofire-game/internal/delivery/cli"
+	httpdelivery "protofire-game/internal/delivery/http"
+	"protofire-game/internal/delivery/tcp"
 	"protofire-game/internal/domain"
 	service "protofire-game/internal/randomness"
+	"protofire-game/internal/rating"
 	"protofire-game/internal/repository"
 	"protofire-game/internal/usecase"
 )
 
 var dataDir string
 
-func initSQLiteRepository() (domain.GameRepository, error) {
+type gameRepository interface {
+	domain.GameRepository
+	domain.StatsRepository
+}
+
+func initSQLiteRepository() (*repository.SQLiteRepository, error) {
 	if dataDir == "" {
 		return nil, fmt.Errorf("DATA_DIR is not set")
 	}
@@ -30,41 +48,260 @@ func initSQLiteRepository() (domain.GameRepository, error) {
 	return repository.NewSQLiteRepository(dbPath)
 }
 
-func initOnChainRepository() (domain.GameRepository, error) {
-	return repository.NewOnChainRepository()
+// initOnChainRepository indexes the on-chain history in the SQLite database,
+// and queues the games to store there, unless the build has no data
+// directory.
+func initOnChainRepository() (gameRepository, error) {
+	repo, err := repository.NewOnChainRepository()
+	if err != nil {
+		return nil, err
+	}
+	if dataDir == "" {
+		return repo, nil
+	}
+
+	index, err := initSQLiteRepository()
+	if err != nil {
+		repo.Close()
+		return nil, fmt.Errorf("failed to open the history index: %w", err)
+	}
+	repo.SetIndex(index)
+	repo.SetOutbox(index)
+	return repo, nil
 }
 
-func main() {
+func initRatingEngine() domain.RatingEngine {
+	switch os.Getenv("RATING_SYSTEM") {
+	case "glicko2":
+		return rating.NewGlicko2(rating.DefaultGlicko2Tau)
+	case "", "elo":
+	default:
+		log.Printf("Warning: unknown RATING_SYSTEM %q, using elo", os.Getenv("RATING_SYSTEM"))
+	}
 
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: Error loading .env file: %v", err)
+	k := float64(rating.DefaultEloK)
+	if value := os.Getenv("ELO_K"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			log.Printf("Warning: invalid ELO_K %q, using %v", value, k)
+		} else {
+			k = parsed
+		}
+	}
+	return rating.NewElo(k)
+}
+
+// initRandomGenerator returns the generator selected by RANDOM_GENERATOR.
+// The seeded generator makes bot games reproducible from RANDOM_SEED, or
+// from a random seed that is printed at startup.
+func initRandomGenerator() domain.RandomGenerator {
+	switch os.Getenv("RANDOM_GENERATOR") {
+	case "seeded":
+		seed := service.NewRandomSeed()
+		if value := os.Getenv("RANDOM_SEED"); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				log.Printf("Warning: invalid RANDOM_SEED %q, using %d", value, seed)
+			} else {
+				seed = parsed
+			}
+		}
+		log.Printf("Using seeded random generator with seed %d", seed)
+		return service.NewSeededRandomGenerator(seed)
+	case "", "crypto":
+	default:
+		log.Printf("Warning: unknown RANDOM_GENERATOR %q, using crypto", os.Getenv("RANDOM_GENERATOR"))
+	}
+	return service.NewCryptoRandomGenerator()
+}
+
+// initTimeout returns the default of --timeout, from STORAGE_TIMEOUT.
+func initTimeout() time.Duration {
+	value := os.Getenv("STORAGE_TIMEOUT")
+	if value == "" {
+		return cli.DefaultTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		log.Printf("Warning: invalid STORAGE_TIMEOUT %q, using %v", value, cli.DefaultTimeout)
+		return cli.DefaultTimeout
 	}
+	return timeout
+}
 
-	fmt.Println("\nSelect Storage Type:")
-	fmt.Println("1. SQLite")
-	fmt.Println("2. On-Chain")
-	fmt.Print("Choose storage type: ")
+// initRepository opens the storage backend named by --storage or STORAGE.
+// Without either, the interactive game asks for it.
+func initRepository(storage string, interactive bool) (gameRepository, error) {
+	if storage == "" && interactive {
+		fmt.Println("\nSelect Storage Type:")
+		fmt.Println("1. SQLite")
+		fmt.Println("2. On-Chain")
+		fmt.Print("Choose storage type: ")
 
-	var choice string
-	fmt.Scanln(&choice)
+		var choice string
+		fmt.Scanln(&choice)
 
-	var repo domain.GameRepository
-	var err error
+		switch choice {
+		case "1":
+			fmt.Println("Using SQLite storage")
+			storage = "sqlite"
+		case "2":
+			fmt.Println("Using On-Chain storage")
+			storage = "onchain"
+		default:
+			fmt.Println("Invalid choice. Using default SQLite storage")
+			storage = "sqlite"
+		}
+	}
 
-	switch choice {
-	case "1":
-		fmt.Println("Using SQLite storage")
-		repo, err = initSQLiteRepository()
-	case "2":
-		fmt.Println("Using On-Chain storage")
-		repo, err = initOnChainRepository()
+	switch storage {
+	case "", "sqlite":
+		return initSQLiteRepository()
+	case "onchain":
+		return initOnChainRepository()
 	default:
-		fmt.Println("Invalid choice. Using default SQLite storage")
-		repo, err = initSQLiteRepository()
+		return nil, fmt.Errorf("unknown storage %q", storage)
+	}
+}
+
+// serve runs the REST API until the process is interrupted.
+func serve(args []string, newGameUseCase func() *usecase.GameUseCase, statsUseCase *usecase.StatsUseCase) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	idleTimeout := flags.Duration("idle-timeout", usecase.DefaultSessionIdleTimeout, "time after which a game without rounds played is dropped")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sessions := usecase.NewSessionManager(newGameUseCase, *idleTimeout)
+	go sessions.RunExpiry(ctx, min(time.Minute, *idleTimeout))
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           httpdelivery.NewServer(sessions, newGameUseCase(), statsUseCase),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("Serving the REST API on %s", *addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		log.Printf("Server failed: %v", err)
+		return 1
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// host runs a lobby for networked Player vs Player matches until the process
+// is interrupted. Storing a finished match is given up after the timeout.
+func host(args []string, newGameUseCase func() *usecase.GameUseCase, timeout time.Duration) int {
+	flags := flag.NewFlagSet("host", flag.ContinueOnError)
+	addr := flags.String("addr", ":9000", "address to listen on")
+	reconnectTimeout := flags.Duration("reconnect-timeout", tcp.DefaultReconnectTimeout, "time a disconnected player has to come back before the match is abandoned")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Abandoned matches leave their game behind in the sessions.
+	sessions := usecase.NewSessionManager(newGameUseCase, usecase.DefaultSessionIdleTimeout)
+	go sessions.RunExpiry(ctx, time.Minute)
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Printf("Failed to listen: %v", err)
+		return 1
+	}
+	log.Printf("Hosting networked games on %s", ln.Addr())
+	server := tcp.NewServer(sessions, *reconnectTimeout)
+	server.SetTimeout(timeout)
+	if err := server.Serve(ctx, ln); err != nil {
+		log.Printf("Server failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// connect joins the lobby of a host to play networked games.
+func connect(args []string) int {
+	flags := flag.NewFlagSet("connect", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:9000", "address of the host")
+	player := flags.String("player", "", "your player name")
+	reconnectTimeout := flags.Duration("reconnect-timeout", tcp.DefaultReconnectTimeout, "how long to keep trying to reconnect after the connection drops")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if *player == "" {
+		fmt.Fprintln(os.Stderr, "Error: --player is required")
+		return 1
+	}
+
+	if err := tcp.RunClient(*addr, *player, *reconnectTimeout, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: Error loading .env file: %v", err)
 	}
 
+	storage := flag.String("storage", os.Getenv("STORAGE"), "storage backend, sqlite or onchain")
+	timeout := flag.Duration("timeout", initTimeout(), "time a call to the storage may take in the game and its commands, 0 for no limit")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--storage sqlite|onchain] [--timeout duration] [command] [flags]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(os.Stderr, "Without a command the interactive game is started.")
+		fmt.Fprintln(os.Stderr)
+		cli.PrintCommands(os.Stderr)
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", "serve", "serve the REST API over HTTP")
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", "host", "host a lobby for Player vs Player games over the network")
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", "connect", "play on a host started with host")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "Run '%s <command> --help' for the flags of a command.\n", filepath.Base(os.Args[0]))
+	}
+	flag.Parse()
+	interactive := flag.NArg() == 0
+
+	// Clients leave storage to the host.
+	if flag.Arg(0) == "connect" {
+		return connect(flag.Args()[1:])
+	}
+
+	repo, err := initRepository(*storage, interactive)
 	if err != nil {
-		log.Fatalf("Failed to initialize repository: %v", err)
+		log.Printf("Failed to initialize repository: %v", err)
+		return 1
 	}
 
 	defer func() {
@@ -75,9 +312,84 @@ func main() {
 		}
 	}()
 
-	randGen := service.NewDefaultRandomGenerator()
-	gameUseCase := usecase.NewGameUseCase(repo, randGen)
+	// The games queued for the chain are stored in the background while the
+	// program runs, and the rest on the next run.
+	if outbox, ok := repo.(interface{ RunOutbox(context.Context) error }); ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			if err := outbox.RunOutbox(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Stopped storing queued games on-chain: %v", err)
+			}
+		}()
+		defer func() {
+			cancel()
+			<-stopped
+		}()
+	}
+
+	// Backends that cannot store ratings get them recomputed from history.
+	ratingRepo, _ := repo.(domain.RatingRepository)
+	excludeBots := os.Getenv("RATING_EXCLUDE_BOTS") == "true"
+	ratingUseCase := usecase.NewRatingUseCase(repo, ratingRepo, initRatingEngine(), excludeBots)
+
+	randGen := initRandomGenerator()
+	newGameUseCase := func() *usecase.GameUseCase {
+		// Concurrent games each get their own seeded stream, so every game
+		// can still be replayed from its seed.
+		gameRandGen := randGen
+		if seeded, ok := randGen.(*service.SeededRandomGenerator); ok {
+			gameRandGen = seeded.Split()
+		}
+		gameUseCase := usecase.NewGameUseCase(repo, gameRandGen)
+		gameUseCase.SetStrategies(service.NewStrategies(gameRandGen))
+		gameUseCase.SetRatings(ratingUseCase)
+		return gameUseCase
+	}
+	statsUseCase := usecase.NewStatsUseCase(repo)
+
+	if flag.Arg(0) == "serve" {
+		return serve(flag.Args()[1:], newGameUseCase, statsUseCase)
+	}
+	if flag.Arg(0) == "host" {
+		return host(flag.Args()[1:], newGameUseCase, *timeout)
+	}
 
-	gameCLI := cli.NewGameCLI(gameUseCase)
-	gameCLI.Start()
+	gameUseCase := newGameUseCase()
+	gameCLI := cli.NewGameCLI(gameUseCase, statsUseCase, ratingUseCase)
+	gameCLI.SetTimeout(*timeout)
+	if tournamentRepo, ok := repo.(domain.TournamentRepository); ok {
+		gameCLI.SetTournaments(usecase.NewTournamentUseCase(tournamentRepo, gameUseCase))
+	}
+	if watcher, ok := repo.(domain.GameWatcher); ok {
+		gameCLI.SetLiveFeed(usecase.NewLiveFeedUseCase(watcher))
+	}
+	if outboxRepo, ok := repo.(domain.OutboxRepository); ok {
+		gameCLI.SetOutbox(usecase.NewOutboxUseCase(outboxRepo))
+	}
+	if interactive {
+		if unfinishedRepo, ok := repo.(domain.UnfinishedGameRepository); ok {
+			gameUseCase.SetUnfinishedGames(unfinishedRepo)
+		}
+		err := gameCLI.Start()
+		switch {
+		case errors.Is(err, cli.ErrInterrupted):
+			// Exit as the interrupt would have, once everything is closed.
+			return 130
+		case err != nil && !errors.Is(err, io.EOF):
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if err := gameCLI.Run(flag.Args(), os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
 }
@@ -8,29 +8,61 @@ contract ProtofireGame {
         Scissors
     }
 
-    // 15 bytes is enough for a player name of 15 characters.
-    // so the 2 players and the winner can fit in a single storage slot.
+    // A game as it is sent to the contract. 15 bytes is enough for a player
+    // name of 15 characters. winner is 0 for a draw, or 1 or 2 for the
+    // player who won. ruleSet is 0 for Rock Paper Scissors and 1 for Rock
+    // Paper Scissors Lizard Spock. mode is 0 for a game between players and
+    // 1 for a game against the bot.
     struct GameResult {
         bytes15 player1;
         bytes15 player2;
         uint8 winner;
+        uint8 ruleSet;
+        uint8 mode;
     }
 
-    GameResult[] private gameResults;
+    // A game as it is stored: the players, the winner and the rule set fill
+    // the first slot, and the mode, the time and the sender share a second.
+    struct StoredGameResult {
+        bytes15 player1;
+        bytes15 player2;
+        uint8 winner;
+        uint8 ruleSet;
+        uint8 mode;
+        uint64 timestamp;
+        address submitter;
+    }
+
+    StoredGameResult[] private gameResults;
 
+    // index is the position of the game, as read with getGameResult.
     event GameResultStored(
         bytes15 indexed player1,
         bytes15 indexed player2,
-        uint8 winner
+        uint8 winner,
+        uint8 ruleSet,
+        uint8 mode,
+        uint256 indexed index,
+        address submitter,
+        uint64 timestamp
     );
 
     function storeGameResult(
         bytes15 player1,
         bytes15 player2,
-        uint8 winner
+        uint8 winner,
+        uint8 ruleSet,
+        uint8 mode
     ) external {
-        gameResults.push(GameResult(player1, player2, winner));
-        emit GameResultStored(player1, player2, winner);
+        _storeGameResult(GameResult(player1, player2, winner, ruleSet, mode));
+    }
+
+    // Stores many games in a single transaction, which pays the base cost of
+    // a transaction once for all of them.
+    function storeGameResults(GameResult[] calldata results) external {
+        for (uint256 i = 0; i < results.length; i++) {
+            _storeGameResult(results[i]);
+        }
     }
 
     function getTotalGames() external view returns (uint256) {
@@ -39,9 +71,46 @@ contract ProtofireGame {
 
     function getGameResult(
         uint256 index
-    ) external view returns (bytes15, bytes15, uint8) {
+    )
+        external
+        view
+        returns (bytes15, bytes15, uint8, uint8, uint8, uint64, address)
+    {
         require(index < gameResults.length, "Index out of bounds");
-        GameResult storage result = gameResults[index];
-        return (result.player1, result.player2, result.winner);
+        StoredGameResult storage result = gameResults[index];
+        return (
+            result.player1,
+            result.player2,
+            result.winner,
+            result.ruleSet,
+            result.mode,
+            result.timestamp,
+            result.submitter
+        );
+    }
+
+    function _storeGameResult(GameResult memory result) private {
+        uint64 timestamp = uint64(block.timestamp);
+        gameResults.push(
+            StoredGameResult(
+                result.player1,
+                result.player2,
+                result.winner,
+                result.ruleSet,
+                result.mode,
+                timestamp,
+                msg.sender
+            )
+        );
+        emit GameResultStored(
+            result.player1,
+            result.player2,
+            result.winner,
+            result.ruleSet,
+            result.mode,
+            gameResults.length - 1,
+            msg.sender,
+            timestamp
+        );
     }
 }
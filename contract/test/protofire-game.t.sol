@@ -15,17 +15,25 @@ contract ProtofireGameTest is Test {
         string memory player1 = "Ulad";
         string memory player2 = "Arsenii";
         uint8 winner = 1; // player1 wins
+        uint8 ruleSet = 1; // with lizard and Spock
+        uint8 mode = 1; // against the bot
 
         game.storeGameResult(
             _stringToBytes15(player1),
             _stringToBytes15(player2),
-            winner
+            winner,
+            ruleSet,
+            mode
         );
 
         (
             bytes15 resultPlayer1,
             bytes15 resultPlayer2,
-            uint8 resultWinner
+            uint8 resultWinner,
+            uint8 resultRuleSet,
+            uint8 resultMode,
+            uint64 resultTimestamp,
+            address resultSubmitter
         ) = game.getGameResult(0);
 
         assertEq(
@@ -39,6 +47,29 @@ contract ProtofireGameTest is Test {
             "Player2 name mismatch"
         );
         assertEq(resultWinner, winner, "Winner value mismatch");
+        assertEq(resultRuleSet, ruleSet, "Rule set mismatch");
+        assertEq(resultMode, mode, "Mode mismatch");
+        assertEq(resultTimestamp, block.timestamp, "Timestamp mismatch");
+        assertEq(resultSubmitter, address(this), "Submitter mismatch");
+    }
+
+    function testStoreGameResultRecordsTimeAndSubmitter() public {
+        address submitter = makeAddr("submitter");
+        vm.warp(1735725600); // 2025-01-01T10:00:00Z
+
+        vm.prank(submitter);
+        game.storeGameResult(
+            _stringToBytes15("Ulad"),
+            _stringToBytes15("Arsenii"),
+            2, // Arsenii (player2) wins
+            0,
+            0
+        );
+
+        (, , , , , uint64 resultTimestamp, address resultSubmitter) = game
+            .getGameResult(0);
+        assertEq(resultTimestamp, 1735725600, "Timestamp mismatch");
+        assertEq(resultSubmitter, submitter, "Submitter mismatch");
     }
 
     function testGetTotalGames() public {
@@ -47,17 +78,23 @@ contract ProtofireGameTest is Test {
         game.storeGameResult(
             _stringToBytes15("Ulad"),
             _stringToBytes15("Arsenii"),
-            1 // Ulad (player1) wins
+            1, // Ulad (player1) wins
+            0,
+            0
         );
         game.storeGameResult(
             _stringToBytes15("Arsenii"),
             _stringToBytes15("Ulad"),
-            2 // Ulad (player2) wins
+            2, // Ulad (player2) wins
+            0,
+            0
         );
         game.storeGameResult(
             _stringToBytes15("Ulad"),
             _stringToBytes15("Arsenii"),
-            1 // Ulad (player1) wins
+            1, // Ulad (player1) wins
+            0,
+            0
         );
 
         assertEq(game.getTotalGames(), 3, "Game count should be 3");
@@ -72,19 +109,175 @@ contract ProtofireGameTest is Test {
         string memory player1 = "Alice";
         string memory player2 = "Bob";
         uint8 winner = 1; // Alice (player1) wins
+        uint8 ruleSet = 0; // rock paper scissors
+        uint8 mode = 0; // between players
+
+        game.storeGameResult(
+            _stringToBytes15("Ulad"),
+            _stringToBytes15("Arsenii"),
+            1, // Ulad (player1) wins
+            0,
+            0
+        );
 
         vm.expectEmit(true, true, true, true);
         emit ProtofireGame.GameResultStored(
             _stringToBytes15(player1),
             _stringToBytes15(player2),
-            winner
+            winner,
+            ruleSet,
+            mode,
+            1, // the second game stored
+            address(this),
+            uint64(block.timestamp)
         );
 
         game.storeGameResult(
             _stringToBytes15(player1),
             _stringToBytes15(player2),
-            winner
+            winner,
+            ruleSet,
+            mode
+        );
+    }
+
+    function testStoreGameResults() public {
+        ProtofireGame.GameResult[] memory results = _gameResults(3);
+
+        game.storeGameResults(results);
+
+        assertEq(game.getTotalGames(), 3, "Game count should be 3");
+        for (uint256 i = 0; i < results.length; i++) {
+            (
+                bytes15 resultPlayer1,
+                bytes15 resultPlayer2,
+                uint8 resultWinner,
+                uint8 resultRuleSet,
+                uint8 resultMode,
+                ,
+            ) = game.getGameResult(i);
+
+            assertEq(
+                resultPlayer1,
+                results[i].player1,
+                "Player1 name mismatch"
+            );
+            assertEq(
+                resultPlayer2,
+                results[i].player2,
+                "Player2 name mismatch"
+            );
+            assertEq(
+                resultWinner,
+                results[i].winner,
+                "Winner value mismatch"
+            );
+            assertEq(
+                resultRuleSet,
+                results[i].ruleSet,
+                "Rule set mismatch"
+            );
+            assertEq(resultMode, results[i].mode, "Mode mismatch");
+        }
+    }
+
+    function testStoreGameResultsAfterSingleGames() public {
+        game.storeGameResult(
+            _stringToBytes15("Ulad"),
+            _stringToBytes15("Arsenii"),
+            1, // Ulad (player1) wins
+            0,
+            0
         );
+
+        game.storeGameResults(_gameResults(2));
+
+        assertEq(game.getTotalGames(), 3, "Game count should be 3");
+        (bytes15 resultPlayer1, , , , , , ) = game.getGameResult(0);
+        assertEq(
+            resultPlayer1,
+            _stringToBytes15("Ulad"),
+            "Single game should stay first"
+        );
+    }
+
+    function testStoreGameResultsEmpty() public {
+        game.storeGameResults(new ProtofireGame.GameResult[](0));
+
+        assertEq(game.getTotalGames(), 0, "Game count should be zero");
+    }
+
+    function testStoreGameResultsEvents() public {
+        ProtofireGame.GameResult[] memory results = _gameResults(2);
+
+        for (uint256 i = 0; i < results.length; i++) {
+            vm.expectEmit(true, true, true, true);
+            emit ProtofireGame.GameResultStored(
+                results[i].player1,
+                results[i].player2,
+                results[i].winner,
+                results[i].ruleSet,
+                results[i].mode,
+                i,
+                address(this),
+                uint64(block.timestamp)
+            );
+        }
+
+        game.storeGameResults(results);
+    }
+
+    // Compares storing 10 games one call at a time with a single batch. On
+    // top of the gas measured here, each transaction pays a base cost of
+    // 21000 gas, which the batch only pays once.
+    function testStoreGameResultsGas() public {
+        uint256 count = 10;
+        ProtofireGame.GameResult[] memory results = _gameResults(count);
+        ProtofireGame single = new ProtofireGame();
+
+        uint256 gasBefore = gasleft();
+        for (uint256 i = 0; i < count; i++) {
+            single.storeGameResult(
+                results[i].player1,
+                results[i].player2,
+                results[i].winner,
+                results[i].ruleSet,
+                results[i].mode
+            );
+        }
+        uint256 singleGas = gasBefore - gasleft();
+
+        gasBefore = gasleft();
+        game.storeGameResults(results);
+        uint256 batchGas = gasBefore - gasleft();
+
+        emit log_named_uint("storeGameResult x10 gas", singleGas);
+        emit log_named_uint(
+            "storeGameResult x10 gas with transaction costs",
+            singleGas + count * 21000
+        );
+        emit log_named_uint("storeGameResults of 10 gas", batchGas);
+        emit log_named_uint(
+            "storeGameResults of 10 gas with transaction cost",
+            batchGas + 21000
+        );
+        assertLt(batchGas, singleGas, "Batch should use less gas");
+        assertEq(game.getTotalGames(), count, "Game count should be 10");
+    }
+
+    function _gameResults(
+        uint256 count
+    ) internal pure returns (ProtofireGame.GameResult[] memory results) {
+        results = new ProtofireGame.GameResult[](count);
+        for (uint256 i = 0; i < count; i++) {
+            results[i] = ProtofireGame.GameResult(
+                _stringToBytes15(i % 2 == 0 ? "Alice" : "Bob"),
+                _stringToBytes15(i % 2 == 0 ? "Bob" : "Alice"),
+                uint8(i % 3), // draws and wins of either player
+                uint8(i % 2), // either rule set
+                uint8((i / 2) % 2) // either mode
+            );
+        }
     }
 
     function _stringToBytes15(
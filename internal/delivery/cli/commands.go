@@ -0,0 +1,550 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"protofire-game/internal/domain"
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(c *GameCLI, args []string, out io.Writer) error
+}
+
+var commands = []command{
+	{"play", "play a game from a list of moves", (*GameCLI).runPlay},
+	{"history", "list the games played", (*GameCLI).runHistory},
+	{"stats", "show the leaderboard or the stats of a player", (*GameCLI).runStats},
+	{"export", "export every game with its rounds as JSON or CSV", (*GameCLI).runExport},
+	{"import", "store the games of a JSON export, on-chain in a single transaction", (*GameCLI).runImport},
+	{"watch", "print the games stored by any client as they land, on-chain only", (*GameCLI).runWatch},
+	{"outbox", "show whether the games queued for the chain were stored, on-chain only", (*GameCLI).runOutbox},
+}
+
+// PrintCommands lists the subcommands accepted by Run.
+func PrintCommands(w io.Writer) {
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.description)
+	}
+}
+
+// Run executes a single subcommand without prompting, writing its output to
+// out, so the game can be driven from scripts.
+func (c *GameCLI) Run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no command given")
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, args[1:], out)
+		}
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	return flags
+}
+
+func (c *GameCLI) runPlay(args []string, out io.Writer) error {
+	flags := newFlagSet("play")
+	mode := flags.String("mode", domain.PlayerVsBot.Code(), "game mode, bot or pvp")
+	player := flags.String("player", "", "name of player 1")
+	opponent := flags.String("opponent", "", "name of player 2 in pvp games")
+	moves := flags.String("moves", "", "comma separated moves of player 1, e.g. r,p,s")
+	opponentMoves := flags.String("opponent-moves", "", "comma separated moves of player 2 in pvp games")
+	formatCode := flags.String("format", domain.DefaultMatchFormat.Code(), "match format, bo<N>, ft<N>, fixed<N> or sd<N>")
+	ruleSet := flags.String("rules", domain.DefaultRuleSet.Name, "rule set, rps or rpsls")
+	difficultyName := flags.String("difficulty", domain.Easy.String(), "bot difficulty")
+	asJSON := flags.Bool("json", false, "print the game as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	gameType, err := domain.ParseGameType(*mode)
+	if err != nil {
+		return err
+	}
+	format, err := domain.ParseMatchFormat(*formatCode)
+	if err != nil {
+		return err
+	}
+	rules, err := domain.RuleSetByName(*ruleSet)
+	if err != nil {
+		return err
+	}
+
+	moves1, err := parseMoves(rules, *moves)
+	if err != nil {
+		return fmt.Errorf("invalid --moves: %w", err)
+	}
+	if len(moves1) == 0 {
+		return fmt.Errorf("--moves is required")
+	}
+
+	player2 := "Bot"
+	var moves2 []domain.Move
+	if gameType == domain.PlayerVsBot {
+		difficulty, err := domain.ParseDifficulty(*difficultyName)
+		if err != nil {
+			return err
+		}
+		if err := c.useCase.SetBotDifficulty(difficulty); err != nil {
+			return err
+		}
+	} else {
+		player2 = *opponent
+		if moves2, err = parseMoves(rules, *opponentMoves); err != nil {
+			return fmt.Errorf("invalid --opponent-moves: %w", err)
+		}
+		if len(moves2) != len(moves1) {
+			return fmt.Errorf("--moves and --opponent-moves must have the same number of moves")
+		}
+	}
+
+	if err := c.useCase.StartNewGame(gameType, format, rules, *player, player2); err != nil {
+		return err
+	}
+
+	// Moves left once the game is decided are ignored, since the number of
+	// rounds against the bot is not known in advance.
+	for i, move1 := range moves1 {
+		var move2 domain.Move
+		if moves2 != nil {
+			move2 = moves2[i]
+		}
+
+		ctx, cancel := c.storageContext()
+		game, err := c.useCase.PlayRound(ctx, move1, move2)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if game.Winner == "" {
+			continue
+		}
+
+		// The JSON output stays a game, so where the on-chain save stands
+		// goes to stderr.
+		if *asJSON {
+			if err := writeJSON(out, toGameJSON(game)); err != nil {
+				return err
+			}
+			return c.awaitOutbox(game, os.Stderr)
+		}
+		printGame(out, game)
+		return c.awaitOutbox(game, out)
+	}
+
+	return fmt.Errorf("game is not finished after %d moves", len(moves1))
+}
+
+func (c *GameCLI) runHistory(args []string, out io.Writer) error {
+	flags := newFlagSet("history")
+	limit := flags.Int("limit", 20, "number of games to list, 0 for all")
+	offset := flags.Int("offset", 0, "number of games to skip")
+	player := flags.String("player", "", "only the games of this player")
+	winner := flags.String("winner", "", "only the games won by this player, or Draw")
+	mode := flags.String("mode", "", "only the games of this mode, bot or pvp")
+	from := flags.String("from", "", "only the games played from this date, YYYY-MM-DD or RFC 3339")
+	to := flags.String("to", "", "only the games played before this date, YYYY-MM-DD or RFC 3339")
+	order := flags.String("order", domain.NewestFirst.Code(), "newest or oldest first")
+	asJSON := flags.Bool("json", false, "print the games as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	query := domain.HistoryQuery{
+		Player: *player,
+		Winner: *winner,
+		Limit:  *limit,
+		Offset: *offset,
+	}
+	var err error
+	if *mode != "" {
+		gameMode, err := domain.ParseGameType(*mode)
+		if err != nil {
+			return err
+		}
+		query.Mode = &gameMode
+	}
+	if query.Order, err = domain.ParseSortOrder(*order); err != nil {
+		return err
+	}
+	if query.From, err = parseDate("from", *from); err != nil {
+		return err
+	}
+	if query.To, err = parseDate("to", *to); err != nil {
+		return err
+	}
+
+	ctx, cancel := c.storageContext()
+	defer cancel()
+
+	page, err := c.useCase.QueryHistory(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to get history: %w", err)
+	}
+
+	if *asJSON {
+		games := make([]gameJSON, len(page.Games))
+		for i, game := range page.Games {
+			games[i] = toGameJSON(game)
+		}
+		return writeJSON(out, games)
+	}
+
+	for _, game := range page.Games {
+		printGame(out, game)
+	}
+	return nil
+}
+
+// parseDate reads a date, at midnight local time, or a time in RFC 3339.
+// An empty value is the zero time.
+func parseDate(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s date %q, use YYYY-MM-DD or RFC 3339", name, value)
+	}
+	return date, nil
+}
+
+func (c *GameCLI) runStats(args []string, out io.Writer) error {
+	flags := newFlagSet("stats")
+	player := flags.String("player", "", "player to show the stats of, the leaderboard when empty")
+	limit := flags.Int("limit", leaderboardSize, "number of players in the leaderboard, 0 for all")
+	asJSON := flags.Bool("json", false, "print the stats as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := c.storageContext()
+	defer cancel()
+
+	if *player == "" {
+		leaderboard, err := c.statsUseCase.GetLeaderboard(ctx, *limit)
+		if err != nil {
+			return fmt.Errorf("failed to get leaderboard: %w", err)
+		}
+
+		if *asJSON {
+			entries := make([]playerStatsJSON, len(leaderboard))
+			for i, stats := range leaderboard {
+				entries[i] = toPlayerStatsJSON(stats)
+			}
+			return writeJSON(out, entries)
+		}
+		printLeaderboard(out, leaderboard)
+		return nil
+	}
+
+	stats, headToHead, err := c.statsUseCase.GetPlayerStats(ctx, *player)
+	if err != nil {
+		return fmt.Errorf("failed to get player stats: %w", err)
+	}
+
+	if *asJSON {
+		result := struct {
+			playerStatsJSON
+			HeadToHead []headToHeadJSON `json:"head_to_head"`
+		}{playerStatsJSON: toPlayerStatsJSON(stats), HeadToHead: make([]headToHeadJSON, len(headToHead))}
+		for i, record := range headToHead {
+			result.HeadToHead[i] = headToHeadJSON{
+				Opponent: record.Opponent,
+				Wins:     record.Wins,
+				Losses:   record.Losses,
+				Draws:    record.Draws,
+			}
+		}
+		return writeJSON(out, result)
+	}
+	printPlayerStats(out, stats, headToHead)
+	return nil
+}
+
+func (c *GameCLI) runExport(args []string, out io.Writer) (err error) {
+	flags := newFlagSet("export")
+	format := flags.String("format", "json", "export format, json or csv")
+	output := flags.String("output", "", "file to write to, stdout when empty")
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown export format %q", *format)
+	}
+
+	ctx, cancel := c.storageContext()
+	history, err := c.useCase.GetHistory(ctx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get history: %w", err)
+	}
+
+	if *output != "" {
+		file, createErr := os.Create(*output)
+		if createErr != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, createErr)
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = file
+	}
+
+	if *format == "csv" {
+		return writeCSV(out, history)
+	}
+
+	games := make([]gameJSON, len(history))
+	for i, game := range history {
+		games[i] = toGameJSON(game)
+	}
+	return writeJSON(out, games)
+}
+
+func (c *GameCLI) runImport(args []string, out io.Writer) error {
+	flags := newFlagSet("import")
+	input := flags.String("input", "", "JSON export to read, stdin when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if *input != "" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", *input, err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	var exported []gameJSON
+	if err := json.NewDecoder(in).Decode(&exported); err != nil {
+		return fmt.Errorf("invalid export: %w", err)
+	}
+	games := make([]*domain.Game, len(exported))
+	for i, game := range exported {
+		var err error
+		if games[i], err = fromGameJSON(game); err != nil {
+			return fmt.Errorf("invalid game %d: %w", i+1, err)
+		}
+	}
+
+	ctx, cancel := c.storageContext()
+	defer cancel()
+	if err := c.useCase.ImportGames(ctx, games); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Imported %d games\n", len(games))
+	return nil
+}
+
+// parseMoves parses a comma separated list of moves, by name or shortcut.
+func parseMoves(rules *domain.RuleSet, input string) ([]domain.Move, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+
+	var moves []domain.Move
+	for _, field := range strings.Split(input, ",") {
+		move, err := rules.ParseMove(field)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, move)
+	}
+	return moves, nil
+}
+
+type gameJSON struct {
+	ID         string      `json:"id"`
+	Player1    string      `json:"player1"`
+	Player2    string      `json:"player2"`
+	Winner     string      `json:"winner"`
+	Mode       string      `json:"mode"`
+	RuleSet    string      `json:"rule_set"`
+	Difficulty string      `json:"difficulty,omitempty"`
+	Seed       *uint64     `json:"seed,omitempty"`
+	Forfeit    bool        `json:"forfeit,omitempty"`
+	PlayedAt   string      `json:"played_at"`
+	Submitter  string      `json:"submitter,omitempty"`
+	Rounds     []roundJSON `json:"rounds"`
+}
+
+type roundJSON struct {
+	Move1      string `json:"move1"`
+	Move2      string `json:"move2"`
+	Winner     string `json:"winner"`
+	Commitment string `json:"commitment,omitempty"`
+	Salt       string `json:"salt,omitempty"`
+}
+
+type playerStatsJSON struct {
+	Player        string  `json:"player"`
+	Games         int     `json:"games"`
+	Wins          int     `json:"wins"`
+	Losses        int     `json:"losses"`
+	Draws         int     `json:"draws"`
+	WinRate       float64 `json:"win_rate"`
+	LongestStreak int     `json:"longest_streak"`
+	FavoriteMove  string  `json:"favorite_move,omitempty"`
+}
+
+type headToHeadJSON struct {
+	Opponent string `json:"opponent"`
+	Wins     int    `json:"wins"`
+	Losses   int    `json:"losses"`
+	Draws    int    `json:"draws"`
+}
+
+func toGameJSON(game *domain.Game) gameJSON {
+	result := gameJSON{
+		ID:        game.ID,
+		Player1:   game.Player1,
+		Player2:   game.Player2,
+		Winner:    game.Winner,
+		Mode:      game.Mode.Code(),
+		RuleSet:   game.RuleSet,
+		Seed:      game.Seed,
+		Forfeit:   game.Forfeit,
+		PlayedAt:  game.PlayedAt,
+		Submitter: game.Submitter,
+		Rounds:    make([]roundJSON, len(game.Rounds)),
+	}
+	if game.Mode == domain.PlayerVsBot {
+		result.Difficulty = strings.ToLower(game.Difficulty.String())
+	}
+	for i, round := range game.Rounds {
+		result.Rounds[i] = roundJSON{
+			Move1:      round.Move1.String(),
+			Move2:      round.Move2.String(),
+			Winner:     round.Winner,
+			Commitment: round.Commitment,
+			Salt:       round.Salt,
+		}
+	}
+	return result
+}
+
+// fromGameJSON reads a game of an export back.
+func fromGameJSON(game gameJSON) (*domain.Game, error) {
+	mode, err := domain.ParseGameType(game.Mode)
+	if err != nil {
+		return nil, err
+	}
+	result := &domain.Game{
+		ID:       game.ID,
+		Player1:  game.Player1,
+		Player2:  game.Player2,
+		Winner:   game.Winner,
+		Mode:     mode,
+		RuleSet:  game.RuleSet,
+		Seed:     game.Seed,
+		Forfeit:  game.Forfeit,
+		PlayedAt: game.PlayedAt,
+	}
+	if game.Difficulty != "" {
+		if result.Difficulty, err = domain.ParseDifficulty(game.Difficulty); err != nil {
+			return nil, err
+		}
+	}
+
+	ruleSet := game.RuleSet
+	if ruleSet == "" {
+		ruleSet = domain.DefaultRuleSet.Name
+	}
+	rules, err := domain.RuleSetByName(ruleSet)
+	if err != nil {
+		return nil, err
+	}
+	for i, round := range game.Rounds {
+		move1, err := rules.ParseMove(round.Move1)
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %w", i+1, err)
+		}
+		move2, err := rules.ParseMove(round.Move2)
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %w", i+1, err)
+		}
+		result.Rounds = append(result.Rounds, domain.RoundResult{
+			Move1:      move1,
+			Move2:      move2,
+			Winner:     round.Winner,
+			Commitment: round.Commitment,
+			Salt:       round.Salt,
+		})
+	}
+	return result, nil
+}
+
+func toPlayerStatsJSON(stats *domain.PlayerStats) playerStatsJSON {
+	return playerStatsJSON{
+		Player:        stats.Player,
+		Games:         stats.Games,
+		Wins:          stats.Wins,
+		Losses:        stats.Losses,
+		Draws:         stats.Draws,
+		WinRate:       stats.WinRate(),
+		LongestStreak: stats.LongestStreak,
+		FavoriteMove:  stats.FavoriteMove,
+	}
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writeCSV writes one row per round. Games without stored rounds get a
+// single row with empty round columns.
+func writeCSV(w io.Writer, games []*domain.Game) error {
+	writer := csv.NewWriter(w)
+	header := []string{"id", "played_at", "player1", "player2", "winner", "mode", "rule_set",
+		"round", "move1", "move2", "round_winner"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, game := range games {
+		row := []string{game.ID, game.PlayedAt, game.Player1, game.Player2, game.Winner, game.Mode.Code(), game.RuleSet}
+		if len(game.Rounds) == 0 {
+			if err := writer.Write(append(row, "", "", "", "")); err != nil {
+				return err
+			}
+			continue
+		}
+		for i, round := range game.Rounds {
+			record := append(append([]string{}, row...),
+				strconv.Itoa(i+1), round.Move1.String(), round.Move2.String(), round.Winner)
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,464 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/usecase"
+)
+
+func newCommandsTestCLI() *GameCLI {
+	repo := &MockGameRepository{
+		history: []*domain.Game{
+			{
+				ID:       "game2",
+				Player1:  "Alice",
+				Player2:  "Bot",
+				Winner:   "Bot",
+				Mode:     domain.PlayerVsBot,
+				RuleSet:  "rps",
+				PlayedAt: "2025-01-02T10:00:00Z",
+				Rounds: []domain.RoundResult{
+					{Move1: domain.Rock, Move2: domain.Paper, Winner: "Bot"},
+				},
+			},
+			{ID: "game1", Player1: "Alice", Player2: "Bob", Winner: "Alice", RuleSet: "rps", PlayedAt: "2025-01-01T10:00:00Z"},
+		},
+	}
+	return newTestCLI(repo, &MockRandomGenerator{move: domain.Scissors})
+}
+
+func TestRunPlay(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		wantErr  string
+	}{
+		{
+			name:     "against the bot",
+			args:     []string{"play", "--player", "alice", "--moves", "r,r,r"},
+			expected: []string{"Players: alice vs Bot", "Winner: alice", "Round 2: Rock vs Scissors - alice"},
+		},
+		{
+			name:     "between players",
+			args:     []string{"play", "--mode", "pvp", "--player", "alice", "--opponent", "bob", "--moves", "r,p,s", "--opponent-moves", "p,p,r", "--format", "fixed3"},
+			expected: []string{"Players: alice vs bob", "Winner: bob", "Round 3: Scissors vs Rock - bob"},
+		},
+		{
+			name:    "not enough moves",
+			args:    []string{"play", "--player", "alice", "--moves", "r"},
+			wantErr: "game is not finished after 1 moves",
+		},
+		{
+			name:    "move outside the rule set",
+			args:    []string{"play", "--player", "alice", "--moves", "r,l"},
+			wantErr: `invalid --moves: invalid move "l" for Rock Paper Scissors`,
+		},
+		{
+			name:    "missing opponent moves",
+			args:    []string{"play", "--mode", "pvp", "--player", "alice", "--opponent", "bob", "--moves", "r"},
+			wantErr: "--moves and --opponent-moves must have the same number of moves",
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"dance"},
+			wantErr: `unknown command "dance"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := newCommandsTestCLI().Run(tt.args, &out)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Run() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("Run() output missing expected string: %s", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRunHistoryJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := newCommandsTestCLI().Run([]string{"history", "--limit", "1", "--json"}, &out); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var games []gameJSON
+	if err := json.Unmarshal(out.Bytes(), &games); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(games) != 1 || games[0].ID != "game2" || games[0].Mode != "bot" || games[0].Difficulty != "easy" {
+		t.Errorf("history --json = %+v", games)
+	}
+	if len(games[0].Rounds) != 1 || games[0].Rounds[0].Move2 != "Paper" {
+		t.Errorf("history --json rounds = %+v", games[0].Rounds)
+	}
+}
+
+func TestRunHistoryFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		ids     []string
+		wantErr string
+	}{
+		{"winner", []string{"--winner", "Alice"}, []string{"game1"}, ""},
+		{"mode", []string{"--mode", "bot"}, []string{"game2"}, ""},
+		{"player and order", []string{"--player", "Alice", "--order", "oldest"}, []string{"game1", "game2"}, ""},
+		{"date range", []string{"--from", "2025-01-02T00:00:00Z", "--to", "2025-01-03T00:00:00Z"}, []string{"game2"}, ""},
+		{"offset", []string{"--offset", "1"}, []string{"game1"}, ""},
+		{"invalid date", []string{"--from", "yesterday"}, nil, `invalid from date "yesterday", use YYYY-MM-DD or RFC 3339`},
+		{"negative offset", []string{"--offset", "-1"}, nil, "failed to get history: offset cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := newCommandsTestCLI().Run(append([]string{"history", "--json"}, tt.args...), &out)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			var games []gameJSON
+			if err := json.Unmarshal(out.Bytes(), &games); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			var ids []string
+			for _, game := range games {
+				ids = append(ids, game.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.ids, ",") {
+				t.Errorf("history %v = %v, want %v", tt.args, ids, tt.ids)
+			}
+		})
+	}
+}
+
+func TestRunStatsJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := newCommandsTestCLI().Run([]string{"stats", "--player", "Alice", "--json"}, &out); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var stats struct {
+		playerStatsJSON
+		HeadToHead []headToHeadJSON `json:"head_to_head"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &stats); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if stats.Games != 2 || stats.Wins != 1 || stats.Losses != 1 || len(stats.HeadToHead) != 2 {
+		t.Errorf("stats --json = %+v", stats)
+	}
+}
+
+func TestRunExportCSV(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "games.csv")
+	if err := newCommandsTestCLI().Run([]string{"export", "--format", "csv", "--output", output}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,played_at,player1,player2,winner,mode,rule_set,round,move1,move2,round_winner\n" +
+		"game2,2025-01-02T10:00:00Z,Alice,Bot,Bot,bot,rps,1,Rock,Paper,Bot\n" +
+		"game1,2025-01-01T10:00:00Z,Alice,Bob,Alice,pvp,rps,,,,\n"
+	if string(data) != expected {
+		t.Errorf("export --format csv =\n%s\nwant\n%s", data, expected)
+	}
+}
+
+// batchGameRepository keeps the games stored many at once.
+type batchGameRepository struct {
+	MockGameRepository
+	saved [][]*domain.Game
+}
+
+func (r *batchGameRepository) SaveGames(ctx context.Context, games []*domain.Game) error {
+	r.saved = append(r.saved, games)
+	return nil
+}
+
+func TestRunImport(t *testing.T) {
+	export := filepath.Join(t.TempDir(), "games.json")
+	if err := newCommandsTestCLI().Run([]string{"export", "--output", export}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	repo := &batchGameRepository{}
+	cli := NewGameCLI(usecase.NewGameUseCase(repo, &MockRandomGenerator{}), usecase.NewStatsUseCase(repo), nil)
+	var out bytes.Buffer
+	if err := cli.Run([]string{"import", "--input", export}, &out); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if out.String() != "Imported 2 games\n" {
+		t.Errorf("import output = %q", out.String())
+	}
+	if len(repo.saved) != 1 || len(repo.saved[0]) != 2 {
+		t.Fatalf("import saved %v, want a single batch of 2 games", repo.saved)
+	}
+	imported := repo.saved[0][0]
+	if imported.ID != "game2" || imported.Mode != domain.PlayerVsBot || imported.Winner != "Bot" {
+		t.Errorf("imported game = %+v", imported)
+	}
+	if len(imported.Rounds) != 1 || imported.Rounds[0].Move2 != domain.Paper {
+		t.Errorf("imported rounds = %+v", imported.Rounds)
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`[{"player1": "alice", "player2": "bob", "winner": "alice", "mode": "solo"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := cli.Run([]string{"import", "--input", invalid}, &bytes.Buffer{})
+	if err == nil || err.Error() != `invalid game 1: unknown game mode "solo"` {
+		t.Errorf("Run() error = %v, want invalid game 1: unknown game mode \"solo\"", err)
+	}
+}
+
+// stuckGameRepository never stores a game, like an RPC node that does not
+// answer, until the call is given up.
+type stuckGameRepository struct {
+	MockGameRepository
+	saving chan struct{}
+}
+
+func (r *stuckGameRepository) SaveGame(ctx context.Context, result *domain.Game) error {
+	close(r.saving)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func newStuckTestCLI() (*GameCLI, *stuckGameRepository) {
+	repo := &stuckGameRepository{saving: make(chan struct{})}
+	useCase := usecase.NewGameUseCase(repo, &MockRandomGenerator{})
+	return NewGameCLI(useCase, usecase.NewStatsUseCase(repo), nil), repo
+}
+
+var stuckPlayArgs = []string{"play", "--mode", "pvp", "--player", "alice", "--opponent", "bob", "--moves", "r,r", "--opponent-moves", "s,s"}
+
+func TestRunStorageTimeout(t *testing.T) {
+	cli, _ := newStuckTestCLI()
+	cli.SetTimeout(10 * time.Millisecond)
+
+	err := cli.Run(stuckPlayArgs, &bytes.Buffer{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRunStorageInterrupted(t *testing.T) {
+	cli, repo := newStuckTestCLI()
+	// Only in case the signal cannot be sent
+	cli.SetTimeout(5 * time.Second)
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	interrupted := make(chan error, 1)
+	go func() {
+		<-repo.saving
+		interrupted <- process.Signal(os.Interrupt)
+	}()
+
+	err = cli.Run(stuckPlayArgs, &bytes.Buffer{})
+	if signalErr := <-interrupted; signalErr != nil {
+		t.Skipf("cannot interrupt the test: %v", signalErr)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// feedWatcher reports its games, then loses the feed.
+type feedWatcher []*domain.Game
+
+func (w feedWatcher) WatchGames(ctx context.Context, onGame func(*domain.Game)) error {
+	for _, game := range w {
+		onGame(game)
+	}
+	return errors.New("connection lost")
+}
+
+func TestRunWatch(t *testing.T) {
+	cli := newCommandsTestCLI()
+	if err := cli.Run([]string{"watch"}, &bytes.Buffer{}); !errors.Is(err, errNoLiveFeed) {
+		t.Errorf("Run() without a live feed error = %v, want %v", err, errNoLiveFeed)
+	}
+
+	cli.SetLiveFeed(usecase.NewLiveFeedUseCase(feedWatcher{
+		{ID: "0xa1", Player1: "alice", Player2: "bob", Winner: "bob", RuleSet: "rps", PlayedAt: "2025-01-01T10:00:00Z"},
+		{ID: "0xb2", Player1: "carol", Player2: "dave", Winner: "Draw", RuleSet: "rps", PlayedAt: "2025-01-01T10:00:12Z"},
+	}))
+
+	var out bytes.Buffer
+	err := cli.Run([]string{"watch", "--player", "bob", "--json"}, &out)
+	if err == nil || err.Error() != "live feed stopped: connection lost" {
+		t.Errorf("Run() error = %v, want live feed stopped: connection lost", err)
+	}
+	var game gameJSON
+	if err := json.Unmarshal(out.Bytes(), &game); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if game.ID != "0xa1" || game.Winner != "bob" {
+		t.Errorf("watch --json = %+v", game)
+	}
+
+	out.Reset()
+	cli.Run([]string{"watch"}, &out)
+	for _, expected := range []string{"Players: alice vs bob", "Players: carol vs dave", "Winner: Draw"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("watch output missing expected string: %s", expected)
+		}
+	}
+}
+
+type outboxEntries []*domain.OutboxEntry
+
+func (e outboxEntries) GetOutboxEntries(ctx context.Context) ([]*domain.OutboxEntry, error) {
+	return e, nil
+}
+
+// queuedRepository queues the games it saves, and has them stored by the
+// time the queue is read, with the status the worker would give them.
+type queuedRepository struct {
+	*MockGameRepository
+	status   domain.OutboxStatus
+	attempts int
+	entries  []*domain.OutboxEntry
+}
+
+func (r *queuedRepository) SaveGame(ctx context.Context, game *domain.Game) error {
+	r.entries = append(r.entries, &domain.OutboxEntry{Game: game, Status: domain.OutboxPending})
+	return nil
+}
+
+func (r *queuedRepository) GetOutboxEntries(ctx context.Context) ([]*domain.OutboxEntry, error) {
+	for _, entry := range r.entries {
+		entry.Status = r.status
+		entry.Attempts = r.attempts
+		if r.status == domain.OutboxConfirmed {
+			entry.TxHash = "0xa1"
+		} else if r.attempts > 0 {
+			entry.LastError = "connection refused"
+		}
+	}
+	return r.entries, nil
+}
+
+func TestRunPlayWaitsForTheOnChainSave(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   domain.OutboxStatus
+		attempts int
+		expected string
+		wantErr  string
+	}{
+		{name: "stored", status: domain.OutboxConfirmed, attempts: 1, expected: "Stored on-chain in transaction 0xa1"},
+		{name: "failed attempt", status: domain.OutboxPending, attempts: 1, expected: "On-chain save still pending after 1 attempts (connection refused), it is retried on the next run"},
+		{name: "not attempted in time", status: domain.OutboxPending, expected: "On-chain save still pending, it is stored on the next run"},
+		{name: "reverted", status: domain.OutboxFailed, attempts: 1, wantErr: "could not be stored on-chain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &queuedRepository{MockGameRepository: &MockGameRepository{}, status: tt.status, attempts: tt.attempts}
+			cli := NewGameCLI(usecase.NewGameUseCase(repo, &MockRandomGenerator{move: domain.Scissors}), usecase.NewStatsUseCase(repo), nil)
+			cli.SetOutbox(usecase.NewOutboxUseCase(repo))
+			cli.SetTimeout(50 * time.Millisecond)
+
+			var out bytes.Buffer
+			err := cli.Run([]string{"play", "--player", "alice", "--moves", "r,r"}, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !strings.Contains(out.String(), tt.expected) {
+				t.Errorf("play output missing expected string: %s\n%s", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestRunOutbox(t *testing.T) {
+	cli := newCommandsTestCLI()
+	if err := cli.Run([]string{"outbox"}, &bytes.Buffer{}); !errors.Is(err, errNoOutbox) {
+		t.Errorf("Run() without an outbox error = %v, want %v", err, errNoOutbox)
+	}
+
+	cli.SetOutbox(usecase.NewOutboxUseCase(outboxEntries{
+		{
+			Game:      &domain.Game{ID: "g2", Player1: "carol", Player2: "dave", Winner: "Draw", RuleSet: "rps", PlayedAt: "2025-01-01T10:01:00Z"},
+			Status:    domain.OutboxPending,
+			Attempts:  3,
+			LastError: "failed to estimate gas: connection refused",
+			QueuedAt:  "2025-01-01T10:01:00Z",
+		},
+		{
+			Game:     &domain.Game{ID: "g1", Player1: "alice", Player2: "bob", Winner: "bob", RuleSet: "rps", PlayedAt: "2025-01-01T10:00:00Z"},
+			Status:   domain.OutboxConfirmed,
+			TxHash:   "0xa1",
+			Attempts: 1,
+			QueuedAt: "2025-01-01T10:00:00Z",
+		},
+	}))
+
+	var out bytes.Buffer
+	if err := cli.Run([]string{"outbox"}, &out); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for _, expected := range []string{"Status: pending", "Last error: failed to estimate gas: connection refused (3 attempts)", "Status: confirmed", "Transaction: 0xa1"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("outbox output missing expected string: %s", expected)
+		}
+	}
+
+	out.Reset()
+	if err := cli.Run([]string{"outbox", "--status", "confirmed", "--json"}, &out); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	var entries []outboxEntryJSON
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Game.ID != "g1" || entries[0].TxHash != "0xa1" {
+		t.Errorf("outbox --status confirmed --json = %+v", entries)
+	}
+
+	if err := cli.Run([]string{"outbox", "--status", "lost"}, &bytes.Buffer{}); err == nil {
+		t.Error("Run() with an unknown status succeeded")
+	}
+}
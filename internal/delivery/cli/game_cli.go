@@ -2,173 +2,527 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"time"
 
 	"protofire-game/internal/domain"
+	"protofire-game/internal/randomness"
 	"protofire-game/internal/usecase"
 )
 
+const (
+	leaderboardSize = 10
+	historyPageSize = 10
+)
+
+// DefaultTimeout is how long a call to the storage may take before it is
+// given up, long enough for a transaction to be mined.
+const DefaultTimeout = 2 * time.Minute
+
 type GameCLI struct {
-	useCase *usecase.GameUseCase
-	reader  *bufio.Reader
+	useCase       *usecase.GameUseCase
+	statsUseCase  *usecase.StatsUseCase
+	ratingUseCase *usecase.RatingUseCase
+	reader        *bufio.Reader
+	terminal      terminal // nil when stdin is not a terminal
+	tournaments   *usecase.TournamentUseCase
+	liveFeed      *usecase.LiveFeedUseCase
+	outbox        *usecase.OutboxUseCase
+	timeout       time.Duration
 }
 
-func NewGameCLI(useCase *usecase.GameUseCase) *GameCLI {
+func NewGameCLI(useCase *usecase.GameUseCase, statsUseCase *usecase.StatsUseCase, ratingUseCase *usecase.RatingUseCase) *GameCLI {
+	reader := bufio.NewReader(os.Stdin)
 	return &GameCLI{
-		useCase: useCase,
-		reader:  bufio.NewReader(os.Stdin),
+		useCase:       useCase,
+		statsUseCase:  statsUseCase,
+		ratingUseCase: ratingUseCase,
+		reader:        reader,
+		terminal:      newTerminal(os.Stdin, reader, os.Stdout),
+		timeout:       DefaultTimeout,
+	}
+}
+
+// SetTimeout sets how long a call to the storage may take, 0 for no limit.
+func (c *GameCLI) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// storageContext returns the context of a call to the storage, done after the
+// timeout or when Ctrl-C is pressed. Ctrl-C only gives up the call: while no
+// call is running it exits as usual.
+func (c *GameCLI) storageContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if c.timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	return ctx, func() {
+		cancel()
+		stop()
 	}
 }
 
-func (c *GameCLI) Start() {
+// Start runs the game menu until the players exit, or returns the error of
+// reading a hidden move: ErrInterrupted when Ctrl-C is pressed, and io.EOF
+// once the input is closed.
+func (c *GameCLI) Start() error {
+	if err := c.offerUnfinishedGames(); err != nil {
+		return err
+	}
+
+	options := []menuOption{
+		{"Player vs Player", c.playPlayerVsPlayer},
+		{"Player vs Bot", c.playPlayerVsBot},
+		{"View Game History", withoutError(c.showHistory)},
+		{"Leaderboard", withoutError(c.showLeaderboard)},
+		{"Player stats", withoutError(c.showPlayerStats)},
+		{"Rankings", withoutError(c.showRankings)},
+		{"Verify game", withoutError(c.verifyGame)},
+		{"Tournaments", c.showTournaments},
+	}
+	// The live feed and the on-chain saves are only offered with the storage
+	// that has them.
+	if c.liveFeed != nil {
+		options = append(options, menuOption{"Live feed", withoutError(c.showLiveFeed)})
+	}
+	if c.outbox != nil {
+		options = append(options, menuOption{"On-chain saves", withoutError(c.showOutbox)})
+	}
+
 	for {
 		fmt.Println("\nRock Paper Scissors Game")
-		fmt.Println("1. Player vs Player")
-		fmt.Println("2. Player vs Bot")
-		fmt.Println("3. View Game History")
-		fmt.Println("4. Exit")
+		for i, option := range options {
+			fmt.Printf("%d. %s\n", i+1, option.label)
+		}
+		fmt.Printf("%d. Exit\n", len(options)+1)
 		fmt.Print("Choose an option: ")
 
-		choice := c.readInput()
-
-		switch choice {
-		case "1":
-			c.playPlayerVsPlayer()
-		case "2":
-			c.playPlayerVsBot()
-		case "3":
-			c.showHistory()
-		case "4":
+		choice, err := strconv.Atoi(c.readInput())
+		switch {
+		case err != nil || choice < 1 || choice > len(options)+1:
+			fmt.Println("Invalid option, please try again")
+		case choice == len(options)+1:
 			fmt.Println("Thanks for playing!")
-			return
+			return nil
 		default:
-			fmt.Println("Invalid option, please try again")
+			if err := options[choice-1].run(); err != nil {
+				return err
+			}
 		}
 	}
 }
 
-func (c *GameCLI) playPlayerVsPlayer() {
+// menuOption is an entry of the game menu.
+type menuOption struct {
+	label string
+	run   func() error
+}
+
+func withoutError(show func()) func() error {
+	return func() error {
+		show()
+		return nil
+	}
+}
+
+func (c *GameCLI) playPlayerVsPlayer() error {
 	fmt.Print("Enter Player 1 name: ")
 	player1 := c.readPlayerName()
 
 	fmt.Print("Enter Player 2 name: ")
 	player2 := c.readPlayerName()
 
-	if err := c.useCase.StartNewGame(domain.PlayerVsPlayer, player1, player2); err != nil {
+	format := c.readMatchFormat()
+	rules := c.readRuleSet()
+
+	if err := c.useCase.StartNewGame(domain.PlayerVsPlayer, format, rules, player1, player2); err != nil {
 		fmt.Printf("Error starting game: %v\n", err)
-		return
+		return nil
 	}
 
-	var currentRound int
-	var game *domain.Game
-	var err error
+	_, err := c.playCurrentGame(domain.PlayerVsPlayer, format, rules, player1, player2)
+	return err
+}
+
+func (c *GameCLI) playPlayerVsBot() error {
+	fmt.Print("Enter your name: ")
+	player1 := c.readPlayerName()
+
+	format := c.readMatchFormat()
+	rules := c.readRuleSet()
+
+	if err := c.useCase.SetBotDifficulty(c.readDifficulty()); err != nil {
+		fmt.Printf("Error starting game: %v\n", err)
+		return nil
+	}
 
-	fmt.Println("\nBest of 3 rounds! Game ends early if a player wins the first two rounds.")
+	if err := c.useCase.StartNewGame(domain.PlayerVsBot, format, rules, player1, "Bot"); err != nil {
+		fmt.Printf("Error starting game: %v\n", err)
+		return nil
+	}
 
-	for currentRound < 3 {
-		currentRound++
-		fmt.Printf("\nRound %d:\n", currentRound)
+	_, err := c.playCurrentGame(domain.PlayerVsBot, format, rules, player1, "Bot")
+	return err
+}
 
-		move1 := c.getPlayerMove(player1)
-		move2 := c.getPlayerMove(player2)
+// playCurrentGame plays the game in progress on the use case, reading the
+// moves of the players at the keyboard.
+func (c *GameCLI) playCurrentGame(mode domain.GameType, format domain.MatchFormat, rules *domain.RuleSet, player1, player2 string) (*domain.Game, error) {
+	if mode == domain.PlayerVsBot {
+		return c.playRounds(format, func() (domain.Move, domain.Move, error) {
+			return c.getPlayerMove(rules, player1), 0, nil // 0 is a placeholder, bot move is generated in usecase
+		})
+	}
+	return c.playRounds(format, c.readHiddenMoves(rules, player1, player2))
+}
 
-		game, err = c.useCase.PlayRound(move1, move2)
+// readHiddenMoves reads the moves of two players sharing the keyboard.
+func (c *GameCLI) readHiddenMoves(rules *domain.RuleSet, player1, player2 string) func() (domain.Move, domain.Move, error) {
+	return func() (domain.Move, domain.Move, error) {
+		move1, err := c.getHiddenMove(rules, player1)
+		if err != nil {
+			return 0, 0, err
+		}
+		move2, err := c.getHiddenMove(rules, player2)
+		return move1, move2, err
+	}
+}
+
+// playRounds plays the game started on the use case until it is over, and
+// returns it, or nil if it could not be played to the end. It fails when the
+// moves cannot be read, leaving the game unfinished.
+func (c *GameCLI) playRounds(format domain.MatchFormat, readMoves func() (domain.Move, domain.Move, error)) (*domain.Game, error) {
+	fmt.Printf("\n%s\n", format.Description())
+
+	for {
+		currentRound := len(c.useCase.GetCurrentRounds()) + 1
+		if maxRounds := format.MaxRounds(); maxRounds > 0 {
+			fmt.Printf("\nRound %d of %d:\n", currentRound, maxRounds)
+		} else {
+			fmt.Printf("\nRound %d:\n", currentRound)
+		}
+
+		if commitment := c.useCase.GetBotCommitment(); commitment != "" {
+			fmt.Printf("Bot has committed to its move: %s\n", commitment)
+		}
+
+		move1, move2, err := readMoves()
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := c.storageContext()
+		game, err := c.useCase.PlayRound(ctx, move1, move2)
+		cancel()
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
-			return
+			// A game that could not be stored is still in progress, without
+			// the round that ended it.
+			if c.useCase.GetCurrentGame() == nil {
+				return nil, nil
+			}
+			fmt.Print("Enter y to play the round again, or press Enter to give up the game: ")
+			if !strings.EqualFold(c.readInput(), "y") {
+				return nil, nil
+			}
+			continue
 		}
 
 		c.displayResult(game)
 
 		if game.Winner != "" {
-			if currentRound < 3 {
+			if game.Winner != "Draw" && (format.MaxRounds() == 0 || currentRound < format.MaxRounds()) {
 				fmt.Printf("\n%s won in %d rounds!\n", game.Winner, currentRound)
 			}
-			return
+			return game, nil
 		}
 	}
 }
 
-func (c *GameCLI) playPlayerVsBot() {
-	fmt.Print("Enter your name: ")
-	player1 := c.readPlayerName()
+func (c *GameCLI) getPlayerMove(rules *domain.RuleSet, player string) domain.Move {
+	move, _ := c.readMove(rules, player, func() (string, error) {
+		return c.readInput(), nil
+	})
+	return move
+}
 
-	if err := c.useCase.StartNewGame(domain.PlayerVsBot, player1, "Bot"); err != nil {
-		fmt.Printf("Error starting game: %v\n", err)
-		return
+// getHiddenMove reads the move of a player sharing the keyboard with their
+// opponent: the keyboard is passed to them, their move is masked and the
+// screen cleared once they are done. Without a terminal moves are read as
+// usual. It fails when the terminal cannot be read, with ErrInterrupted on
+// Ctrl-C and io.EOF once the input is closed.
+func (c *GameCLI) getHiddenMove(rules *domain.RuleSet, player string) (domain.Move, error) {
+	if c.terminal == nil {
+		return c.getPlayerMove(rules, player), nil
 	}
 
-	var currentRound int
-	var game *domain.Game
-	var err error
+	fmt.Printf("Pass the keyboard to %s and press Enter when ready.", player)
+	if _, err := c.terminal.ReadHidden(); err != nil {
+		return 0, err
+	}
+	move, err := c.readMove(rules, player, c.terminal.ReadHidden)
+	if err != nil {
+		return 0, err
+	}
+	c.terminal.Clear()
+	return move, nil
+}
 
-	fmt.Println("\nBest of 3 rounds! Game ends early if a player wins the first two rounds.")
+func (c *GameCLI) readMove(rules *domain.RuleSet, player string, read func() (string, error)) (domain.Move, error) {
+	names := make([]string, len(rules.Moves))
+	shortcuts := make([]string, len(rules.Moves))
+	for i, m := range rules.Moves {
+		names[i] = m.String()
+		shortcuts[i] = strings.ToUpper(m.Shortcut())
+	}
 
-	for currentRound < 3 {
-		currentRound++
-		fmt.Printf("\nRound %d:\n", currentRound)
+	for {
+		fmt.Printf("%s, enter your move (%s): ", player, strings.Join(names, "/"))
+		input, err := read()
+		if err != nil {
+			return 0, err
+		}
+		move, err := rules.ParseMove(input)
+		if err != nil {
+			fmt.Printf("Invalid move. Please enter %s, or %s (or full word)\n",
+				strings.Join(shortcuts[:len(shortcuts)-1], ", "), shortcuts[len(shortcuts)-1])
+			continue
+		}
+		return move, nil
+	}
+}
 
-		move1 := c.getPlayerMove(player1)
+// showHistory pages through the stored games, most recent first, of every
+// player or of the one given.
+func (c *GameCLI) showHistory() {
+	fmt.Print("Filter by player (press Enter for all players): ")
+	query := domain.HistoryQuery{Player: c.readInput(), Limit: historyPageSize}
 
-		game, err = c.useCase.PlayRound(move1, 0) // 0 is a placeholder, bot move is generated in usecase
+	for {
+		ctx, cancel := c.storageContext()
+		page, err := c.useCase.QueryHistory(ctx, query)
+		cancel()
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
+			fmt.Printf("Error getting history: %v\n", err)
 			return
 		}
 
-		c.displayResult(game)
-
-		if game.Winner != "" {
-			if currentRound < 3 {
-				fmt.Printf("\n%s won in %d rounds!\n", game.Winner, currentRound)
+		if page.Total == 0 {
+			if query.Player != "" {
+				fmt.Printf("No games played by %s yet!\n", query.Player)
+			} else {
+				fmt.Println("No games played yet!")
 			}
 			return
 		}
-	}
-}
 
-func (c *GameCLI) getPlayerMove(player string) domain.Move {
-	for {
-		fmt.Printf("%s, enter your move (Rock/Paper/Scissors): ", player)
-		input := strings.ToLower(c.readInput())
-
-		switch input {
-		case "rock", "r":
-			return domain.Rock
-		case "paper", "p":
-			return domain.Paper
-		case "scissors", "s":
-			return domain.Scissors
+		fmt.Println("\nGame History:")
+		for _, game := range page.Games {
+			printGame(os.Stdout, game)
+		}
+		fmt.Printf("Games %d-%d of %d\n", query.Offset+1, query.Offset+len(page.Games), page.Total)
+
+		hasNext := query.Offset+len(page.Games) < page.Total
+		hasPrevious := query.Offset > 0
+		if !hasNext && !hasPrevious {
+			return
+		}
+
+		var options []string
+		if hasNext {
+			options = append(options, "n next page")
+		}
+		if hasPrevious {
+			options = append(options, "p previous page")
+		}
+		fmt.Printf("%s, or press Enter to go back: ", strings.Join(options, ", "))
+
+		switch input := strings.ToLower(c.readInput()); {
+		case input == "":
+			return
+		case input == "n" && hasNext:
+			query.Offset += historyPageSize
+		case input == "p" && hasPrevious:
+			query.Offset = max(query.Offset-historyPageSize, 0)
 		default:
-			fmt.Println("Invalid move. Please enter R, P, or S (or full word)")
+			fmt.Println("Invalid option, please try again")
 		}
 	}
 }
 
-func (c *GameCLI) showHistory() {
-	history, err := c.useCase.GetHistory()
+func printGame(w io.Writer, game *domain.Game) {
+	fmt.Fprintf(w, "\nGame ID: %s\n", game.ID)
+	fmt.Fprintf(w, "Players: %s vs %s\n", game.Player1, game.Player2)
+	if game.Forfeit {
+		fmt.Fprintf(w, "Winner: %s (forfeit)\n", game.Winner)
+	} else {
+		fmt.Fprintf(w, "Winner: %s\n", game.Winner)
+	}
+	if rules, err := domain.RuleSetByName(game.RuleSet); err == nil {
+		fmt.Fprintf(w, "Rules: %s\n", rules.DisplayName)
+	}
+	if game.Seed != nil {
+		fmt.Fprintf(w, "Bot: %s, seed %d\n", game.Difficulty, *game.Seed)
+	}
+	fmt.Fprintf(w, "Played at: %v\n", game.PlayedAt)
+	if game.Submitter != "" {
+		fmt.Fprintf(w, "Stored by: %s\n", game.Submitter)
+	}
+	for i, round := range game.Rounds {
+		fmt.Fprintf(w, "Round %d: %s vs %s - %s\n", i+1, round.Move1, round.Move2, round.Winner)
+	}
+	fmt.Fprintln(w, "------------------------")
+}
+
+func (c *GameCLI) showLeaderboard() {
+	ctx, cancel := c.storageContext()
+	defer cancel()
+
+	leaderboard, err := c.statsUseCase.GetLeaderboard(ctx, leaderboardSize)
 	if err != nil {
-		fmt.Printf("Error getting history: %v\n", err)
+		fmt.Printf("Error getting leaderboard: %v\n", err)
 		return
 	}
 
-	if len(history) == 0 {
+	if len(leaderboard) == 0 {
 		fmt.Println("No games played yet!")
 		return
 	}
 
-	fmt.Println("\nGame History:")
-	for _, game := range history {
-		fmt.Printf("\nGame ID: %s\n", game.ID)
-		fmt.Printf("Players: %s vs %s\n", game.Player1, game.Player2)
-		fmt.Printf("Winner: %s\n", game.Winner)
-		fmt.Printf("Played at: %v\n", game.PlayedAt)
-		fmt.Println("------------------------")
+	printLeaderboard(os.Stdout, leaderboard)
+}
+
+func printLeaderboard(w io.Writer, leaderboard []*domain.PlayerStats) {
+	fmt.Fprintln(w, "\nLeaderboard:")
+	fmt.Fprintf(w, "%-4s %-15s %6s %6s %6s %6s %9s %7s\n", "#", "Player", "Games", "Wins", "Losses", "Draws", "Win rate", "Streak")
+	for i, stats := range leaderboard {
+		fmt.Fprintf(w, "%-4d %-15s %6d %6d %6d %6d %8.1f%% %7d\n",
+			i+1, stats.Player, stats.Games, stats.Wins, stats.Losses, stats.Draws, stats.WinRate()*100, stats.LongestStreak)
+	}
+}
+
+func (c *GameCLI) showPlayerStats() {
+	fmt.Print("Enter player name: ")
+	player := c.readPlayerName()
+
+	ctx, cancel := c.storageContext()
+	defer cancel()
+
+	stats, headToHead, err := c.statsUseCase.GetPlayerStats(ctx, player)
+	if err != nil {
+		fmt.Printf("Error getting player stats: %v\n", err)
+		return
+	}
+
+	if stats.Games == 0 {
+		fmt.Printf("%s hasn't played any games yet!\n", player)
+		return
+	}
+
+	printPlayerStats(os.Stdout, stats, headToHead)
+}
+
+func printPlayerStats(w io.Writer, stats *domain.PlayerStats, headToHead []*domain.HeadToHead) {
+	fmt.Fprintf(w, "\nStats for %s:\n", stats.Player)
+	fmt.Fprintf(w, "Games: %d\n", stats.Games)
+	fmt.Fprintf(w, "Wins: %d, Losses: %d, Draws: %d\n", stats.Wins, stats.Losses, stats.Draws)
+	fmt.Fprintf(w, "Win rate: %.1f%%\n", stats.WinRate()*100)
+	fmt.Fprintf(w, "Longest win streak: %d\n", stats.LongestStreak)
+	if stats.FavoriteMove != "" {
+		fmt.Fprintf(w, "Favorite move: %s\n", stats.FavoriteMove)
+	}
+
+	if len(headToHead) > 0 {
+		fmt.Fprintln(w, "\nHead-to-head:")
+		for _, record := range headToHead {
+			fmt.Fprintf(w, "vs %-15s %d W / %d L / %d D\n", record.Opponent, record.Wins, record.Losses, record.Draws)
+		}
+	}
+}
+
+func (c *GameCLI) showRankings() {
+	ctx, cancel := c.storageContext()
+	defer cancel()
+
+	rankings, err := c.ratingUseCase.GetRankings(ctx)
+	if err != nil {
+		fmt.Printf("Error getting rankings: %v\n", err)
+		return
+	}
+
+	if len(rankings) == 0 {
+		fmt.Println("No rated games played yet!")
+		return
+	}
+
+	fmt.Printf("\nRankings (%s):\n", c.ratingUseCase.SystemName())
+	fmt.Printf("%-4s %-15s %8s %6s %6s\n", "#", "Player", "Rating", "RD", "Games")
+	for i, rating := range rankings {
+		deviation := "-"
+		if rating.Deviation > 0 {
+			deviation = fmt.Sprintf("%.0f", rating.Deviation)
+		}
+		fmt.Printf("%-4d %-15s %8.0f %6s %6d\n", i+1, rating.Player, rating.Rating, deviation, rating.Games)
 	}
 }
 
+func (c *GameCLI) verifyGame() {
+	fmt.Print("Enter game ID: ")
+	gameID := c.readInput()
+
+	ctx, cancel := c.storageContext()
+	game, err := c.useCase.VerifyGame(ctx, gameID)
+	cancel()
+	if game == nil {
+		fmt.Printf("Error verifying game: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nGame %s: %s vs %s\n", game.ID, game.Player1, game.Player2)
+	for i, round := range game.Rounds {
+		status := "OK"
+		if roundErr := round.VerifyCommitment(); roundErr != nil {
+			status = fmt.Sprintf("FAILED (%v)", roundErr)
+		}
+		fmt.Printf("Round %d: bot played %s, commitment %s - %s\n", i+1, round.Move2, round.Commitment, status)
+	}
+
+	if game.Seed != nil {
+		c.replayGame(game)
+	}
+
+	if err != nil {
+		fmt.Printf("Verification failed: %v\n", err)
+		return
+	}
+	fmt.Println("All bot moves match their commitments.")
+}
+
+// replayGame replays a seeded bot game and reports whether the bot would
+// play the same moves again.
+func (c *GameCLI) replayGame(game *domain.Game) {
+	moves, err := randomness.ReplayBotMoves(game)
+	if err != nil {
+		fmt.Printf("Error replaying game: %v\n", err)
+		return
+	}
+
+	for i, move := range moves {
+		if move != game.Rounds[i].Move2 {
+			fmt.Printf("Replay with seed %d differs in round %d: bot would play %s\n", *game.Seed, i+1, move)
+			return
+		}
+	}
+	fmt.Printf("Replay with seed %d (%s) reproduces every bot move.\n", *game.Seed, game.Difficulty)
+}
+
 func (c *GameCLI) readInput() string {
 	input, _ := c.reader.ReadString('\n')
 	return strings.TrimSpace(input)
@@ -185,6 +539,61 @@ func (c *GameCLI) readPlayerName() string {
 	}
 }
 
+func (c *GameCLI) readMatchFormat() domain.MatchFormat {
+	for {
+		fmt.Printf("Match format - bo<N> best of N, ft<N> first to N wins, fixed<N> N rounds, sd<N> best of N with sudden death [%s]: ",
+			domain.DefaultMatchFormat.Code())
+		input := c.readInput()
+		if input == "" {
+			return domain.DefaultMatchFormat
+		}
+		format, err := domain.ParseMatchFormat(input)
+		if err != nil {
+			fmt.Printf("Invalid match format: %v\n", err)
+			continue
+		}
+		return format
+	}
+}
+
+func (c *GameCLI) readRuleSet() *domain.RuleSet {
+	options := make([]string, 0, len(domain.RuleSets()))
+	for _, rules := range domain.RuleSets() {
+		options = append(options, fmt.Sprintf("%s %s", rules.Name, rules.DisplayName))
+	}
+
+	for {
+		fmt.Printf("Rule set - %s [%s]: ", strings.Join(options, ", "), domain.DefaultRuleSet.Name)
+		input := c.readInput()
+		if input == "" {
+			return domain.DefaultRuleSet
+		}
+		rules, err := domain.RuleSetByName(input)
+		if err != nil {
+			fmt.Printf("Invalid rule set: %v\n", err)
+			continue
+		}
+		return rules
+	}
+}
+
+func (c *GameCLI) readDifficulty() domain.Difficulty {
+	for {
+		fmt.Printf("Bot difficulty - %s, %s, %s, %s [%s]: ",
+			domain.Easy, domain.Medium, domain.Hard, domain.Expert, domain.Easy)
+		input := c.readInput()
+		if input == "" {
+			return domain.Easy
+		}
+		difficulty, err := domain.ParseDifficulty(input)
+		if err != nil {
+			fmt.Printf("Invalid difficulty: %v\n", err)
+			continue
+		}
+		return difficulty
+	}
+}
+
 func (c *GameCLI) validatePlayerName(name string) error {
 	if len(name) == 0 {
 		return fmt.Errorf("name cannot be empty")
@@ -199,12 +608,18 @@ func (c *GameCLI) displayResult(result *domain.Game) {
 	fmt.Printf("\nGame Result:\n")
 	fmt.Printf("%s vs %s\n", result.Player1, result.Player2)
 
-	if len(c.useCase.GetCurrentRounds()) > 0 {
-		lastRound := c.useCase.GetCurrentRounds()[len(c.useCase.GetCurrentRounds())-1]
+	if len(result.Rounds) > 0 {
+		lastRound := result.Rounds[len(result.Rounds)-1]
 		fmt.Printf("Round moves: %s vs %s\n", lastRound.Move1, lastRound.Move2)
+		if rules, err := domain.RuleSetByName(result.RuleSet); err == nil {
+			fmt.Println(rules.Describe(lastRound.Move1, lastRound.Move2))
+		}
 		if lastRound.Winner != "" {
 			fmt.Printf("Round winner: %s\n", lastRound.Winner)
 		}
+		if lastRound.Commitment != "" {
+			fmt.Printf("Bot reveal: %s with salt %s\n", lastRound.Move2, lastRound.Salt)
+		}
 	}
 
 	if result.Winner != "" {
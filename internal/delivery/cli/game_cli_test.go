@@ -3,54 +3,132 @@ package cli
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"strings"
 	"testing"
 
 	"protofire-game/internal/domain"
+	"protofire-game/internal/rating"
 	"protofire-game/internal/usecase"
 )
 
-// MockGameRepository implements domain.GameRepository for testing
+// MockGameRepository implements domain.GameRepository and domain.StatsRepository for testing
 type MockGameRepository struct {
 	history []*domain.Game
 	err     error
 }
 
-func (m *MockGameRepository) SaveGame(result *domain.Game) error {
+func (m *MockGameRepository) SaveGame(ctx context.Context, result *domain.Game) error {
 	return m.err
 }
 
-func (m *MockGameRepository) GetGameHistory() ([]*domain.Game, error) {
+func (m *MockGameRepository) GetGameHistory(ctx context.Context) ([]*domain.Game, error) {
 	return m.history, m.err
 }
 
+func (m *MockGameRepository) GetGame(ctx context.Context, id string) (*domain.Game, error) {
+	for _, game := range m.history {
+		if game.ID == id {
+			return game, m.err
+		}
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return nil, fmt.Errorf("game %s %w", id, domain.ErrNotFound)
+}
+
+func (m *MockGameRepository) QueryGameHistory(ctx context.Context, query domain.HistoryQuery) (*domain.HistoryPage, error) {
+	return domain.QueryGames(m.history, query), m.err
+}
+
+func (m *MockGameRepository) GetLeaderboard(ctx context.Context, limit int) ([]*domain.PlayerStats, error) {
+	return domain.ComputeLeaderboard(m.history, limit), m.err
+}
+
+func (m *MockGameRepository) GetPlayerStats(ctx context.Context, player string) (*domain.PlayerStats, error) {
+	return domain.ComputePlayerStats(m.history, player), m.err
+}
+
+func (m *MockGameRepository) GetHeadToHead(ctx context.Context, player string) ([]*domain.HeadToHead, error) {
+	return domain.ComputeHeadToHead(m.history, player), m.err
+}
+
 // MockRandomGenerator implements domain.RandomGenerator for testing
 type MockRandomGenerator struct {
 	move domain.Move
 }
 
-func (m *MockRandomGenerator) GenerateMove() domain.Move {
+func (m *MockRandomGenerator) GenerateMove(rules *domain.RuleSet) domain.Move {
 	return m.move
 }
 
+func newTestCLI(repo *MockGameRepository, randGen *MockRandomGenerator) *GameCLI {
+	useCase := usecase.NewGameUseCase(repo, randGen)
+	ratingUseCase := usecase.NewRatingUseCase(repo, nil, rating.NewElo(rating.DefaultEloK), false)
+	return NewGameCLI(useCase, usecase.NewStatsUseCase(repo), ratingUseCase)
+}
+
 func TestNewGameCLI(t *testing.T) {
 	repo := &MockGameRepository{}
 	randGen := &MockRandomGenerator{}
-	useCase := usecase.NewGameUseCase(repo, randGen)
-	cli := NewGameCLI(useCase)
+	cli := newTestCLI(repo, randGen)
 	if cli == nil {
 		t.Error("NewGameCLI returned nil")
 	}
 }
 
+func TestMenuOffersOnChainOptionsWithTheirStorage(t *testing.T) {
+	start := func(cli *GameCLI, input string) string {
+		cli.reader = bufio.NewReader(strings.NewReader(input))
+
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := cli.Start()
+
+		w.Close()
+		os.Stdout = old
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+
+		if err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		return buf.String()
+	}
+
+	cli := newTestCLI(&MockGameRepository{}, &MockRandomGenerator{})
+	output := start(cli, "9\n")
+	for _, option := range []string{"Live feed", "On-chain saves"} {
+		if strings.Contains(output, option) {
+			t.Errorf("menu without the on-chain storage offers %s", option)
+		}
+	}
+	if !strings.Contains(output, "9. Exit") || !strings.Contains(output, "Thanks for playing!") {
+		t.Errorf("menu without the on-chain storage does not exit with 9:\n%s", output)
+	}
+
+	cli = newTestCLI(&MockGameRepository{}, &MockRandomGenerator{})
+	cli.SetLiveFeed(usecase.NewLiveFeedUseCase(feedWatcher{}))
+	cli.SetOutbox(usecase.NewOutboxUseCase(outboxEntries{}))
+	output = start(cli, "10\n11\n")
+	for _, expected := range []string{"9. Live feed", "10. On-chain saves", "11. Exit", "No games queued yet!", "Thanks for playing!"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("menu with the on-chain storage missing expected string: %s", expected)
+		}
+	}
+}
+
 func TestValidatePlayerName(t *testing.T) {
 	repo := &MockGameRepository{}
 	randGen := &MockRandomGenerator{}
-	useCase := usecase.NewGameUseCase(repo, randGen)
-	cli := NewGameCLI(useCase)
+	cli := newTestCLI(repo, randGen)
 
 	tests := []struct {
 		name    string
@@ -75,26 +153,29 @@ func TestValidatePlayerName(t *testing.T) {
 func TestGetPlayerMove(t *testing.T) {
 	tests := []struct {
 		name     string
+		rules    *domain.RuleSet
 		input    string
 		expected domain.Move
 	}{
-		{"rock", "rock", domain.Rock},
-		{"paper", "paper", domain.Paper},
-		{"scissors", "scissors", domain.Scissors},
-		{"r", "r", domain.Rock},
-		{"p", "p", domain.Paper},
-		{"s", "s", domain.Scissors},
+		{"rock", domain.RockPaperScissors, "rock", domain.Rock},
+		{"paper", domain.RockPaperScissors, "paper", domain.Paper},
+		{"scissors", domain.RockPaperScissors, "scissors", domain.Scissors},
+		{"r", domain.RockPaperScissors, "r", domain.Rock},
+		{"p", domain.RockPaperScissors, "p", domain.Paper},
+		{"s", domain.RockPaperScissors, "s", domain.Scissors},
+		{"lizard not in rps", domain.RockPaperScissors, "lizard\nrock", domain.Rock},
+		{"lizard", domain.RockPaperScissorsLizardSpock, "Lizard", domain.Lizard},
+		{"k", domain.RockPaperScissorsLizardSpock, "k", domain.Spock},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := &MockGameRepository{}
 			randGen := &MockRandomGenerator{}
-			useCase := usecase.NewGameUseCase(repo, randGen)
-			cli := NewGameCLI(useCase)
+			cli := newTestCLI(repo, randGen)
 			cli.reader = bufio.NewReader(strings.NewReader(tt.input + "\n"))
 
-			got := cli.getPlayerMove("TestPlayer")
+			got := cli.getPlayerMove(tt.rules, "TestPlayer")
 			if got != tt.expected {
 				t.Errorf("getPlayerMove() = %v, want %v", got, tt.expected)
 			}
@@ -102,11 +183,226 @@ func TestGetPlayerMove(t *testing.T) {
 	}
 }
 
+// mockTerminal plays back typed lines and counts screen clears. Once the
+// lines run out it fails with err, or io.EOF.
+type mockTerminal struct {
+	lines   []string
+	err     error
+	cleared int
+}
+
+func (m *mockTerminal) ReadHidden() (string, error) {
+	if len(m.lines) == 0 {
+		if m.err != nil {
+			return "", m.err
+		}
+		return "", io.EOF
+	}
+	line := m.lines[0]
+	m.lines = m.lines[1:]
+	return line, nil
+}
+
+func (m *mockTerminal) Clear() {
+	m.cleared++
+}
+
+func TestGetHiddenMove(t *testing.T) {
+	repo := &MockGameRepository{}
+	randGen := &MockRandomGenerator{}
+	cli := newTestCLI(repo, randGen)
+	term := &mockTerminal{lines: []string{"", "lizard", "p", "", "s"}}
+	cli.terminal = term
+	cli.reader = bufio.NewReader(strings.NewReader("rock\n"))
+
+	// Capture stdout
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	move1, err1 := cli.getHiddenMove(domain.RockPaperScissors, "Alice")
+	move2, err2 := cli.getHiddenMove(domain.RockPaperScissors, "Bob")
+
+	w.Close()
+	os.Stdout = old
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	if err1 != nil || err2 != nil {
+		t.Fatalf("getHiddenMove() error = %v, %v", err1, err2)
+	}
+	if move1 != domain.Paper || move2 != domain.Scissors {
+		t.Errorf("getHiddenMove() = %v, %v, want Paper, Scissors", move1, move2)
+	}
+	if term.cleared != 2 {
+		t.Errorf("screen cleared %d times, want 2", term.cleared)
+	}
+	if len(term.lines) != 0 {
+		t.Errorf("lines left unread: %v", term.lines)
+	}
+	for _, expected := range []string{
+		"Pass the keyboard to Alice and press Enter when ready.",
+		"Pass the keyboard to Bob and press Enter when ready.",
+		"Invalid move.",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("getHiddenMove() output missing expected string: %s", expected)
+		}
+	}
+
+	// Without a terminal moves are read from stdin as usual
+	cli.terminal = nil
+	if got, err := cli.getHiddenMove(domain.RockPaperScissors, "Alice"); err != nil || got != domain.Rock {
+		t.Errorf("getHiddenMove() without terminal = %v, %v, want Rock", got, err)
+	}
+}
+
+func TestInterruptedGameIsLeftUnfinished(t *testing.T) {
+	repo := &MockGameRepository{}
+	useCase := usecase.NewGameUseCase(repo, &MockRandomGenerator{})
+	cli := NewGameCLI(useCase, usecase.NewStatsUseCase(repo), nil)
+	cli.terminal = &mockTerminal{lines: []string{"", "r"}, err: ErrInterrupted}
+
+	if err := useCase.StartNewGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Alice", "Bob"); err != nil {
+		t.Fatalf("StartNewGame() error = %v", err)
+	}
+
+	old := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+
+	game, err := cli.playCurrentGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Alice", "Bob")
+
+	w.Close()
+	os.Stdout = old
+
+	if !errors.Is(err, ErrInterrupted) || game != nil {
+		t.Errorf("playCurrentGame() = %+v, %v, want ErrInterrupted", game, err)
+	}
+	if useCase.GetCurrentGame() == nil {
+		t.Error("interrupted game is no longer in progress")
+	}
+}
+
+func TestGetHiddenMoveReturnsTerminalErrors(t *testing.T) {
+	failure := errors.New("inappropriate ioctl for device")
+	tests := []struct {
+		name string
+		term *mockTerminal
+		want error
+	}{
+		{"closed before ready", &mockTerminal{}, io.EOF},
+		{"closed after an invalid move", &mockTerminal{lines: []string{"", "x"}}, io.EOF},
+		{"terminal failure", &mockTerminal{lines: []string{""}, err: failure}, failure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := newTestCLI(&MockGameRepository{}, &MockRandomGenerator{})
+			cli.terminal = tt.term
+
+			old := os.Stdout
+			_, w, _ := os.Pipe()
+			os.Stdout = w
+
+			_, err := cli.getHiddenMove(domain.RockPaperScissors, "Alice")
+
+			w.Close()
+			os.Stdout = old
+
+			if !errors.Is(err, tt.want) {
+				t.Errorf("getHiddenMove() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMatchFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected domain.MatchFormat
+	}{
+		{"default", "\n", domain.DefaultMatchFormat},
+		{"best of 5", "bo5\n", domain.MatchFormat{Type: domain.BestOf, Rounds: 5}},
+		{"first to 3", "FT3\n", domain.MatchFormat{Type: domain.FirstTo, Rounds: 3}},
+		{"fixed rounds", "fixed4\n", domain.MatchFormat{Type: domain.FixedRounds, Rounds: 4}},
+		{"sudden death", "sd3\n", domain.MatchFormat{Type: domain.SuddenDeath, Rounds: 3}},
+		{"retry after invalid", "bo0\nxyz\nbo7\n", domain.MatchFormat{Type: domain.BestOf, Rounds: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockGameRepository{}
+			randGen := &MockRandomGenerator{}
+			cli := newTestCLI(repo, randGen)
+			cli.reader = bufio.NewReader(strings.NewReader(tt.input))
+
+			got := cli.readMatchFormat()
+			if got != tt.expected {
+				t.Errorf("readMatchFormat() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadRuleSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *domain.RuleSet
+	}{
+		{"default", "\n", domain.RockPaperScissors},
+		{"rpsls", "rpsls\n", domain.RockPaperScissorsLizardSpock},
+		{"retry after invalid", "chess\nRPS\n", domain.RockPaperScissors},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockGameRepository{}
+			randGen := &MockRandomGenerator{}
+			cli := newTestCLI(repo, randGen)
+			cli.reader = bufio.NewReader(strings.NewReader(tt.input))
+
+			got := cli.readRuleSet()
+			if got != tt.expected {
+				t.Errorf("readRuleSet() = %v, want %v", got.Name, tt.expected.Name)
+			}
+		})
+	}
+}
+
+func TestReadDifficulty(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected domain.Difficulty
+	}{
+		{"default", "\n", domain.Easy},
+		{"hard", "hard\n", domain.Hard},
+		{"retry after invalid", "impossible\nExpert\n", domain.Expert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockGameRepository{}
+			randGen := &MockRandomGenerator{}
+			cli := newTestCLI(repo, randGen)
+			cli.reader = bufio.NewReader(strings.NewReader(tt.input))
+
+			got := cli.readDifficulty()
+			if got != tt.expected {
+				t.Errorf("readDifficulty() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestDisplayResult(t *testing.T) {
 	repo := &MockGameRepository{}
 	randGen := &MockRandomGenerator{}
-	useCase := usecase.NewGameUseCase(repo, randGen)
-	cli := NewGameCLI(useCase)
+	cli := newTestCLI(repo, randGen)
 
 	game := &domain.Game{
 		Player1: "Player1",
@@ -144,6 +440,56 @@ func TestDisplayResult(t *testing.T) {
 	os.Stdout = old
 }
 
+// failingOnceRepository cannot store the first game saved.
+type failingOnceRepository struct {
+	MockGameRepository
+	failed bool
+	saved  []*domain.Game
+}
+
+func (m *failingOnceRepository) SaveGame(ctx context.Context, result *domain.Game) error {
+	if !m.failed {
+		m.failed = true
+		return context.DeadlineExceeded
+	}
+	m.saved = append(m.saved, result)
+	return nil
+}
+
+func TestPlayRoundsAgainWhenGameCannotBeSaved(t *testing.T) {
+	repo := &failingOnceRepository{}
+	useCase := usecase.NewGameUseCase(repo, &MockRandomGenerator{})
+	cli := NewGameCLI(useCase, usecase.NewStatsUseCase(repo), nil)
+	cli.terminal = nil
+	cli.reader = bufio.NewReader(strings.NewReader("r\ns\nr\ns\ny\np\nr\n"))
+
+	if err := useCase.StartNewGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Alice", "Bob"); err != nil {
+		t.Fatalf("StartNewGame() error = %v", err)
+	}
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	game, err := cli.playCurrentGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Alice", "Bob")
+
+	w.Close()
+	os.Stdout = old
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	if !strings.Contains(output, "Error: failed to save game: context deadline exceeded") {
+		t.Errorf("playCurrentGame() output missing the error:\n%s", output)
+	}
+	if count := strings.Count(output, "Round 2 of 3:"); count != 2 {
+		t.Errorf("round 2 played %d times, want 2", count)
+	}
+	if err != nil || game == nil || len(repo.saved) != 1 || len(game.Rounds) != 2 || game.Rounds[1].Move1 != domain.Paper {
+		t.Errorf("playCurrentGame() = %+v, saved %d games", game, len(repo.saved))
+	}
+}
+
 func TestShowHistory(t *testing.T) {
 	repo := &MockGameRepository{
 		history: []*domain.Game{
@@ -151,12 +497,16 @@ func TestShowHistory(t *testing.T) {
 				Player1: "Player1",
 				Player2: "Player2",
 				Winner:  "Player1",
+				Rounds: []domain.RoundResult{
+					{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Player1"},
+					{Move1: domain.Paper, Move2: domain.Rock, Winner: "Player1"},
+				},
 			},
 		},
 	}
 	randGen := &MockRandomGenerator{}
-	useCase := usecase.NewGameUseCase(repo, randGen)
-	cli := NewGameCLI(useCase)
+	cli := newTestCLI(repo, randGen)
+	cli.reader = bufio.NewReader(strings.NewReader("\n"))
 
 	// Capture stdout
 	old := os.Stdout
@@ -177,6 +527,8 @@ func TestShowHistory(t *testing.T) {
 		"Game History:",
 		"Players: Player1 vs Player2",
 		"Winner: Player1",
+		"Round 1: Rock vs Scissors - Player1",
+		"Round 2: Paper vs Rock - Player1",
 	}
 
 	for _, expected := range expectedStrings {
@@ -193,8 +545,8 @@ func TestShowHistoryError(t *testing.T) {
 		err: errors.New("database error"),
 	}
 	randGen := &MockRandomGenerator{}
-	useCase := usecase.NewGameUseCase(repo, randGen)
-	cli := NewGameCLI(useCase)
+	cli := newTestCLI(repo, randGen)
+	cli.reader = bufio.NewReader(strings.NewReader("\n"))
 
 	// Capture stdout
 	old := os.Stdout
@@ -217,3 +569,240 @@ func TestShowHistoryError(t *testing.T) {
 
 	os.Stdout = old
 }
+
+func TestShowHistoryPages(t *testing.T) {
+	repo := &MockGameRepository{}
+	for i := 1; i <= 12; i++ {
+		repo.history = append(repo.history, &domain.Game{
+			ID:       fmt.Sprintf("game%d", i),
+			Player1:  "Alice",
+			Player2:  "Bob",
+			Winner:   "Alice",
+			PlayedAt: fmt.Sprintf("2025-01-%02dT10:00:00Z", i),
+		})
+	}
+	repo.history = append(repo.history, &domain.Game{ID: "other", Player1: "Carol", Player2: "Dave", Winner: "Dave", PlayedAt: "2025-02-01T10:00:00Z"})
+	cli := newTestCLI(repo, &MockRandomGenerator{})
+	cli.reader = bufio.NewReader(strings.NewReader("Alice\nx\nn\np\n\n"))
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cli.showHistory()
+
+	w.Close()
+	os.Stdout = old
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	for _, expected := range []string{
+		"Games 1-10 of 12\nn next page, or press Enter to go back: Invalid option",
+		"Game ID: game12",
+		"Games 11-12 of 12\np previous page, or press Enter to go back: ",
+		"Game ID: game1\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("showHistory() output missing expected string: %q", expected)
+		}
+	}
+	if strings.Contains(output, "Game ID: other") {
+		t.Error("showHistory() listed a game of other players")
+	}
+	if count := strings.Count(output, "Games 1-10 of 12"); count != 3 {
+		t.Errorf("showHistory() showed the first page %d times, want 3", count)
+	}
+}
+
+func TestShowLeaderboard(t *testing.T) {
+	repo := &MockGameRepository{
+		history: []*domain.Game{
+			{Player1: "Alice", Player2: "Bob", Winner: "Alice", PlayedAt: "2025-01-01T10:00:00Z"},
+			{Player1: "Alice", Player2: "Bob", Winner: "Draw", PlayedAt: "2025-01-02T10:00:00Z"},
+			{Player1: "Bob", Player2: "Carol", Winner: "Bob", PlayedAt: "2025-01-03T10:00:00Z"},
+			{Player1: "Alice", Player2: "Carol", Winner: "Alice", PlayedAt: "2025-01-04T10:00:00Z"},
+		},
+	}
+	randGen := &MockRandomGenerator{}
+	cli := newTestCLI(repo, randGen)
+
+	// Capture stdout
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cli.showLeaderboard()
+
+	// Restore stdout
+	w.Close()
+
+	// Read the output
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	output := buf.String()
+	expectedStrings := []string{
+		"Leaderboard:",
+		"1    Alice                3      2      0      1     66.7%       1",
+		"2    Bob                  3      1      1      1     33.3%       1",
+		"3    Carol                2      0      2      0      0.0%       0",
+	}
+
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("showLeaderboard() output missing expected string: %s", expected)
+		}
+	}
+
+	os.Stdout = old
+}
+
+func TestShowPlayerStats(t *testing.T) {
+	repo := &MockGameRepository{
+		history: []*domain.Game{
+			{
+				Player1:  "Alice",
+				Player2:  "Bob",
+				Winner:   "Alice",
+				PlayedAt: "2025-01-01T10:00:00Z",
+				Rounds: []domain.RoundResult{
+					{Move1: domain.Paper, Move2: domain.Rock, Winner: "Alice"},
+					{Move1: domain.Paper, Move2: domain.Rock, Winner: "Alice"},
+				},
+			},
+			{Player1: "Carol", Player2: "Alice", Winner: "Carol", PlayedAt: "2025-01-02T10:00:00Z"},
+		},
+	}
+	randGen := &MockRandomGenerator{}
+	cli := newTestCLI(repo, randGen)
+	cli.reader = bufio.NewReader(strings.NewReader("Alice\n"))
+
+	// Capture stdout
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cli.showPlayerStats()
+
+	// Restore stdout
+	w.Close()
+
+	// Read the output
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	output := buf.String()
+	expectedStrings := []string{
+		"Stats for Alice:",
+		"Wins: 1, Losses: 1, Draws: 0",
+		"Win rate: 50.0%",
+		"Favorite move: Paper",
+		"vs Bob             1 W / 0 L / 0 D",
+		"vs Carol           0 W / 1 L / 0 D",
+	}
+
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("showPlayerStats() output missing expected string: %s", expected)
+		}
+	}
+
+	os.Stdout = old
+}
+
+func TestShowRankings(t *testing.T) {
+	repo := &MockGameRepository{
+		history: []*domain.Game{
+			{Player1: "Alice", Player2: "Bob", Winner: "Alice", PlayedAt: "2025-01-01T10:00:00Z"},
+			{Player1: "Bob", Player2: "Carol", Winner: "Draw", PlayedAt: "2025-01-02T10:00:00Z"},
+		},
+	}
+	randGen := &MockRandomGenerator{}
+	cli := newTestCLI(repo, randGen)
+
+	// Capture stdout
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cli.showRankings()
+
+	// Restore stdout
+	w.Close()
+
+	// Read the output
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	output := buf.String()
+	expectedStrings := []string{
+		"Rankings (elo):",
+		"1    Alice               1516      -      1",
+		"2    Carol               1499      -      1",
+		"3    Bob                 1485      -      2",
+	}
+
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("showRankings() output missing expected string: %s\n%s", expected, output)
+		}
+	}
+
+	os.Stdout = old
+}
+
+func TestVerifyGame(t *testing.T) {
+	commitment, salt, err := domain.CommitMove(domain.Paper)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &MockGameRepository{
+		history: []*domain.Game{
+			{
+				ID:      "game1",
+				Player1: "Player1",
+				Player2: "Bot",
+				Mode:    domain.PlayerVsBot,
+				Rounds: []domain.RoundResult{
+					{Move1: domain.Rock, Move2: domain.Paper, Winner: "Bot", Commitment: commitment, Salt: salt},
+					{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Player1", Commitment: commitment, Salt: salt},
+				},
+			},
+		},
+	}
+	randGen := &MockRandomGenerator{}
+	cli := newTestCLI(repo, randGen)
+	cli.reader = bufio.NewReader(strings.NewReader("game1\n"))
+
+	// Capture stdout
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cli.verifyGame()
+
+	// Restore stdout
+	w.Close()
+
+	// Read the output
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	output := buf.String()
+	expectedStrings := []string{
+		"Game game1: Player1 vs Bot",
+		"Round 1: bot played Paper, commitment " + commitment + " - OK",
+		"Round 2: bot played Scissors, commitment " + commitment + " - FAILED (commitment does not match Scissors)",
+		"Verification failed: round 2: commitment does not match Scissors",
+	}
+
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("verifyGame() output missing expected string: %s", expected)
+		}
+	}
+
+	os.Stdout = old
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/usecase"
+)
+
+var errNoLiveFeed = errors.New("the live feed is only available with the on-chain storage")
+
+// SetLiveFeed enables following the games stored by every client of the
+// storage.
+func (c *GameCLI) SetLiveFeed(liveFeed *usecase.LiveFeedUseCase) {
+	c.liveFeed = liveFeed
+}
+
+func (c *GameCLI) showLiveFeed() {
+	fmt.Print("Player to follow (press Enter for every game): ")
+	player := c.readInput()
+	fmt.Println("Waiting for new games, press Ctrl-C to stop")
+
+	err := c.watchGames(player, func(game *domain.Game) {
+		printGame(os.Stdout, game)
+	})
+	if err != nil {
+		fmt.Printf("Error following new games: %v\n", err)
+	}
+}
+
+// watchGames follows the new games until Ctrl-C is pressed, which is not an
+// error.
+func (c *GameCLI) watchGames(player string, onGame func(*domain.Game)) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := c.liveFeed.Watch(ctx, player, onGame)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func (c *GameCLI) runWatch(args []string, out io.Writer) error {
+	flags := newFlagSet("watch")
+	player := flags.String("player", "", "only the games of this player")
+	asJSON := flags.Bool("json", false, "print each game as a line of JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if c.liveFeed == nil {
+		return errNoLiveFeed
+	}
+
+	encoder := json.NewEncoder(out)
+	err := c.watchGames(*player, func(game *domain.Game) {
+		if *asJSON {
+			encoder.Encode(toGameJSON(game))
+			return
+		}
+		printGame(out, game)
+	})
+	if err != nil {
+		return fmt.Errorf("live feed stopped: %w", err)
+	}
+	return nil
+}
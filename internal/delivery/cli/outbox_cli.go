@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/usecase"
+)
+
+var errNoOutbox = errors.New("on-chain saves are only queued with the on-chain storage")
+
+// outboxWaitInterval is how often the queue is read while a command waits on
+// the game it saved.
+const outboxWaitInterval = 500 * time.Millisecond
+
+// SetOutbox enables listing the games queued for the storage, and whether
+// they were stored yet.
+func (c *GameCLI) SetOutbox(outbox *usecase.OutboxUseCase) {
+	c.outbox = outbox
+}
+
+func (c *GameCLI) showOutbox() {
+	ctx, cancel := c.storageContext()
+	defer cancel()
+
+	entries, err := c.outbox.GetEntries(ctx, "")
+	if err != nil {
+		fmt.Printf("Error getting on-chain saves: %v\n", err)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println("No games queued yet!")
+		return
+	}
+
+	fmt.Println("\nOn-chain saves:")
+	for _, entry := range entries {
+		printOutboxEntry(os.Stdout, entry)
+	}
+}
+
+func printOutboxEntry(w io.Writer, entry *domain.OutboxEntry) {
+	game := entry.Game
+	fmt.Fprintf(w, "\nGame ID: %s\n", game.ID)
+	fmt.Fprintf(w, "Players: %s vs %s\n", game.Player1, game.Player2)
+	fmt.Fprintf(w, "Winner: %s\n", game.Winner)
+	fmt.Fprintf(w, "Status: %s\n", entry.Status)
+	if entry.TxHash != "" {
+		fmt.Fprintf(w, "Transaction: %s\n", entry.TxHash)
+	}
+	if entry.Status != domain.OutboxConfirmed && entry.LastError != "" {
+		fmt.Fprintf(w, "Last error: %s (%d attempts)\n", entry.LastError, entry.Attempts)
+	}
+	fmt.Fprintf(w, "Queued at: %s\n", entry.QueuedAt)
+	fmt.Fprintln(w, "------------------------")
+}
+
+type outboxEntryJSON struct {
+	Game      gameJSON `json:"game"`
+	Status    string   `json:"status"`
+	TxHash    string   `json:"tx_hash,omitempty"`
+	Attempts  int      `json:"attempts"`
+	LastError string   `json:"last_error,omitempty"`
+	QueuedAt  string   `json:"queued_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+func (c *GameCLI) runOutbox(args []string, out io.Writer) error {
+	flags := newFlagSet("outbox")
+	statusName := flags.String("status", "", "only the games with this status, pending, confirmed or failed")
+	asJSON := flags.Bool("json", false, "print the games as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if c.outbox == nil {
+		return errNoOutbox
+	}
+
+	var status domain.OutboxStatus
+	if *statusName != "" {
+		var err error
+		if status, err = domain.ParseOutboxStatus(*statusName); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := c.storageContext()
+	defer cancel()
+
+	entries, err := c.outbox.GetEntries(ctx, status)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		result := make([]outboxEntryJSON, len(entries))
+		for i, entry := range entries {
+			result[i] = outboxEntryJSON{
+				Game:      toGameJSON(entry.Game),
+				Status:    string(entry.Status),
+				TxHash:    entry.TxHash,
+				Attempts:  entry.Attempts,
+				LastError: entry.LastError,
+				QueuedAt:  entry.QueuedAt,
+				UpdatedAt: entry.UpdatedAt,
+			}
+		}
+		return writeJSON(out, result)
+	}
+
+	for _, entry := range entries {
+		printOutboxEntry(out, entry)
+	}
+	return nil
+}
+
+// awaitOutbox waits for the first attempt to store a game queued for the
+// storage, so that a command does not exit before the game is sent, and
+// tells how it went. A game still pending once the timeout is over, or after
+// a failed attempt, is stored on a later run.
+func (c *GameCLI) awaitOutbox(game *domain.Game, out io.Writer) error {
+	if c.outbox == nil {
+		return nil
+	}
+
+	ctx, cancel := c.storageContext()
+	defer cancel()
+
+	ticker := time.NewTicker(outboxWaitInterval)
+	defer ticker.Stop()
+
+	for {
+		entries, err := c.outbox.GetEntries(ctx, "")
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("failed to get on-chain saves: %w", err)
+		}
+
+		var entry *domain.OutboxEntry
+		for _, e := range entries {
+			if e.Game.ID == game.ID {
+				entry = e
+			}
+		}
+		switch {
+		case err != nil:
+			// The wait is over, which is told below.
+		case entry == nil:
+			// Stored right away, without an outbox.
+			return nil
+		case entry.Status == domain.OutboxConfirmed:
+			fmt.Fprintf(out, "Stored on-chain in transaction %s\n", entry.TxHash)
+			return nil
+		case entry.Status == domain.OutboxFailed:
+			return fmt.Errorf("game %s could not be stored on-chain: %s", game.ID, entry.LastError)
+		case entry.Attempts > 0:
+			fmt.Fprintf(out, "On-chain save still pending after %d attempts (%s), it is retried on the next run\n", entry.Attempts, entry.LastError)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(out, "On-chain save still pending, it is stored on the next run")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned when Ctrl-C is pressed while input is hidden, so
+// the game can unwind and exit as it would on an interrupt.
+var ErrInterrupted = errors.New("interrupted")
+
+// terminal reads input typed on a terminal without showing it, so players
+// sharing a keyboard cannot read each other's moves.
+type terminal interface {
+	// ReadHidden reads a line without echoing anything, so not even the
+	// length of the input shows.
+	ReadHidden() (string, error)
+	// Clear clears the screen.
+	Clear()
+}
+
+type ttyTerminal struct {
+	in     *os.File
+	reader *bufio.Reader // the reader of in, which may hold input already typed
+	out    *os.File
+}
+
+// newTerminal returns nil when stdin is not a terminal, in which case input
+// cannot be hidden and is read as usual. Input is read through the reader
+// wrapping in, so nothing it has buffered is lost.
+func newTerminal(in *os.File, reader *bufio.Reader, out *os.File) terminal {
+	if !term.IsTerminal(int(in.Fd())) {
+		return nil
+	}
+	return &ttyTerminal{in: in, reader: reader, out: out}
+}
+
+func (t *ttyTerminal) ReadHidden() (string, error) {
+	fd := int(t.in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	var line []byte
+	for {
+		b, err := t.reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case b == '\r' || b == '\n':
+			fmt.Fprint(t.out, "\r\n")
+			return strings.TrimSpace(string(line)), nil
+		case b == 3: // Ctrl-C, which raw mode no longer turns into an interrupt
+			fmt.Fprint(t.out, "\r\n")
+			return "", ErrInterrupted
+		case b == 4 && len(line) == 0: // Ctrl-D
+			fmt.Fprint(t.out, "\r\n")
+			return "", io.EOF
+		case b == 127 || b == '\b':
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+			}
+		case b >= ' ':
+			line = append(line, b)
+		}
+	}
+}
+
+func (t *ttyTerminal) Clear() {
+	if term.IsTerminal(int(t.out.Fd())) {
+		fmt.Fprint(t.out, "\033[H\033[2J")
+	}
+}
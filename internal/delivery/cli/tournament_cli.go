@@ -0,0 +1,399 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/usecase"
+)
+
+// SetTournaments enables tournaments, played on the CLI's game use case.
+func (c *GameCLI) SetTournaments(tournaments *usecase.TournamentUseCase) {
+	c.tournaments = tournaments
+}
+
+func (c *GameCLI) showTournaments() error {
+	if c.tournaments == nil {
+		fmt.Println("Tournaments are only available with the SQLite storage")
+		return nil
+	}
+
+	for {
+		fmt.Println("\nTournaments")
+		fmt.Println("1. New tournament")
+		fmt.Println("2. Resume tournament")
+		fmt.Println("3. Show tournament")
+		fmt.Println("4. Back")
+		fmt.Print("Choose an option: ")
+
+		var err error
+		switch c.readInput() {
+		case "1":
+			err = c.newTournament()
+		case "2":
+			if tournament := c.chooseTournament(false); tournament != nil {
+				err = c.playTournament(tournament)
+			}
+		case "3":
+			if tournament := c.chooseTournament(true); tournament != nil {
+				printTournament(os.Stdout, tournament)
+			}
+		case "4":
+			return nil
+		default:
+			fmt.Println("Invalid option, please try again")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *GameCLI) newTournament() error {
+	fmt.Print("Tournament name: ")
+	name := c.readInput()
+	format := c.readTournamentFormat()
+	players := c.readTournamentPlayers()
+
+	swissRounds := 0
+	if format == domain.Swiss {
+		swissRounds = c.readSwissRounds(len(players))
+	}
+
+	matchFormat := c.readMatchFormat()
+	rules := c.readRuleSet()
+
+	tournament, err := c.tournaments.CreateTournament(name, format, matchFormat, rules, players, swissRounds)
+	if err != nil {
+		fmt.Printf("Error creating tournament: %v\n", err)
+		return nil
+	}
+
+	return c.playTournament(tournament)
+}
+
+// chooseTournament lists the tournaments, only those in progress unless
+// finished ones are included, and reads the one to pick.
+func (c *GameCLI) chooseTournament(includeFinished bool) *domain.Tournament {
+	all, err := c.tournaments.ListTournaments()
+	if err != nil {
+		fmt.Printf("Error getting tournaments: %v\n", err)
+		return nil
+	}
+
+	var tournaments []*domain.Tournament
+	for _, tournament := range all {
+		if includeFinished || !tournament.Finished() {
+			tournaments = append(tournaments, tournament)
+		}
+	}
+	if len(tournaments) == 0 {
+		fmt.Println("No tournaments found!")
+		return nil
+	}
+
+	fmt.Println()
+	for i, tournament := range tournaments {
+		status := fmt.Sprintf("round %d", tournament.CurrentRound())
+		if tournament.Finished() {
+			status = "won by " + tournament.Winner
+		}
+		fmt.Printf("%d. %s (%s, %d players, %s)\n", i+1, tournament.Name, tournament.Format, len(tournament.Players), status)
+	}
+
+	for {
+		fmt.Print("Choose a tournament: ")
+		choice, err := strconv.Atoi(c.readInput())
+		if err != nil || choice < 1 || choice > len(tournaments) {
+			fmt.Println("Invalid option, please try again")
+			continue
+		}
+		return tournaments[choice-1]
+	}
+}
+
+// playTournament plays the pending matches in order until the tournament is
+// over or the players stop. Every result is stored, so a stopped tournament
+// can be resumed later. It only fails when a match is interrupted.
+func (c *GameCLI) playTournament(tournament *domain.Tournament) error {
+	rules, err := domain.RuleSetByName(tournament.RuleSet)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return nil
+	}
+
+	for !tournament.Finished() {
+		printTournament(os.Stdout, tournament)
+
+		for _, match := range tournament.Pending() {
+			fmt.Printf("\nNext match: %s vs %s. Press Enter to play, or q to stop for now: ", match.Player1, match.Player2)
+			if strings.EqualFold(c.readInput(), "q") {
+				fmt.Println("Tournament saved, resume it from the Tournaments menu.")
+				return nil
+			}
+
+			if err := c.tournaments.StartMatch(tournament, match); err != nil {
+				fmt.Printf("Error starting match: %v\n", err)
+				return nil
+			}
+			game, err := c.playRounds(tournament.MatchFormat, c.readHiddenMoves(rules, match.Player1, match.Player2))
+			if err != nil || game == nil {
+				return err
+			}
+
+			if err := c.tournaments.FinishMatch(tournament, match, game); err != nil {
+				fmt.Printf("Error recording match: %v\n", err)
+				return nil
+			}
+			if !match.Played() {
+				fmt.Println("Elimination matches need a winner, the match will be played again.")
+			}
+		}
+	}
+
+	printTournament(os.Stdout, tournament)
+	fmt.Printf("\n%s wins the tournament!\n", tournament.Winner)
+	return nil
+}
+
+func (c *GameCLI) readTournamentFormat() domain.TournamentFormat {
+	for {
+		fmt.Printf("Tournament format - %s, %s, %s, %s [%s]: ",
+			domain.SingleElimination.Code(), domain.DoubleElimination.Code(), domain.RoundRobin.Code(), domain.Swiss.Code(),
+			domain.SingleElimination.Code())
+		input := c.readInput()
+		if input == "" {
+			return domain.SingleElimination
+		}
+		format, err := domain.ParseTournamentFormat(input)
+		if err != nil {
+			fmt.Printf("Invalid tournament format: %v\n", err)
+			continue
+		}
+		return format
+	}
+}
+
+func (c *GameCLI) readTournamentPlayers() []string {
+	for {
+		fmt.Print("Players, best seed first, separated by commas: ")
+		var players []string
+		for _, player := range strings.Split(c.readInput(), ",") {
+			if player = strings.TrimSpace(player); player != "" {
+				players = append(players, player)
+			}
+		}
+
+		if len(players) < 2 {
+			fmt.Println("A tournament needs at least 2 players")
+			continue
+		}
+		valid := true
+		for _, player := range players {
+			if err := c.validatePlayerName(player); err != nil {
+				fmt.Printf("Invalid name %s: %v\n", player, err)
+				valid = false
+			}
+		}
+		if valid {
+			return players
+		}
+	}
+}
+
+func (c *GameCLI) readSwissRounds(players int) int {
+	for {
+		fmt.Printf("Number of rounds [%d]: ", domain.DefaultSwissRounds(players))
+		input := c.readInput()
+		if input == "" {
+			return 0
+		}
+		rounds, err := strconv.Atoi(input)
+		if err != nil || rounds < 1 {
+			fmt.Println("Invalid number of rounds")
+			continue
+		}
+		return rounds
+	}
+}
+
+func printTournament(w io.Writer, tournament *domain.Tournament) {
+	fmt.Fprintf(w, "\n%s - %s, %s", tournament.Name, tournament.Format, tournament.MatchFormat)
+	if rules, err := domain.RuleSetByName(tournament.RuleSet); err == nil {
+		fmt.Fprintf(w, ", %s", rules.DisplayName)
+	}
+	fmt.Fprintln(w)
+	if tournament.Finished() {
+		fmt.Fprintf(w, "Winner: %s\n", tournament.Winner)
+	} else {
+		fmt.Fprintf(w, "Round %d in progress\n", tournament.CurrentRound())
+	}
+
+	switch tournament.Format {
+	case domain.SingleElimination:
+		fmt.Fprintln(w)
+		printBracket(w, tournament, "")
+	case domain.DoubleElimination:
+		fmt.Fprintln(w, "\nWinners bracket:")
+		printBracket(w, tournament, domain.WinnersBracket)
+		fmt.Fprintln(w, "\nLosers bracket:")
+		printRounds(w, tournament, domain.LosersBracket)
+		fmt.Fprintln(w, "\nGrand final:")
+		printRounds(w, tournament, domain.GrandFinal)
+	default:
+		fmt.Fprintln(w)
+		printRounds(w, tournament, "")
+	}
+
+	printStandings(w, tournament)
+}
+
+// printBracket draws an elimination bracket as a tree, from the first round
+// on the left to the winner on the right. Players yet to be decided are
+// left blank.
+func printBracket(w io.Writer, tournament *domain.Tournament, bracket string) {
+	size := tournament.BracketSize()
+
+	columns := [][]string{make([]string, 0, size)}
+	for _, match := range bracketRound(tournament, 1, bracket) {
+		player2 := match.Player2
+		if match.IsBye() {
+			player2 = "(bye)"
+		}
+		columns[0] = append(columns[0], match.Player1, player2)
+	}
+	for n := size / 2; n >= 1; n /= 2 {
+		round := bracketRound(tournament, len(columns), bracket)
+		names := make([]string, n)
+		for j := range names {
+			if j < len(round) && round[j].Played() {
+				names[j] = round[j].Winner
+			}
+		}
+		columns = append(columns, names)
+	}
+
+	width := 0
+	for _, names := range columns {
+		for _, name := range names {
+			width = max(width, utf8.RuneCountInString(name))
+		}
+	}
+
+	// Each name is followed by " ─" and the junction joining it to the other
+	// player of its match, then "─ " leads to the winner.
+	stride := width + 5
+	grid := make([][]rune, 2*size-1)
+	for y := range grid {
+		grid[y] = []rune(strings.Repeat(" ", len(columns)*stride))
+	}
+
+	rows := make([]int, size)
+	for j := range rows {
+		rows[j] = 2 * j
+	}
+	for c, names := range columns {
+		x := c * stride
+		for j, name := range names {
+			copy(grid[rows[j]][x:], []rune(name))
+		}
+		if c == len(columns)-1 {
+			break
+		}
+
+		junction := x + width + 2
+		next := make([]int, len(rows)/2)
+		for j := 0; j < len(rows); j += 2 {
+			top, bottom := rows[j], rows[j+1]
+			grid[top][junction-1], grid[top][junction] = '─', '┐'
+			grid[bottom][junction-1], grid[bottom][junction] = '─', '┘'
+			for y := top + 1; y < bottom; y++ {
+				grid[y][junction] = '│'
+			}
+
+			middle := (top + bottom) / 2
+			grid[middle][junction], grid[middle][junction+1] = '├', '─'
+			next[j/2] = middle
+		}
+		rows = next
+	}
+
+	for _, line := range grid {
+		fmt.Fprintln(w, strings.TrimRight(string(line), " "))
+	}
+}
+
+func bracketRound(tournament *domain.Tournament, round int, bracket string) []*domain.TournamentMatch {
+	var matches []*domain.TournamentMatch
+	for _, match := range tournament.Round(round) {
+		if match.Bracket == bracket {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// printRounds lists the matches of a bracket round by round.
+func printRounds(w io.Writer, tournament *domain.Tournament, bracket string) {
+	printed := false
+	for round := 1; round <= tournament.CurrentRound(); round++ {
+		matches := bracketRound(tournament, round, bracket)
+		if len(matches) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "Round %d:\n", round)
+		for _, match := range matches {
+			switch {
+			case match.IsBye():
+				fmt.Fprintf(w, "  %s has a bye\n", match.Player1)
+			case match.Played():
+				fmt.Fprintf(w, "  %s vs %s - %s\n", match.Player1, match.Player2, match.Winner)
+			default:
+				fmt.Fprintf(w, "  %s vs %s - to play\n", match.Player1, match.Player2)
+			}
+		}
+		printed = true
+	}
+	if !printed {
+		fmt.Fprintln(w, "  No matches yet")
+	}
+}
+
+func printStandings(w io.Writer, tournament *domain.Tournament) {
+	fmt.Fprintln(w, "\nStandings:")
+	standings := tournament.Standings()
+
+	if tournament.Format.IsElimination() {
+		fmt.Fprintf(w, "%-4s %-15s %6s %6s %6s  %s\n", "#", "Player", "Played", "Wins", "Losses", "Status")
+		for i, standing := range standings {
+			status := "in"
+			switch {
+			case standing.Player == tournament.Winner:
+				status = "winner"
+			case standing.Eliminated:
+				status = "out"
+			}
+			fmt.Fprintf(w, "%-4d %-15s %6d %6d %6d  %s\n", i+1, standing.Player, standing.Played, standing.Wins, standing.Losses, status)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%-4s %-15s %6s %6s %6s %6s %6s", "#", "Player", "Played", "Wins", "Draws", "Losses", "Points")
+	if tournament.Format == domain.Swiss {
+		fmt.Fprintf(w, " %8s", "Buchholz")
+	}
+	fmt.Fprintln(w)
+	for i, standing := range standings {
+		fmt.Fprintf(w, "%-4d %-15s %6d %6d %6d %6d %6.1f", i+1, standing.Player, standing.Played, standing.Wins, standing.Draws, standing.Losses, standing.Points)
+		if tournament.Format == domain.Swiss {
+			fmt.Fprintf(w, " %8.1f", standing.Buchholz)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"protofire-game/internal/domain"
+)
+
+func TestPrintBracket(t *testing.T) {
+	tournament, err := domain.NewTournament("t1", "Office cup", domain.SingleElimination, domain.DefaultMatchFormat,
+		domain.RockPaperScissors, []string{"Alice", "Bob", "Carol", "Dave", "Erin"}, 0, "2025-01-01T10:00:00Z")
+	if err != nil {
+		t.Fatalf("NewTournament() error = %v", err)
+	}
+	for _, winner := range []string{"Erin", "Alice"} {
+		if err := tournament.Record(tournament.Pending()[0], winner, "game"); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	printBracket(&buf, tournament, "")
+
+	expected := `Alice ─┐
+       ├─ Alice ─┐
+(bye) ─┘         │
+                 ├─ Alice ─┐
+Dave  ─┐         │         │
+       ├─ Erin  ─┘         │
+Erin  ─┘                   │
+                           ├─
+Bob   ─┐                   │
+       ├─ Bob   ─┐         │
+(bye) ─┘         │         │
+                 ├─       ─┘
+Carol ─┐         │
+       ├─ Carol ─┘
+(bye) ─┘
+`
+	if buf.String() != expected {
+		t.Errorf("printBracket() =\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
+
+func TestPrintTournament(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   domain.TournamentFormat
+		expected []string
+	}{
+		{
+			name:   "double elimination",
+			format: domain.DoubleElimination,
+			expected: []string{
+				"Office cup - Double elimination, Best of 3, Rock Paper Scissors",
+				"Winner: Carol",
+				"Winners bracket:",
+				"Losers bracket:\nRound 3:\n  Bob vs Alice - Alice",
+				"Grand final:\nRound 4:\n  Carol vs Alice - Alice\nRound 5:\n  Alice vs Carol - Carol",
+				"1    Carol                4      3      1  winner",
+				"3    Bob                  2      0      2  out",
+			},
+		},
+		{
+			name:   "swiss",
+			format: domain.Swiss,
+			expected: []string{
+				"Office cup - Swiss, Best of 3, Rock Paper Scissors",
+				"Round 1:\n  Alice vs Bob - Bob\n  Carol has a bye",
+				"Round 2:\n  Bob vs Carol - Carol\n  Alice has a bye",
+				"Buchholz",
+				"1    Carol                1      1      0      0    2.0      1.0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tournament, err := domain.NewTournament("t1", "Office cup", tt.format, domain.DefaultMatchFormat,
+				domain.RockPaperScissors, []string{"Alice", "Bob", "Carol"}, 0, "2025-01-01T10:00:00Z")
+			if err != nil {
+				t.Fatalf("NewTournament() error = %v", err)
+			}
+			// Player 2 wins every match
+			for !tournament.Finished() {
+				match := tournament.Pending()[0]
+				if err := tournament.Record(match, match.Player2, "game"); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+
+			var buf bytes.Buffer
+			printTournament(&buf, tournament)
+			output := buf.String()
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("printTournament() output missing expected string: %s\n%s", expected, output)
+				}
+			}
+		})
+	}
+}
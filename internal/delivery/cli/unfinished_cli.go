@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"protofire-game/internal/domain"
+)
+
+// offerUnfinishedGames lists the games left in progress when the program
+// last exited, to resume them or mark them as forfeits. It only fails when a
+// resumed game is interrupted.
+func (c *GameCLI) offerUnfinishedGames() error {
+	for {
+		games, err := c.useCase.GetUnfinishedGames()
+		if err != nil {
+			fmt.Printf("Error getting unfinished games: %v\n", err)
+			return nil
+		}
+		if len(games) == 0 {
+			return nil
+		}
+
+		fmt.Println("\nUnfinished games:")
+		for i, unfinished := range games {
+			fmt.Printf("%d. ", i+1)
+			printUnfinishedGame(os.Stdout, unfinished)
+		}
+		fmt.Print("Enter a number to resume a game, f and a number to mark it as a forfeit, or press Enter to skip: ")
+
+		input := strings.ToLower(c.readInput())
+		if input == "" {
+			return nil
+		}
+		forfeit := strings.HasPrefix(input, "f")
+		choice, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(input, "f")))
+		if err != nil || choice < 1 || choice > len(games) {
+			fmt.Println("Invalid option, please try again")
+			continue
+		}
+
+		if forfeit {
+			c.forfeitGame(games[choice-1].Game)
+		} else if err := c.resumeGame(games[choice-1]); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *GameCLI) resumeGame(unfinished *domain.UnfinishedGame) error {
+	game := unfinished.Game
+	rules, err := domain.RuleSetByName(game.RuleSet)
+	if err != nil {
+		fmt.Printf("Error resuming game: %v\n", err)
+		return nil
+	}
+	if err := c.useCase.ResumeGame(game.ID); err != nil {
+		fmt.Printf("Error resuming game: %v\n", err)
+		return nil
+	}
+
+	fmt.Printf("\nResuming %s vs %s after round %d\n", game.Player1, game.Player2, len(game.Rounds))
+	_, err = c.playCurrentGame(game.Mode, unfinished.Format, rules, game.Player1, game.Player2)
+	return err
+}
+
+// forfeitGame ends an unfinished game as lost by the player who abandoned
+// it. Against the bot that is always the player.
+func (c *GameCLI) forfeitGame(game *domain.Game) {
+	player := game.Player1
+	if game.Mode == domain.PlayerVsPlayer {
+		for {
+			fmt.Printf("Who forfeits, %s or %s? ", game.Player1, game.Player2)
+			input := c.readInput()
+			if strings.EqualFold(input, game.Player1) {
+				break
+			}
+			if strings.EqualFold(input, game.Player2) {
+				player = game.Player2
+				break
+			}
+			fmt.Println("Invalid player, please try again")
+		}
+	}
+
+	ctx, cancel := c.storageContext()
+	defer cancel()
+
+	result, err := c.useCase.ForfeitGame(ctx, game.ID, player)
+	if err != nil {
+		fmt.Printf("Error forfeiting game: %v\n", err)
+		return
+	}
+	fmt.Printf("%s forfeits, %s wins the game.\n", player, result.Winner)
+}
+
+// printUnfinishedGame describes an unfinished game on one line, with the
+// score of the rounds played so far.
+func printUnfinishedGame(w io.Writer, unfinished *domain.UnfinishedGame) {
+	game := unfinished.Game
+
+	wins1, wins2 := 0, 0
+	for _, round := range game.Rounds {
+		switch round.Winner {
+		case game.Player1:
+			wins1++
+		case game.Player2:
+			wins2++
+		}
+	}
+
+	rules := game.RuleSet
+	if ruleSet, err := domain.RuleSetByName(game.RuleSet); err == nil {
+		rules = ruleSet.DisplayName
+	}
+	fmt.Fprintf(w, "%s vs %s - %s, %s, %d-%d after round %d, started %s\n",
+		game.Player1, game.Player2, rules, unfinished.Format, wins1, wins2, len(game.Rounds), game.PlayedAt)
+}
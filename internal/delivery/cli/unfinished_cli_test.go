@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/randomness"
+	"protofire-game/internal/repository"
+	"protofire-game/internal/usecase"
+)
+
+func newUnfinishedGame(id string) *domain.UnfinishedGame {
+	return &domain.UnfinishedGame{
+		Game: &domain.Game{
+			ID:       id,
+			Player1:  "Alice",
+			Player2:  "Bob",
+			Mode:     domain.PlayerVsPlayer,
+			RuleSet:  domain.RockPaperScissors.Name,
+			PlayedAt: "2025-01-01T10:00:00Z",
+			Rounds: []domain.RoundResult{
+				{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Alice"},
+			},
+		},
+		Format: domain.DefaultMatchFormat,
+	}
+}
+
+func TestPrintUnfinishedGame(t *testing.T) {
+	var buf bytes.Buffer
+	printUnfinishedGame(&buf, newUnfinishedGame("game1"))
+
+	expected := "Alice vs Bob - Rock Paper Scissors, Best of 3, 1-0 after round 1, started 2025-01-01T10:00:00Z\n"
+	if buf.String() != expected {
+		t.Errorf("printUnfinishedGame() = %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestOfferUnfinishedGames(t *testing.T) {
+	repo := repository.NewMockRepository()
+	resumed := newUnfinishedGame("resumed")
+	resumed.Game.PlayedAt = "2025-01-02T10:00:00Z"
+	for _, unfinished := range []*domain.UnfinishedGame{newUnfinishedGame("forfeited"), resumed} {
+		if err := repo.SaveUnfinishedGame(unfinished); err != nil {
+			t.Fatalf("SaveUnfinishedGame() error = %v", err)
+		}
+	}
+
+	useCase := usecase.NewGameUseCase(repo, randomness.NewMockRandomGenerator([]domain.Move{}))
+	useCase.SetUnfinishedGames(repo)
+	cli := NewGameCLI(useCase, usecase.NewStatsUseCase(repo), nil)
+	cli.terminal = nil
+
+	// The most recent game is resumed and won, then the other one is
+	// forfeited by Alice
+	cli.reader = bufio.NewReader(strings.NewReader("1\nr\ns\nf1\nx\nalice\n"))
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	if err := cli.offerUnfinishedGames(); err != nil {
+		t.Errorf("offerUnfinishedGames() error = %v", err)
+	}
+
+	w.Close()
+	os.Stdout = old
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	output := buf.String()
+
+	for _, expected := range []string{
+		"1. Alice vs Bob - Rock Paper Scissors, Best of 3, 1-0 after round 1, started 2025-01-02T10:00:00Z",
+		"Resuming Alice vs Bob after round 1",
+		"Round 2 of 3:",
+		"Alice won in 2 rounds!",
+		"Invalid player, please try again",
+		"Alice forfeits, Bob wins the game.",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("offerUnfinishedGames() output missing expected string: %s", expected)
+		}
+	}
+
+	if len(repo.Unfinished) != 0 {
+		t.Errorf("unfinished games left: %d", len(repo.Unfinished))
+	}
+	if len(repo.Games) != 2 {
+		t.Fatalf("stored games = %d, want 2", len(repo.Games))
+	}
+	if game := repo.Games[0]; game.ID != "resumed" || game.Winner != "Alice" || game.Forfeit || len(game.Rounds) != 2 {
+		t.Errorf("resumed game = %+v", game)
+	}
+	if game := repo.Games[1]; game.ID != "forfeited" || game.Winner != "Bob" || !game.Forfeit {
+		t.Errorf("forfeited game = %+v", game)
+	}
+}
@@ -0,0 +1,324 @@
+package http
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/usecase"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+//go:embed openapi.yaml
+var openAPIDocument []byte
+
+// Server exposes the game over a REST API. Games in progress are played in
+// the sessions, finished games are read from the history.
+type Server struct {
+	sessions     *usecase.SessionManager
+	history      *usecase.GameUseCase
+	statsUseCase *usecase.StatsUseCase
+	mux          *http.ServeMux
+}
+
+func NewServer(sessions *usecase.SessionManager, history *usecase.GameUseCase, statsUseCase *usecase.StatsUseCase) *Server {
+	s := &Server{
+		sessions:     sessions,
+		history:      history,
+		statsUseCase: statsUseCase,
+		mux:          http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("POST /games", s.createGame)
+	s.mux.HandleFunc("GET /games", s.listGames)
+	s.mux.HandleFunc("GET /games/{id}", s.getGame)
+	s.mux.HandleFunc("POST /games/{id}/rounds", s.playRound)
+	s.mux.HandleFunc("GET /leaderboard", s.getLeaderboard)
+	s.mux.HandleFunc("GET /players/{name}/stats", s.getPlayerStats)
+	s.mux.HandleFunc("GET /openapi.yaml", s.getOpenAPI)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) createGame(w http.ResponseWriter, r *http.Request) {
+	var req createGameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	mode, err := domain.ParseGameType(req.Mode)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	format := domain.DefaultMatchFormat
+	if req.Format != "" {
+		if format, err = domain.ParseMatchFormat(req.Format); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	rules, err := domain.RuleSetByName(req.RuleSet)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	player2 := req.Player2
+	difficulty := domain.Easy
+	if mode == domain.PlayerVsBot {
+		player2 = "Bot"
+		if req.Difficulty != "" {
+			if difficulty, err = domain.ParseDifficulty(req.Difficulty); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+	}
+
+	state, err := s.sessions.Start(mode, format, rules, req.Player1, player2, difficulty)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newGameState(state.Game, state))
+}
+
+func (s *Server) playRound(w http.ResponseWriter, r *http.Request) {
+	var req playRoundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	id := r.PathValue("id")
+	current, err := s.sessions.Get(id)
+	if errors.Is(err, domain.ErrNotFound) {
+		s.writeFinishedOrNotFound(w, r, id)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rules, err := domain.RuleSetByName(current.Game.RuleSet)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	move1, err := rules.ParseMove(req.Move1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid move1: %w", err))
+		return
+	}
+	var move2 domain.Move
+	if current.Game.Mode == domain.PlayerVsPlayer {
+		if move2, err = rules.ParseMove(req.Move2); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid move2: %w", err))
+			return
+		}
+	}
+
+	state, err := s.sessions.PlayRound(r.Context(), id, move1, move2)
+	if errors.Is(err, domain.ErrNotFound) {
+		s.writeFinishedOrNotFound(w, r, id)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newGameState(state.Game, state))
+}
+
+// writeFinishedOrNotFound answers a round submitted to a game that is not in
+// progress.
+func (s *Server) writeFinishedOrNotFound(w http.ResponseWriter, r *http.Request, id string) {
+	if _, err := s.history.GetGame(r.Context(), id); err == nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("game %s is already finished", id))
+		return
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("game %s %w", id, domain.ErrNotFound))
+}
+
+func (s *Server) getGame(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if state, err := s.sessions.Get(id); err == nil {
+		writeJSON(w, http.StatusOK, newGameState(state.Game, state))
+		return
+	}
+
+	game, err := s.history.GetGame(r.Context(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newGameState(game, nil))
+}
+
+func (s *Server) listGames(w http.ResponseWriter, r *http.Request) {
+	query, err := historyQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	history, err := s.history.QueryHistory(r.Context(), query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	page := gamePage{
+		Games:  make([]gameState, len(history.Games)),
+		Total:  history.Total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	for i, game := range history.Games {
+		page.Games[i] = newGameState(game, nil)
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// historyQuery reads the filters and the page of a game listing.
+func historyQuery(r *http.Request) (domain.HistoryQuery, error) {
+	params := r.URL.Query()
+	query := domain.HistoryQuery{
+		Player: params.Get("player"),
+		Winner: params.Get("winner"),
+	}
+
+	var err error
+	query.Limit, err = queryInt(r, "limit", defaultPageSize)
+	if err != nil || query.Limit < 1 || query.Limit > maxPageSize {
+		return query, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	query.Offset, err = queryInt(r, "offset", 0)
+	if err != nil || query.Offset < 0 {
+		return query, fmt.Errorf("offset cannot be negative")
+	}
+
+	if value := params.Get("mode"); value != "" {
+		mode, err := domain.ParseGameType(value)
+		if err != nil {
+			return query, err
+		}
+		query.Mode = &mode
+	}
+	if value := params.Get("order"); value != "" {
+		if query.Order, err = domain.ParseSortOrder(value); err != nil {
+			return query, err
+		}
+	}
+	dates := []struct {
+		name   string
+		target *time.Time
+	}{{"from", &query.From}, {"to", &query.To}}
+	for _, date := range dates {
+		if value := params.Get(date.name); value != "" {
+			if *date.target, err = time.Parse(time.RFC3339, value); err != nil {
+				return query, fmt.Errorf("%s must be an RFC 3339 date-time", date.name)
+			}
+		}
+	}
+
+	return query, query.Validate()
+}
+
+func (s *Server) getLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultPageSize)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxPageSize))
+		return
+	}
+
+	leaderboard, err := s.statsUseCase.GetLeaderboard(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	entries := make([]playerStats, len(leaderboard))
+	for i, stats := range leaderboard {
+		entries[i] = newPlayerStats(stats)
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) getPlayerStats(w http.ResponseWriter, r *http.Request) {
+	player := r.PathValue("name")
+	if err := domain.ValidatePlayerName(player); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, records, err := s.statsUseCase.GetPlayerStats(r.Context(), player)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response := playerStatsResponse{
+		playerStats: newPlayerStats(stats),
+		HeadToHead:  make([]headToHead, len(records)),
+	}
+	for i, record := range records {
+		response.HeadToHead[i] = headToHead{
+			Opponent: record.Opponent,
+			Wins:     record.Wins,
+			Losses:   record.Losses,
+			Draws:    record.Draws,
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPIDocument)
+}
+
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/randomness"
+	"protofire-game/internal/repository"
+	"protofire-game/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, botMoves ...domain.Move) (*httptest.Server, *repository.MockRepository) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator(append(botMoves, domain.Rock))
+	newGame := func() *usecase.GameUseCase {
+		game := usecase.NewGameUseCase(repo, randGen)
+		game.SetStrategies(randomness.NewStrategies(randGen))
+		return game
+	}
+
+	sessions := usecase.NewSessionManager(newGame, usecase.DefaultSessionIdleTimeout)
+	server := httptest.NewServer(NewServer(sessions, newGame(), usecase.NewStatsUseCase(repo)))
+	t.Cleanup(server.Close)
+	return server, repo
+}
+
+func doJSON(t *testing.T, method, url string, body interface{}, out interface{}) int {
+	var reader bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&reader).Encode(body))
+	}
+
+	req, err := http.NewRequest(method, url, &reader)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+func TestPlayerVsPlayerGame(t *testing.T) {
+	server, repo := newTestServer(t)
+
+	var game gameState
+	status := doJSON(t, "POST", server.URL+"/games", createGameRequest{
+		Mode: "pvp", Player1: "Alice", Player2: "Bob", Format: "ft2", RuleSet: "rpsls",
+	}, &game)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "in_progress", game.Status)
+	assert.Equal(t, "ft2", game.Format)
+	assert.Len(t, game.Moves, 5)
+	assert.Empty(t, game.Rounds)
+
+	roundsURL := fmt.Sprintf("%s/games/%s/rounds", server.URL, game.ID)
+	status = doJSON(t, "POST", roundsURL, playRoundRequest{Move1: "spock", Move2: "r"}, &game)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "in_progress", game.Status)
+	assert.Equal(t, round{Move1: "Spock", Move2: "Rock", Winner: "Alice"}, game.Rounds[0])
+
+	status = doJSON(t, "GET", server.URL+"/games/"+game.ID, nil, &game)
+	require.Equal(t, http.StatusOK, status)
+	assert.Len(t, game.Rounds, 1)
+
+	status = doJSON(t, "POST", roundsURL, playRoundRequest{Move1: "l", Move2: "k"}, &game)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "finished", game.Status)
+	assert.Equal(t, "Alice", game.Winner)
+	require.Len(t, repo.Games, 1)
+
+	var errResp errorResponse
+	status = doJSON(t, "POST", roundsURL, playRoundRequest{Move1: "r", Move2: "r"}, &errResp)
+	assert.Equal(t, http.StatusConflict, status)
+
+	status = doJSON(t, "GET", server.URL+"/games/"+game.ID, nil, &game)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "finished", game.Status)
+	assert.Len(t, game.Rounds, 2)
+}
+
+func TestPlayerVsBotGame(t *testing.T) {
+	server, _ := newTestServer(t, domain.Scissors, domain.Scissors)
+
+	var game gameState
+	status := doJSON(t, "POST", server.URL+"/games", createGameRequest{Mode: "bot", Player1: "Alice"}, &game)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "Bot", game.Player2)
+	assert.Equal(t, "easy", game.Difficulty)
+	commitment := game.BotCommitment
+	assert.Len(t, commitment, 64)
+
+	roundsURL := fmt.Sprintf("%s/games/%s/rounds", server.URL, game.ID)
+	status = doJSON(t, "POST", roundsURL, playRoundRequest{Move1: "rock"}, &game)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, commitment, game.Rounds[0].Commitment)
+	assert.Equal(t, "Scissors", game.Rounds[0].Move2)
+	assert.NotEqual(t, commitment, game.BotCommitment)
+
+	var finished gameState
+	status = doJSON(t, "POST", roundsURL, playRoundRequest{Move1: "rock"}, &finished)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Alice", finished.Winner)
+	assert.Empty(t, finished.BotCommitment)
+}
+
+func TestRequestErrors(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	var game gameState
+	require.Equal(t, http.StatusCreated, doJSON(t, "POST", server.URL+"/games", createGameRequest{Mode: "pvp", Player1: "Alice", Player2: "Bob"}, &game))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+		err    string
+	}{
+		{"unknown mode", "POST", "/games", createGameRequest{Mode: "solo", Player1: "Alice"}, http.StatusBadRequest, `unknown game mode "solo"`},
+		{"invalid format", "POST", "/games", createGameRequest{Mode: "bot", Player1: "Alice", Format: "bo0"}, http.StatusBadRequest, "number of rounds must be at least 1"},
+		{"invalid name", "POST", "/games", createGameRequest{Mode: "bot", Player1: ""}, http.StatusBadRequest, "invalid player1 name: name cannot be empty"},
+		{"invalid move", "POST", "/games/" + game.ID + "/rounds", playRoundRequest{Move1: "lizard", Move2: "rock"}, http.StatusBadRequest, `invalid move1: invalid move "lizard" for Rock Paper Scissors`},
+		{"unknown game", "GET", "/games/unknown", nil, http.StatusNotFound, "game unknown not found"},
+		{"round of unknown game", "POST", "/games/unknown/rounds", playRoundRequest{Move1: "r", Move2: "r"}, http.StatusNotFound, "game unknown not found"},
+		{"invalid limit", "GET", "/games?limit=0", nil, http.StatusBadRequest, "limit must be between 1 and 100"},
+		{"invalid date", "GET", "/games?from=yesterday", nil, http.StatusBadRequest, "from must be an RFC 3339 date-time"},
+		{"empty date range", "GET", "/games?from=2025-01-02T00:00:00Z&to=2025-01-01T00:00:00Z", nil, http.StatusBadRequest, "the date range ends before it starts"},
+		{"unknown order", "GET", "/games?order=random", nil, http.StatusBadRequest, `unknown sort order "random", use newest or oldest`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errResp errorResponse
+			status := doJSON(t, tt.method, server.URL+tt.path, tt.body, &errResp)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.err, errResp.Error)
+		})
+	}
+}
+
+func TestListGamesAndStats(t *testing.T) {
+	server, repo := newTestServer(t)
+	for i := 0; i < 5; i++ {
+		repo.Games = append(repo.Games, &domain.Game{
+			ID:       fmt.Sprintf("game%d", i),
+			Player1:  "Alice",
+			Player2:  "Bob",
+			Winner:   "Alice",
+			RuleSet:  "rps",
+			PlayedAt: fmt.Sprintf("2025-01-0%dT10:00:00Z", i+1),
+		})
+	}
+
+	// Most recent first
+	var page gamePage
+	status := doJSON(t, "GET", server.URL+"/games?limit=2&offset=3", nil, &page)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 5, page.Total)
+	require.Len(t, page.Games, 2)
+	assert.Equal(t, "game1", page.Games[0].ID)
+	assert.Equal(t, "finished", page.Games[0].Status)
+
+	status = doJSON(t, "GET", server.URL+"/games?offset=10", nil, &page)
+	require.Equal(t, http.StatusOK, status)
+	assert.Empty(t, page.Games)
+
+	status = doJSON(t, "GET", server.URL+"/games?order=oldest&from=2025-01-02T00:00:00Z&to=2025-01-04T00:00:00Z&player=Bob&mode=pvp", nil, &page)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 2, page.Total)
+	require.Len(t, page.Games, 2)
+	assert.Equal(t, "game1", page.Games[0].ID)
+	assert.Equal(t, "game2", page.Games[1].ID)
+
+	status = doJSON(t, "GET", server.URL+"/games?winner=Bob", nil, &page)
+	require.Equal(t, http.StatusOK, status)
+	assert.Zero(t, page.Total)
+	assert.Empty(t, page.Games)
+
+	var stats playerStatsResponse
+	status = doJSON(t, "GET", server.URL+"/players/Bob/stats", nil, &stats)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 5, stats.Losses)
+	assert.Equal(t, []headToHead{{Opponent: "Alice", Losses: 5}}, stats.HeadToHead)
+
+	var leaderboard []playerStats
+	status = doJSON(t, "GET", server.URL+"/leaderboard?limit=1", nil, &leaderboard)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, leaderboard, 1)
+	assert.Equal(t, "Alice", leaderboard[0].Player)
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	resp, err := http.Get(server.URL + "/openapi.yaml")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/yaml", resp.Header.Get("Content-Type"))
+}
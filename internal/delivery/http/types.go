@@ -0,0 +1,141 @@
+package http
+
+import (
+	"strings"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/usecase"
+)
+
+// The request and response bodies below are described in openapi.yaml.
+
+type createGameRequest struct {
+	Mode       string `json:"mode"`
+	Player1    string `json:"player1"`
+	Player2    string `json:"player2"`
+	Format     string `json:"format"`
+	RuleSet    string `json:"rule_set"`
+	Difficulty string `json:"difficulty"`
+}
+
+type playRoundRequest struct {
+	Move1 string `json:"move1"`
+	Move2 string `json:"move2"`
+}
+
+type gameState struct {
+	ID            string   `json:"id"`
+	Status        string   `json:"status"`
+	Mode          string   `json:"mode"`
+	Player1       string   `json:"player1"`
+	Player2       string   `json:"player2"`
+	Winner        string   `json:"winner,omitempty"`
+	Format        string   `json:"format,omitempty"`
+	RuleSet       string   `json:"rule_set"`
+	Moves         []string `json:"moves"`
+	Difficulty    string   `json:"difficulty,omitempty"`
+	Seed          *uint64  `json:"seed,omitempty"`
+	BotCommitment string   `json:"bot_commitment,omitempty"`
+	Forfeit       bool     `json:"forfeit,omitempty"`
+	PlayedAt      string   `json:"played_at"`
+	Rounds        []round  `json:"rounds"`
+}
+
+type round struct {
+	Move1      string `json:"move1"`
+	Move2      string `json:"move2"`
+	Winner     string `json:"winner"`
+	Commitment string `json:"commitment,omitempty"`
+	Salt       string `json:"salt,omitempty"`
+}
+
+type gamePage struct {
+	Games  []gameState `json:"games"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+type playerStats struct {
+	Player        string  `json:"player"`
+	Games         int     `json:"games"`
+	Wins          int     `json:"wins"`
+	Losses        int     `json:"losses"`
+	Draws         int     `json:"draws"`
+	WinRate       float64 `json:"win_rate"`
+	LongestStreak int     `json:"longest_streak"`
+	FavoriteMove  string  `json:"favorite_move,omitempty"`
+}
+
+type headToHead struct {
+	Opponent string `json:"opponent"`
+	Wins     int    `json:"wins"`
+	Losses   int    `json:"losses"`
+	Draws    int    `json:"draws"`
+}
+
+type playerStatsResponse struct {
+	playerStats
+	HeadToHead []headToHead `json:"head_to_head"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// newGameState describes a game. session is the state of a game in progress
+// and nil for stored games.
+func newGameState(game *domain.Game, session *usecase.SessionState) gameState {
+	state := gameState{
+		ID:       game.ID,
+		Status:   "finished",
+		Mode:     game.Mode.Code(),
+		Player1:  game.Player1,
+		Player2:  game.Player2,
+		Winner:   game.Winner,
+		RuleSet:  game.RuleSet,
+		Seed:     game.Seed,
+		Forfeit:  game.Forfeit,
+		PlayedAt: game.PlayedAt,
+		Rounds:   make([]round, len(game.Rounds)),
+	}
+
+	if rules, err := domain.RuleSetByName(game.RuleSet); err == nil {
+		state.RuleSet = rules.Name
+		for _, move := range rules.Moves {
+			state.Moves = append(state.Moves, move.String())
+		}
+	}
+	if game.Mode == domain.PlayerVsBot {
+		state.Difficulty = strings.ToLower(game.Difficulty.String())
+	}
+	if session != nil && !session.Finished() {
+		state.Status = "in_progress"
+		state.Format = session.Format.Code()
+		state.BotCommitment = session.BotCommitment
+	}
+
+	for i, r := range game.Rounds {
+		state.Rounds[i] = round{
+			Move1:      r.Move1.String(),
+			Move2:      r.Move2.String(),
+			Winner:     r.Winner,
+			Commitment: r.Commitment,
+			Salt:       r.Salt,
+		}
+	}
+	return state
+}
+
+func newPlayerStats(stats *domain.PlayerStats) playerStats {
+	return playerStats{
+		Player:        stats.Player,
+		Games:         stats.Games,
+		Wins:          stats.Wins,
+		Losses:        stats.Losses,
+		Draws:         stats.Draws,
+		WinRate:       stats.WinRate(),
+		LongestStreak: stats.LongestStreak,
+		FavoriteMove:  stats.FavoriteMove,
+	}
+}
@@ -0,0 +1,240 @@
+package tcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Client is a connection to a Server.
+type Client struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	scanner *bufio.Scanner
+}
+
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageSize)
+	return &Client{conn: conn, encoder: json.NewEncoder(conn), scanner: scanner}, nil
+}
+
+func (c *Client) Send(msg Message) error {
+	return c.encoder.Encode(msg)
+}
+
+// Receive waits for the next message from the server.
+func (c *Client) Receive() (Message, error) {
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return Message{}, err
+		}
+		return Message{}, io.EOF
+	}
+
+	var msg Message
+	if err := json.Unmarshal(c.scanner.Bytes(), &msg); err != nil {
+		return Message{}, fmt.Errorf("invalid message: %w", err)
+	}
+	return msg, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// RunClient plays on the server at addr as player, reading commands from in
+// and printing what happens to out. When the connection drops it reconnects
+// for up to reconnectTimeout, resuming the match in progress.
+func RunClient(addr, player string, reconnectTimeout time.Duration, in io.Reader, out io.Writer) error {
+	client, err := Dial(addr)
+	if err != nil {
+		return err
+	}
+	if err := client.Send(Message{Type: TypeHello, Player: player}); err != nil {
+		client.Close()
+		return err
+	}
+
+	fmt.Fprintln(out, "Commands: list, create [format] [rules], join <match>, leave, quit.")
+	fmt.Fprintln(out, "Anything else is sent as your move.")
+
+	// Commands are read in the background so that server messages are
+	// printed while waiting for input.
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
+		}
+	}()
+
+	var token string
+	for {
+		messages := make(chan Message)
+		done := make(chan struct{})
+		go func() {
+			defer close(messages)
+			for {
+				msg, err := client.Receive()
+				if err != nil {
+					return
+				}
+				select {
+				case messages <- msg:
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		welcomed := false
+	session:
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok || line == "quit" {
+					close(done)
+					client.Close()
+					return nil
+				}
+				if line == "" {
+					continue
+				}
+				if err := client.Send(command(line)); err != nil {
+					break session
+				}
+			case msg, ok := <-messages:
+				if !ok {
+					break session
+				}
+				switch {
+				case msg.Type == TypeWelcome:
+					welcomed = true
+					token = msg.Token
+				case msg.Type == TypeError && !welcomed:
+					close(done)
+					client.Close()
+					return fmt.Errorf("server refused to connect: %s", msg.Error)
+				}
+				printMessage(out, msg)
+			}
+		}
+
+		close(done)
+		client.Close()
+		if token == "" {
+			return fmt.Errorf("connection to %s lost", addr)
+		}
+		fmt.Fprintln(out, "Connection lost, reconnecting...")
+		if client, err = redial(addr, token, reconnectTimeout); err != nil {
+			return err
+		}
+	}
+}
+
+// redial connects again and resumes with token, retrying every second until
+// the timeout.
+func redial(addr, token string, timeout time.Duration) (*Client, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		client, err := Dial(addr)
+		if err == nil {
+			if err = client.Send(Message{Type: TypeHello, Token: token}); err == nil {
+				return client, nil
+			}
+			client.Close()
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to reconnect to %s: %w", addr, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func command(line string) Message {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case "list":
+		return Message{Type: TypeList}
+	case "create":
+		msg := Message{Type: TypeCreate}
+		if len(fields) > 1 {
+			msg.Format = fields[1]
+		}
+		if len(fields) > 2 {
+			msg.RuleSet = fields[2]
+		}
+		return msg
+	case "join":
+		msg := Message{Type: TypeJoin}
+		if len(fields) > 1 {
+			msg.Match = fields[1]
+		}
+		return msg
+	case "leave":
+		return Message{Type: TypeLeave}
+	default:
+		return Message{Type: TypeMove, Move: line}
+	}
+}
+
+func printMessage(out io.Writer, msg Message) {
+	switch msg.Type {
+	case TypeWelcome:
+		fmt.Fprintf(out, "Connected as %s.\n", msg.Player)
+	case TypeLobby:
+		if len(msg.Matches) == 0 {
+			fmt.Fprintln(out, "No open matches. Create one with: create")
+			return
+		}
+		fmt.Fprintln(out, "Open matches:")
+		for _, m := range msg.Matches {
+			fmt.Fprintf(out, "  %s  hosted by %s, %s, %s\n", m.ID, m.Host, m.Format, m.RuleSet)
+		}
+	case TypeCreated:
+		fmt.Fprintf(out, "Created match %s (%s, %s). Waiting for an opponent...\n", msg.Match, msg.Format, msg.RuleSet)
+	case TypeStart:
+		fmt.Fprintf(out, "Match %s started: %s vs %s (%s, %s). Enter your move.\n", msg.Match, msg.Player1, msg.Player2, msg.Format, msg.RuleSet)
+	case TypeResumed:
+		fmt.Fprintf(out, "Resumed match %s: %s vs %s (%s, %s).\n", msg.Match, msg.Player1, msg.Player2, msg.Format, msg.RuleSet)
+		for _, round := range msg.Rounds {
+			printRound(out, round)
+		}
+		if msg.Move != "" {
+			fmt.Fprintf(out, "You played %s, waiting for your opponent...\n", msg.Move)
+		} else {
+			fmt.Fprintln(out, "Enter your move.")
+		}
+	case TypeMoveAccepted:
+		fmt.Fprintf(out, "You played %s, waiting for your opponent...\n", msg.Move)
+	case TypeOpponentMoved:
+		fmt.Fprintln(out, "Your opponent has moved.")
+	case TypeRound:
+		printRound(out, *msg.Round)
+	case TypeFinished:
+		fmt.Fprintf(out, "Game %s finished. Winner: %s\n", msg.GameID, msg.Winner)
+	case TypeOpponentDisconnected:
+		fmt.Fprintf(out, "%s disconnected, waiting for them to come back...\n", msg.Player)
+	case TypeOpponentReconnected:
+		fmt.Fprintf(out, "%s is back.\n", msg.Player)
+	case TypeAbandoned:
+		fmt.Fprintf(out, "Match %s was abandoned.\n", msg.Match)
+	case TypeError:
+		fmt.Fprintf(out, "Error: %s\n", msg.Error)
+	}
+}
+
+func printRound(out io.Writer, round RoundInfo) {
+	fmt.Fprintf(out, "Round %d: %s vs %s - %s\n", round.Number, round.Move1, round.Move2, round.Winner)
+}
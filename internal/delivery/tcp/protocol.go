@@ -0,0 +1,62 @@
+package tcp
+
+// Clients and the server exchange Messages as newline-delimited JSON. A
+// client starts with a hello, then creates or joins a match in the lobby and
+// sends one move per round. Moves are kept by the server until both players
+// have moved, so neither sees the other's move before committing to its own.
+const (
+	// Client to server
+	TypeHello  = "hello"  // Player, and Token to resume after a disconnection
+	TypeList   = "list"   // lists the matches waiting for an opponent
+	TypeCreate = "create" // Format and RuleSet, both optional
+	TypeJoin   = "join"   // Match
+	TypeMove   = "move"   // Move, by name or shortcut
+	TypeLeave  = "leave"  // abandons the current match
+
+	// Server to client
+	TypeWelcome              = "welcome" // Token to reconnect with
+	TypeLobby                = "lobby"   // Matches
+	TypeCreated              = "created" // Match
+	TypeStart                = "start"   // Match, GameID, Player1, Player2, Format, RuleSet
+	TypeMoveAccepted         = "move_accepted"
+	TypeOpponentMoved        = "opponent_moved"
+	TypeRound                = "round"    // Round
+	TypeFinished             = "finished" // GameID, Winner
+	TypeResumed              = "resumed"  // the start fields, Rounds, and Move if already moved this round
+	TypeOpponentDisconnected = "opponent_disconnected"
+	TypeOpponentReconnected  = "opponent_reconnected"
+	TypeAbandoned            = "abandoned" // the match ended without a winner
+	TypeError                = "error"     // Error
+)
+
+type Message struct {
+	Type    string      `json:"type"`
+	Player  string      `json:"player,omitempty"`
+	Token   string      `json:"token,omitempty"`
+	Match   string      `json:"match,omitempty"`
+	Format  string      `json:"format,omitempty"`
+	RuleSet string      `json:"rule_set,omitempty"`
+	Move    string      `json:"move,omitempty"`
+	Matches []MatchInfo `json:"matches,omitempty"`
+	GameID  string      `json:"game_id,omitempty"`
+	Player1 string      `json:"player1,omitempty"`
+	Player2 string      `json:"player2,omitempty"`
+	Round   *RoundInfo  `json:"round,omitempty"`
+	Rounds  []RoundInfo `json:"rounds,omitempty"`
+	Winner  string      `json:"winner,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type MatchInfo struct {
+	ID      string `json:"id"`
+	Host    string `json:"host"`
+	Format  string `json:"format"`
+	RuleSet string `json:"rule_set"`
+}
+
+type RoundInfo struct {
+	Number int    `json:"number"`
+	Move1  string `json:"move1"`
+	Move2  string `json:"move2"`
+	Winner string `json:"winner"`
+}
@@ -0,0 +1,518 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+// DefaultReconnectTimeout is how long a match waits for a disconnected
+// player to come back before it is abandoned.
+const DefaultReconnectTimeout = time.Minute
+
+const (
+	maxMessageSize = 64 * 1024
+	outgoingBuffer = 64
+)
+
+// Server hosts a lobby where remote players create and join Player vs
+// Player matches. Matches are played in the sessions, which store them once
+// finished.
+type Server struct {
+	sessions         *usecase.SessionManager
+	reconnectTimeout time.Duration
+	timeout          time.Duration
+
+	// rounds are the rounds being played, each in its own goroutine.
+	rounds sync.WaitGroup
+
+	mu      sync.Mutex
+	players map[string]*player // by token
+	matches map[string]*match  // by match ID
+	conns   map[*conn]struct{}
+}
+
+type player struct {
+	name  string
+	token string
+	conn  *conn // nil while disconnected
+	match *match
+	timer *time.Timer
+}
+
+type match struct {
+	id      string
+	format  domain.MatchFormat
+	rules   *domain.RuleSet
+	host    *player
+	guest   *player
+	gameID  string
+	moves   map[*player]domain.Move
+	rounds  []RoundInfo
+	started bool
+}
+
+type conn struct {
+	net.Conn
+	out    chan Message
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewServer(sessions *usecase.SessionManager, reconnectTimeout time.Duration) *Server {
+	return &Server{
+		sessions:         sessions,
+		reconnectTimeout: reconnectTimeout,
+		players:          make(map[string]*player),
+		matches:          make(map[string]*match),
+		conns:            make(map[*conn]struct{}),
+	}
+}
+
+// SetTimeout sets how long playing a round may take, storing the game when it
+// is the last one, 0 for no limit.
+func (s *Server) SetTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// Serve accepts connections on ln until ctx is done, then waits for the
+// rounds being played.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for c := range s.conns {
+			c.close()
+		}
+	}()
+
+	for {
+		netConn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				s.rounds.Wait()
+				return nil
+			}
+			return err
+		}
+
+		c := &conn{Conn: netConn, out: make(chan Message, outgoingBuffer)}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+
+		go c.writeLoop()
+		go s.handle(ctx, c)
+	}
+}
+
+func (s *Server) handle(ctx context.Context, c *conn) {
+	defer func() {
+		c.close()
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+	}()
+
+	scanner := bufio.NewScanner(c)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageSize)
+
+	var p *player
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			c.send(errorMessage(fmt.Errorf("invalid message: %w", err)))
+			continue
+		}
+
+		s.mu.Lock()
+		if p == nil {
+			var err error
+			if p, err = s.hello(c, msg); err != nil {
+				c.send(errorMessage(err))
+			}
+		} else {
+			if err := s.dispatch(ctx, p, msg); err != nil {
+				c.send(errorMessage(err))
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	if p != nil {
+		s.mu.Lock()
+		s.disconnected(p, c)
+		s.mu.Unlock()
+	}
+}
+
+// hello identifies the player of a new connection, either a new player or
+// one coming back with their token.
+func (s *Server) hello(c *conn, msg Message) (*player, error) {
+	if msg.Type != TypeHello {
+		return nil, fmt.Errorf("expected %s, got %q", TypeHello, msg.Type)
+	}
+
+	if msg.Token != "" {
+		p, ok := s.players[msg.Token]
+		if !ok {
+			return nil, fmt.Errorf("unknown token")
+		}
+		if p.conn != nil {
+			// The old connection may not have noticed it is gone yet.
+			p.conn.close()
+		}
+		s.reconnected(p, c)
+		return p, nil
+	}
+
+	if err := domain.ValidatePlayerName(msg.Player); err != nil {
+		return nil, fmt.Errorf("invalid player name: %w", err)
+	}
+	for _, other := range s.players {
+		if strings.EqualFold(other.name, msg.Player) {
+			return nil, fmt.Errorf("player name %s is already in use", msg.Player)
+		}
+	}
+
+	p := &player{name: msg.Player, token: uuid.New().String(), conn: c}
+	s.players[p.token] = p
+	c.send(Message{Type: TypeWelcome, Player: p.name, Token: p.token})
+	return p, nil
+}
+
+func (s *Server) dispatch(ctx context.Context, p *player, msg Message) error {
+	switch msg.Type {
+	case TypeList:
+		p.send(s.lobby())
+		return nil
+	case TypeCreate:
+		return s.create(p, msg)
+	case TypeJoin:
+		return s.join(p, msg)
+	case TypeMove:
+		return s.move(ctx, p, msg)
+	case TypeLeave:
+		if p.match == nil {
+			return fmt.Errorf("not in a match")
+		}
+		s.abandon(p.match)
+		return nil
+	default:
+		return fmt.Errorf("unknown message type %q", msg.Type)
+	}
+}
+
+func (s *Server) lobby() Message {
+	msg := Message{Type: TypeLobby, Matches: []MatchInfo{}}
+	for _, m := range s.matches {
+		if !m.started {
+			msg.Matches = append(msg.Matches, MatchInfo{
+				ID:      m.id,
+				Host:    m.host.name,
+				Format:  m.format.Code(),
+				RuleSet: m.rules.Name,
+			})
+		}
+	}
+	return msg
+}
+
+func (s *Server) create(p *player, msg Message) error {
+	if p.match != nil {
+		return fmt.Errorf("already in a match")
+	}
+
+	format := domain.DefaultMatchFormat
+	if msg.Format != "" {
+		var err error
+		if format, err = domain.ParseMatchFormat(msg.Format); err != nil {
+			return err
+		}
+	}
+	rules, err := domain.RuleSetByName(msg.RuleSet)
+	if err != nil {
+		return err
+	}
+
+	m := &match{
+		id:     uuid.New().String()[:8],
+		format: format,
+		rules:  rules,
+		host:   p,
+		moves:  make(map[*player]domain.Move),
+	}
+	s.matches[m.id] = m
+	p.match = m
+
+	p.send(Message{Type: TypeCreated, Match: m.id, Format: format.Code(), RuleSet: rules.Name})
+	return nil
+}
+
+func (s *Server) join(p *player, msg Message) error {
+	if p.match != nil {
+		return fmt.Errorf("already in a match")
+	}
+
+	m, ok := s.matches[msg.Match]
+	if !ok || m.started {
+		return fmt.Errorf("match %s is not open", msg.Match)
+	}
+
+	state, err := s.sessions.Start(domain.PlayerVsPlayer, m.format, m.rules, m.host.name, p.name, domain.Easy)
+	if err != nil {
+		return err
+	}
+
+	m.guest = p
+	m.gameID = state.Game.ID
+	m.started = true
+	p.match = m
+
+	start := m.info(TypeStart)
+	m.host.send(start)
+	m.guest.send(start)
+	return nil
+}
+
+func (s *Server) move(ctx context.Context, p *player, msg Message) error {
+	m := p.match
+	if m == nil || !m.started {
+		return fmt.Errorf("no match in progress")
+	}
+	if _, ok := m.moves[p]; ok {
+		return fmt.Errorf("already moved this round")
+	}
+
+	move, err := m.rules.ParseMove(msg.Move)
+	if err != nil {
+		return err
+	}
+	m.moves[p] = move
+
+	p.send(Message{Type: TypeMoveAccepted, Move: move.String()})
+	opponent := m.opponent(p)
+	if _, ok := m.moves[opponent]; !ok {
+		opponent.send(Message{Type: TypeOpponentMoved})
+		return nil
+	}
+
+	// The round is played, and the game stored when it is the last one,
+	// without holding the lobby. The moves are kept meanwhile, so that
+	// neither player moves again.
+	s.rounds.Add(1)
+	go s.playRound(ctx, m, m.moves[m.host], m.moves[m.guest])
+	return nil
+}
+
+// playRound plays a round of the match and reports it to the players. A
+// round that could not be played, as when the game could not be stored, is
+// undone by the sessions, and the players are asked to play it again.
+func (s *Server) playRound(ctx context.Context, m *match, move1, move2 domain.Move) {
+	defer s.rounds.Done()
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	state, err := s.sessions.PlayRound(ctx, m.gameID, move1, move2)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.matches[m.id] != m {
+		return // Abandoned meanwhile
+	}
+	clear(m.moves)
+
+	if errors.Is(err, domain.ErrNotFound) {
+		m.broadcast(errorMessage(fmt.Errorf("failed to play round: %w", err)))
+		s.abandon(m)
+		return
+	}
+	if err != nil {
+		m.broadcast(errorMessage(fmt.Errorf("failed to play round, play it again: %w", err)))
+		return
+	}
+
+	last := state.Game.Rounds[len(state.Game.Rounds)-1]
+	round := RoundInfo{
+		Number: len(state.Game.Rounds),
+		Move1:  last.Move1.String(),
+		Move2:  last.Move2.String(),
+		Winner: last.Winner,
+	}
+	m.rounds = append(m.rounds, round)
+	m.broadcast(Message{Type: TypeRound, Round: &round})
+
+	if state.Finished() {
+		m.broadcast(Message{Type: TypeFinished, GameID: m.gameID, Winner: state.Game.Winner})
+		s.end(m)
+	}
+}
+
+// disconnected keeps the player of a match in progress for the reconnect
+// timeout, and forgets any other player.
+func (s *Server) disconnected(p *player, c *conn) {
+	if p.conn != c {
+		return // Already reconnected
+	}
+	p.conn = nil
+
+	m := p.match
+	if m == nil || !m.started {
+		if m != nil {
+			s.end(m)
+		}
+		delete(s.players, p.token)
+		return
+	}
+
+	m.opponent(p).send(Message{Type: TypeOpponentDisconnected, Player: p.name})
+	p.timer = time.AfterFunc(s.reconnectTimeout, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if p.conn == nil && p.match == m {
+			s.abandon(m)
+		}
+	})
+}
+
+func (s *Server) reconnected(p *player, c *conn) {
+	p.conn = c
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+
+	c.send(Message{Type: TypeWelcome, Player: p.name, Token: p.token})
+	m := p.match
+	if m == nil || !m.started {
+		return
+	}
+
+	resumed := m.info(TypeResumed)
+	resumed.Rounds = m.rounds
+	if move, ok := m.moves[p]; ok {
+		resumed.Move = move.String()
+	}
+	p.send(resumed)
+	m.opponent(p).send(Message{Type: TypeOpponentReconnected, Player: p.name})
+}
+
+// abandon ends a match without a winner. The game in progress is left to
+// expire in the sessions.
+func (s *Server) abandon(m *match) {
+	m.broadcast(Message{Type: TypeAbandoned, Match: m.id})
+	s.end(m)
+}
+
+func (s *Server) end(m *match) {
+	delete(s.matches, m.id)
+	for _, p := range []*player{m.host, m.guest} {
+		if p == nil {
+			continue
+		}
+		p.match = nil
+		if p.conn == nil {
+			if p.timer != nil {
+				p.timer.Stop()
+			}
+			delete(s.players, p.token)
+		}
+	}
+}
+
+func (m *match) opponent(p *player) *player {
+	if p == m.host {
+		return m.guest
+	}
+	return m.host
+}
+
+func (m *match) info(messageType string) Message {
+	return Message{
+		Type:    messageType,
+		Match:   m.id,
+		GameID:  m.gameID,
+		Player1: m.host.name,
+		Player2: m.guest.name,
+		Format:  m.format.Code(),
+		RuleSet: m.rules.Name,
+	}
+}
+
+func (m *match) broadcast(msg Message) {
+	m.host.send(msg)
+	if m.guest != nil {
+		m.guest.send(msg)
+	}
+}
+
+func (p *player) send(msg Message) {
+	if p.conn != nil {
+		p.conn.send(msg)
+	}
+}
+
+// send queues a message without blocking. A client too slow to keep up
+// with its messages is disconnected.
+func (c *conn) send(msg Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+
+	select {
+	case c.out <- msg:
+	default:
+		c.closeLocked()
+	}
+}
+
+// writeLoop writes the queued messages, and closes the connection once they
+// are all written after close.
+func (c *conn) writeLoop() {
+	defer c.Conn.Close()
+
+	encoder := json.NewEncoder(c.Conn)
+	for msg := range c.out {
+		c.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := encoder.Encode(msg); err != nil {
+			c.close()
+			return
+		}
+	}
+}
+
+func (c *conn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+func (c *conn) closeLocked() {
+	if !c.closed {
+		c.closed = true
+		close(c.out)
+	}
+}
+
+func errorMessage(err error) Message {
+	return Message{Type: TypeError, Error: err.Error()}
+}
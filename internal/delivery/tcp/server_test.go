@@ -0,0 +1,253 @@
+package tcp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/randomness"
+	"protofire-game/internal/repository"
+	"protofire-game/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startTestServer(t *testing.T, reconnectTimeout time.Duration) (string, *repository.MockRepository) {
+	repo := repository.NewMockRepository()
+	return serveTestRepository(t, repo, reconnectTimeout, 0), repo
+}
+
+// serveTestRepository starts a server storing its games in repo.
+func serveTestRepository(t *testing.T, repo domain.GameRepository, reconnectTimeout, timeout time.Duration) string {
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{domain.Rock})
+	sessions := usecase.NewSessionManager(func() *usecase.GameUseCase {
+		return usecase.NewGameUseCase(repo, randGen)
+	}, usecase.DefaultSessionIdleTimeout)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	server := NewServer(sessions, reconnectTimeout)
+	server.SetTimeout(timeout)
+	go func() {
+		served <- server.Serve(ctx, ln)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-served)
+	})
+
+	return ln.Addr().String()
+}
+
+// stuckRepository holds the games saved until release is closed, or gives
+// them up when their context is done first.
+type stuckRepository struct {
+	*repository.MockRepository
+	release chan struct{}
+}
+
+func (r *stuckRepository) SaveGame(ctx context.Context, game *domain.Game) error {
+	select {
+	case <-r.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return r.MockRepository.SaveGame(ctx, game)
+}
+
+func dial(t *testing.T, addr string, hello Message) *Client {
+	client, err := Dial(addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	require.NoError(t, client.Send(hello))
+	return client
+}
+
+// expect skips messages until one of the given type, failing on errors.
+func expect(t *testing.T, client *Client, messageType string) Message {
+	t.Helper()
+	client.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		msg, err := client.Receive()
+		require.NoError(t, err, "waiting for %s", messageType)
+		if msg.Type == messageType {
+			return msg
+		}
+		require.NotEqual(t, TypeError, msg.Type, msg.Error)
+	}
+}
+
+// startMatch connects Alice and Bob and starts a first to 2 match between
+// them, hosted by Alice.
+func startMatch(t *testing.T, addr string) (alice, bob *Client, aliceToken string) {
+	alice = dial(t, addr, Message{Type: TypeHello, Player: "Alice"})
+	aliceToken = expect(t, alice, TypeWelcome).Token
+	bob = dial(t, addr, Message{Type: TypeHello, Player: "Bob"})
+	expect(t, bob, TypeWelcome)
+
+	require.NoError(t, alice.Send(Message{Type: TypeCreate, Format: "ft2", RuleSet: "rps"}))
+	created := expect(t, alice, TypeCreated)
+
+	require.NoError(t, bob.Send(Message{Type: TypeList}))
+	lobby := expect(t, bob, TypeLobby)
+	require.Len(t, lobby.Matches, 1)
+	assert.Equal(t, MatchInfo{ID: created.Match, Host: "Alice", Format: "ft2", RuleSet: "rps"}, lobby.Matches[0])
+
+	require.NoError(t, bob.Send(Message{Type: TypeJoin, Match: created.Match}))
+	start := expect(t, alice, TypeStart)
+	assert.Equal(t, "Alice", start.Player1)
+	assert.Equal(t, "Bob", start.Player2)
+	assert.Equal(t, start, expect(t, bob, TypeStart))
+	return alice, bob, aliceToken
+}
+
+func playRound(t *testing.T, alice, bob *Client, move1, move2 string) RoundInfo {
+	t.Helper()
+	require.NoError(t, alice.Send(Message{Type: TypeMove, Move: move1}))
+	expect(t, alice, TypeMoveAccepted)
+	require.NoError(t, bob.Send(Message{Type: TypeMove, Move: move2}))
+
+	round := expect(t, alice, TypeRound).Round
+	assert.Equal(t, round, expect(t, bob, TypeRound).Round)
+	return *round
+}
+
+func TestNetworkedMatch(t *testing.T) {
+	addr, repo := startTestServer(t, time.Minute)
+	alice, bob, _ := startMatch(t, addr)
+
+	// Bob only learns that Alice has moved, not her move
+	require.NoError(t, alice.Send(Message{Type: TypeMove, Move: "r"}))
+	assert.Equal(t, "Rock", expect(t, alice, TypeMoveAccepted).Move)
+	assert.Equal(t, Message{Type: TypeOpponentMoved}, expect(t, bob, TypeOpponentMoved))
+
+	require.NoError(t, alice.Send(Message{Type: TypeMove, Move: "p"}))
+	assert.Equal(t, "already moved this round", expect(t, alice, TypeError).Error)
+
+	require.NoError(t, bob.Send(Message{Type: TypeMove, Move: "s"}))
+	assert.Equal(t, RoundInfo{Number: 1, Move1: "Rock", Move2: "Scissors", Winner: "Alice"}, *expect(t, bob, TypeRound).Round)
+
+	round := playRound(t, alice, bob, "rock", "paper")
+	assert.Equal(t, RoundInfo{Number: 2, Move1: "Rock", Move2: "Paper", Winner: "Bob"}, round)
+	playRound(t, alice, bob, "paper", "rock")
+
+	finished := expect(t, alice, TypeFinished)
+	assert.Equal(t, "Alice", finished.Winner)
+	assert.Equal(t, finished, expect(t, bob, TypeFinished))
+
+	require.Len(t, repo.Games, 1)
+	game := repo.Games[0]
+	assert.Equal(t, finished.GameID, game.ID)
+	assert.Equal(t, domain.PlayerVsPlayer, game.Mode)
+	assert.Equal(t, "Alice", game.Winner)
+	assert.Len(t, game.Rounds, 3)
+}
+
+func TestNetworkedMatchReconnection(t *testing.T) {
+	addr, repo := startTestServer(t, time.Minute)
+	alice, bob, token := startMatch(t, addr)
+
+	playRound(t, alice, bob, "rock", "scissors")
+	require.NoError(t, alice.Send(Message{Type: TypeMove, Move: "paper"}))
+	expect(t, alice, TypeMoveAccepted)
+
+	alice.Close()
+	assert.Equal(t, "Alice", expect(t, bob, TypeOpponentDisconnected).Player)
+
+	alice = dial(t, addr, Message{Type: TypeHello, Token: token})
+	assert.Equal(t, "Alice", expect(t, alice, TypeWelcome).Player)
+	resumed := expect(t, alice, TypeResumed)
+	assert.Equal(t, "Bob", resumed.Player2)
+	assert.Equal(t, []RoundInfo{{Number: 1, Move1: "Rock", Move2: "Scissors", Winner: "Alice"}}, resumed.Rounds)
+	assert.Equal(t, "Paper", resumed.Move)
+	expect(t, bob, TypeOpponentReconnected)
+
+	// Alice's move from before the disconnection still counts
+	require.NoError(t, bob.Send(Message{Type: TypeMove, Move: "rock"}))
+	assert.Equal(t, "Alice", expect(t, alice, TypeRound).Round.Winner)
+	assert.Equal(t, "Alice", expect(t, bob, TypeFinished).Winner)
+
+	require.Len(t, repo.Games, 1)
+	assert.Len(t, repo.Games[0].Rounds, 2)
+}
+
+func TestNetworkedMatchAbandonedAfterReconnectTimeout(t *testing.T) {
+	addr, repo := startTestServer(t, 50*time.Millisecond)
+	alice, bob, token := startMatch(t, addr)
+
+	playRound(t, alice, bob, "rock", "scissors")
+	alice.Close()
+	expect(t, bob, TypeOpponentDisconnected)
+	expect(t, bob, TypeAbandoned)
+	assert.Empty(t, repo.Games)
+
+	// The match and the player are gone
+	late := dial(t, addr, Message{Type: TypeHello, Token: token})
+	assert.Equal(t, "unknown token", expect(t, late, TypeError).Error)
+
+	require.NoError(t, bob.Send(Message{Type: TypeMove, Move: "rock"}))
+	assert.Equal(t, "no match in progress", expect(t, bob, TypeError).Error)
+}
+
+func TestPlayerNamesAreUnique(t *testing.T) {
+	addr, _ := startTestServer(t, time.Minute)
+
+	alice := dial(t, addr, Message{Type: TypeHello, Player: "Alice"})
+	expect(t, alice, TypeWelcome)
+
+	other := dial(t, addr, Message{Type: TypeHello, Player: "alice"})
+	assert.Equal(t, "player name alice is already in use", expect(t, other, TypeError).Error)
+}
+
+func TestLobbyIsNotHeldWhileAGameIsStored(t *testing.T) {
+	repo := &stuckRepository{MockRepository: repository.NewMockRepository(), release: make(chan struct{})}
+	addr := serveTestRepository(t, repo, time.Minute, 0)
+	alice, bob, _ := startMatch(t, addr)
+
+	playRound(t, alice, bob, "rock", "scissors")
+	require.NoError(t, alice.Send(Message{Type: TypeMove, Move: "rock"}))
+	expect(t, alice, TypeMoveAccepted)
+	require.NoError(t, bob.Send(Message{Type: TypeMove, Move: "scissors"}))
+
+	// The game is being stored while Carol goes through the lobby.
+	carol := dial(t, addr, Message{Type: TypeHello, Player: "Carol"})
+	expect(t, carol, TypeWelcome)
+	require.NoError(t, carol.Send(Message{Type: TypeList}))
+	assert.Empty(t, expect(t, carol, TypeLobby).Matches)
+	assert.Empty(t, repo.Games)
+
+	close(repo.release)
+	assert.Equal(t, "Alice", expect(t, alice, TypeFinished).Winner)
+	expect(t, bob, TypeFinished)
+	assert.Len(t, repo.Games, 1)
+}
+
+func TestNetworkedMatchGoesOnWhenTheGameCannotBeStored(t *testing.T) {
+	repo := &stuckRepository{MockRepository: repository.NewMockRepository(), release: make(chan struct{})}
+	addr := serveTestRepository(t, repo, time.Minute, 50*time.Millisecond)
+	alice, bob, _ := startMatch(t, addr)
+
+	playRound(t, alice, bob, "rock", "scissors")
+	require.NoError(t, alice.Send(Message{Type: TypeMove, Move: "rock"}))
+	expect(t, alice, TypeMoveAccepted)
+	require.NoError(t, bob.Send(Message{Type: TypeMove, Move: "scissors"}))
+
+	// Storing the game times out, and the last round is played again.
+	assert.Contains(t, expect(t, alice, TypeError).Error, context.DeadlineExceeded.Error())
+	assert.Contains(t, expect(t, bob, TypeError).Error, context.DeadlineExceeded.Error())
+	assert.Empty(t, repo.Games)
+
+	close(repo.release)
+	round := playRound(t, alice, bob, "rock", "scissors")
+	assert.Equal(t, 2, round.Number)
+	assert.Equal(t, "Alice", expect(t, alice, TypeFinished).Winner)
+	require.Len(t, repo.Games, 1)
+	assert.Len(t, repo.Games[0].Rounds, 2)
+}
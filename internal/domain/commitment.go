@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const saltSize = 32
+
+// CommitMove commits to a move before the opponent plays, so it can be
+// revealed afterwards and checked against the commitment. The commitment is
+// the hex encoded sha256(move || salt), with the move as a single byte.
+func CommitMove(move Move) (commitment string, salt string, err error) {
+	saltBytes := make([]byte, saltSize)
+	if _, err := rand.Read(saltBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return hashMove(move, saltBytes), hex.EncodeToString(saltBytes), nil
+}
+
+// VerifyCommitment checks that the bot's move and salt revealed for the
+// round match the commitment published before the round.
+func (r RoundResult) VerifyCommitment() error {
+	if r.Commitment == "" {
+		return fmt.Errorf("round has no commitment")
+	}
+
+	saltBytes, err := hex.DecodeString(r.Salt)
+	if err != nil || len(saltBytes) != saltSize {
+		return fmt.Errorf("invalid salt")
+	}
+
+	if hashMove(r.Move2, saltBytes) != r.Commitment {
+		return fmt.Errorf("commitment does not match %s", r.Move2)
+	}
+	return nil
+}
+
+func hashMove(move Move, salt []byte) string {
+	hash := sha256.Sum256(append([]byte{byte(move)}, salt...))
+	return hex.EncodeToString(hash[:])
+}
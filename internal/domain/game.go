@@ -1,15 +1,23 @@
 package domain
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 )
 
+// ErrNotFound is wrapped by the errors returned for unknown games.
+var ErrNotFound = errors.New("not found")
+
 type Move int
 
 const (
 	Rock Move = iota
 	Paper
 	Scissors
+	Lizard
+	Spock
 )
 
 type GameType int
@@ -19,29 +27,102 @@ const (
 	PlayerVsBot
 )
 
+// Game is a finished game. Games against a seeded bot record the seed and
+// difficulty the bot played with, so its moves can be replayed. Forfeit is
+// set when the loser abandoned the game before it was over. Submitter is the
+// address that stored the game on-chain, when the chain records it.
 type Game struct {
-	ID       string
-	Player1  string
-	Player2  string
-	Winner   string
-	PlayedAt string
+	ID         string
+	Player1    string
+	Player2    string
+	Winner     string
+	Mode       GameType
+	RuleSet    string
+	Difficulty Difficulty
+	Seed       *uint64
+	PlayedAt   string
+	Rounds     []RoundResult
+	Forfeit    bool
+	Submitter  string
 }
 
+// RoundResult holds the moves of a round. In games against the bot,
+// Commitment and Salt prove the bot chose Move2 before Move1 was known.
 type RoundResult struct {
-	Move1  Move
-	Move2  Move
-	Winner string
+	Move1      Move
+	Move2      Move
+	Winner     string
+	Commitment string
+	Salt       string
 }
 
+// GameRepository stores the finished games. Backends reached over the
+// network, like the chain, give up when the context is done. SaveGame fails
+// when PlayedAt is not an RFC 3339 date. GetGame looks a single game up by
+// ID, failing with ErrNotFound when there is none.
 type GameRepository interface {
-	SaveGame(result *Game) error
-	GetGameHistory() ([]*Game, error)
+	SaveGame(ctx context.Context, result *Game) error
+	GetGame(ctx context.Context, id string) (*Game, error)
+	GetGameHistory(ctx context.Context) ([]*Game, error)
+	QueryGameHistory(ctx context.Context, query HistoryQuery) (*HistoryPage, error)
+}
+
+// GameBatchRepository stores many games at once, more cheaply than one at a
+// time, like the chain does in a single transaction.
+type GameBatchRepository interface {
+	SaveGames(ctx context.Context, results []*Game) error
+}
+
+// GameWatcher follows the games stored by every client of a shared backend,
+// like the chain, as they land.
+type GameWatcher interface {
+	// WatchGames calls onGame with each game stored from now on, until the
+	// context is done or the backend cannot be followed anymore.
+	WatchGames(ctx context.Context, onGame func(*Game)) error
+}
+
+// UnfinishedGame is a game in progress, with the rounds played so far and
+// the match format deciding when it is over.
+type UnfinishedGame struct {
+	Game   *Game
+	Format MatchFormat
+}
+
+// UnfinishedGameRepository stores the games in progress after every round,
+// so they can be resumed after the program exits.
+type UnfinishedGameRepository interface {
+	SaveUnfinishedGame(game *UnfinishedGame) error
+	GetUnfinishedGames() ([]*UnfinishedGame, error)
+	DeleteUnfinishedGame(id string) error
 }
 
 type RandomGenerator interface {
-	GenerateMove() Move
+	GenerateMove(rules *RuleSet) Move
 }
 
+// SeedableGenerator is a deterministic RandomGenerator. Each bot game is
+// played from its own seed, drawn with NextSeed and applied with Reseed.
+type SeedableGenerator interface {
+	RandomGenerator
+	NextSeed() uint64
+	Reseed(seed uint64)
+}
+
+// Strategy chooses the bot's next move from the rounds played so far in the
+// game. The bot is always player 2, so Move1 of each round is the opponent's.
+type Strategy interface {
+	NextMove(rules *RuleSet, history []RoundResult) Move
+}
+
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Medium
+	Hard
+	Expert
+)
+
 func (m Move) String() string {
 	switch m {
 	case Rock:
@@ -50,11 +131,32 @@ func (m Move) String() string {
 		return "Paper"
 	case Scissors:
 		return "Scissors"
+	case Lizard:
+		return "Lizard"
+	case Spock:
+		return "Spock"
 	default:
 		return "Unknown"
 	}
 }
 
+func (m Move) Shortcut() string {
+	switch m {
+	case Rock:
+		return "r"
+	case Paper:
+		return "p"
+	case Scissors:
+		return "s"
+	case Lizard:
+		return "l"
+	case Spock:
+		return "k"
+	default:
+		return ""
+	}
+}
+
 func (m GameType) String() string {
 	switch m {
 	case PlayerVsPlayer:
@@ -66,18 +168,50 @@ func (m GameType) String() string {
 	}
 }
 
-func DetermineWinner(move1, move2 Move) int {
-	if move1 == move2 {
-		return 0 // Draw
+// Code is the short name of the game type used on the command line, "pvp"
+// or "bot".
+func (m GameType) Code() string {
+	switch m {
+	case PlayerVsPlayer:
+		return "pvp"
+	case PlayerVsBot:
+		return "bot"
+	default:
+		return "unknown"
+	}
+}
+
+func ParseGameType(s string) (GameType, error) {
+	for _, m := range []GameType{PlayerVsPlayer, PlayerVsBot} {
+		if strings.EqualFold(s, m.Code()) {
+			return m, nil
+		}
 	}
+	return 0, fmt.Errorf("unknown game mode %q", s)
+}
 
-	if (move1 == Rock && move2 == Scissors) ||
-		(move1 == Paper && move2 == Rock) ||
-		(move1 == Scissors && move2 == Paper) {
-		return 1 // Player 1 wins
+func (d Difficulty) String() string {
+	switch d {
+	case Easy:
+		return "Easy"
+	case Medium:
+		return "Medium"
+	case Hard:
+		return "Hard"
+	case Expert:
+		return "Expert"
+	default:
+		return "Unknown"
 	}
+}
 
-	return 2 // Player 2 wins
+func ParseDifficulty(s string) (Difficulty, error) {
+	for d := Easy; d <= Expert; d++ {
+		if strings.EqualFold(s, d.String()) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown difficulty %q", s)
 }
 
 func ValidatePlayerName(name string) error {
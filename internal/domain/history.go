@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SortOrder is the order in which a history query returns the games.
+type SortOrder int
+
+const (
+	NewestFirst SortOrder = iota
+	OldestFirst
+)
+
+func (o SortOrder) Code() string {
+	if o == OldestFirst {
+		return "oldest"
+	}
+	return "newest"
+}
+
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch s {
+	case "newest":
+		return NewestFirst, nil
+	case "oldest":
+		return OldestFirst, nil
+	default:
+		return NewestFirst, fmt.Errorf("unknown sort order %q, use newest or oldest", s)
+	}
+}
+
+// HistoryQuery selects a page of the stored games. Empty filters match every
+// game, and a zero Limit returns every game after Offset.
+type HistoryQuery struct {
+	Player string    // played by this player, on either side
+	Winner string    // won by this player, or "Draw"
+	From   time.Time // played at or after
+	To     time.Time // played before
+	Mode   *GameType
+	Order  SortOrder
+	Limit  int
+	Offset int
+}
+
+// HistoryPage holds the games of a page and the number of games matching
+// the query across all pages.
+type HistoryPage struct {
+	Games []*Game
+	Total int
+}
+
+func (q HistoryQuery) Validate() error {
+	if q.Limit < 0 {
+		return errors.New("limit cannot be negative")
+	}
+	if q.Offset < 0 {
+		return errors.New("offset cannot be negative")
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return errors.New("the date range ends before it starts")
+	}
+	return nil
+}
+
+// Matches tells whether a game passes the filters of the query. Games whose
+// date cannot be read never match a date range.
+func (q HistoryQuery) Matches(game *Game) bool {
+	if q.Player != "" && game.Player1 != q.Player && game.Player2 != q.Player {
+		return false
+	}
+	if q.Winner != "" && game.Winner != q.Winner {
+		return false
+	}
+	if q.Mode != nil && game.Mode != *q.Mode {
+		return false
+	}
+
+	if !q.From.IsZero() || !q.To.IsZero() {
+		playedAt, err := time.Parse(time.RFC3339, game.PlayedAt)
+		if err != nil {
+			return false
+		}
+		if !q.From.IsZero() && playedAt.Before(q.From) {
+			return false
+		}
+		if !q.To.IsZero() && !playedAt.Before(q.To) {
+			return false
+		}
+	}
+	return true
+}
+
+// Page returns the page of the query out of all the games matching it,
+// already in the order of the query.
+func (q HistoryQuery) Page(games []*Game) *HistoryPage {
+	page := &HistoryPage{Games: []*Game{}, Total: len(games)}
+	if q.Offset >= len(games) {
+		return page
+	}
+	end := len(games)
+	if q.Limit > 0 && q.Offset+q.Limit < end {
+		end = q.Offset + q.Limit
+	}
+	page.Games = append(page.Games, games[q.Offset:end]...)
+	return page
+}
+
+// QueryGames runs a query over games held in memory, given in the order they
+// were stored, for the backends without a query engine.
+func QueryGames(games []*Game, q HistoryQuery) *HistoryPage {
+	type entry struct {
+		game     *Game
+		playedAt time.Time
+	}
+
+	var matching []entry
+	for _, game := range games {
+		if q.Matches(game) {
+			playedAt, _ := time.Parse(time.RFC3339, game.PlayedAt)
+			matching = append(matching, entry{game, playedAt})
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].playedAt.Before(matching[j].playedAt)
+	})
+
+	sorted := make([]*Game, len(matching))
+	for i, e := range matching {
+		if q.Order == NewestFirst {
+			sorted[len(matching)-1-i] = e.game
+		} else {
+			sorted[i] = e.game
+		}
+	}
+	return q.Page(sorted)
+}
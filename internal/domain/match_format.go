@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type MatchFormatType int
+
+const (
+	BestOf MatchFormatType = iota
+	FirstTo
+	FixedRounds
+	SuddenDeath
+)
+
+// MatchFormat decides when a game is over and who won it, based on the
+// rounds played so far.
+type MatchFormat struct {
+	Type   MatchFormatType
+	Rounds int
+}
+
+var DefaultMatchFormat = MatchFormat{Type: BestOf, Rounds: 3}
+
+var matchFormatCodes = map[MatchFormatType]string{
+	BestOf:      "bo",
+	FirstTo:     "ft",
+	FixedRounds: "fixed",
+	SuddenDeath: "sd",
+}
+
+func NewMatchFormat(formatType MatchFormatType, rounds int) (MatchFormat, error) {
+	format := MatchFormat{Type: formatType, Rounds: rounds}
+	if err := format.Validate(); err != nil {
+		return MatchFormat{}, err
+	}
+	return format, nil
+}
+
+// ParseMatchFormat parses the short form of a format, e.g. "bo5", "ft3",
+// "fixed4" or "sd3".
+func ParseMatchFormat(s string) (MatchFormat, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for formatType, code := range matchFormatCodes {
+		if !strings.HasPrefix(s, code) {
+			continue
+		}
+		rounds, err := strconv.Atoi(strings.TrimPrefix(s, code))
+		if err != nil {
+			continue
+		}
+		return NewMatchFormat(formatType, rounds)
+	}
+	return MatchFormat{}, fmt.Errorf("unknown match format %q", s)
+}
+
+func (f MatchFormat) Validate() error {
+	if _, ok := matchFormatCodes[f.Type]; !ok {
+		return fmt.Errorf("unknown match format type")
+	}
+	if f.Rounds < 1 {
+		return fmt.Errorf("number of rounds must be at least 1")
+	}
+	return nil
+}
+
+func (f MatchFormat) Code() string {
+	return fmt.Sprintf("%s%d", matchFormatCodes[f.Type], f.Rounds)
+}
+
+func (f MatchFormat) String() string {
+	switch f.Type {
+	case BestOf:
+		return fmt.Sprintf("Best of %d", f.Rounds)
+	case FirstTo:
+		return fmt.Sprintf("First to %d wins", f.Rounds)
+	case FixedRounds:
+		return fmt.Sprintf("%d rounds", f.Rounds)
+	case SuddenDeath:
+		return fmt.Sprintf("Best of %d with sudden death", f.Rounds)
+	default:
+		return "Unknown"
+	}
+}
+
+func (f MatchFormat) Description() string {
+	switch f.Type {
+	case BestOf:
+		return fmt.Sprintf("Best of %d rounds! Game ends early once a player wins %d rounds.", f.Rounds, f.winsNeeded())
+	case FirstTo:
+		return fmt.Sprintf("First to %d wins! Draws don't count.", f.Rounds)
+	case FixedRounds:
+		return fmt.Sprintf("%d rounds! The player with most round wins takes the game.", f.Rounds)
+	case SuddenDeath:
+		return fmt.Sprintf("Best of %d rounds! If tied, sudden death rounds are played until someone wins one.", f.Rounds)
+	default:
+		return ""
+	}
+}
+
+// MaxRounds returns the maximum number of rounds a game can last, or 0 when
+// the format has no upper bound.
+func (f MatchFormat) MaxRounds() int {
+	switch f.Type {
+	case BestOf, FixedRounds:
+		return f.Rounds
+	default:
+		return 0
+	}
+}
+
+// Result returns the winner of the game ("Draw" for a tie) and whether the
+// game is over after the given rounds.
+func (f MatchFormat) Result(rounds []RoundResult, player1, player2 string) (string, bool) {
+	p1Wins, p2Wins := countRoundWins(rounds, player1, player2)
+
+	switch f.Type {
+	case BestOf:
+		if p1Wins >= f.winsNeeded() || p2Wins >= f.winsNeeded() || len(rounds) >= f.Rounds {
+			return majority(p1Wins, p2Wins, player1, player2), true
+		}
+	case FirstTo:
+		if p1Wins >= f.Rounds {
+			return player1, true
+		}
+		if p2Wins >= f.Rounds {
+			return player2, true
+		}
+	case FixedRounds:
+		if len(rounds) >= f.Rounds {
+			return majority(p1Wins, p2Wins, player1, player2), true
+		}
+	case SuddenDeath:
+		if p1Wins >= f.winsNeeded() || p2Wins >= f.winsNeeded() ||
+			(len(rounds) >= f.Rounds && p1Wins != p2Wins) {
+			return majority(p1Wins, p2Wins, player1, player2), true
+		}
+	}
+
+	return "", false
+}
+
+func (f MatchFormat) winsNeeded() int {
+	return f.Rounds/2 + 1
+}
+
+func countRoundWins(rounds []RoundResult, player1, player2 string) (int, int) {
+	p1Wins := 0
+	p2Wins := 0
+	for _, r := range rounds {
+		if r.Winner == player1 {
+			p1Wins++
+		} else if r.Winner == player2 {
+			p2Wins++
+		}
+	}
+	return p1Wins, p2Wins
+}
+
+func majority(p1Wins, p2Wins int, player1, player2 string) string {
+	if p1Wins > p2Wins {
+		return player1
+	} else if p2Wins > p1Wins {
+		return player2
+	}
+	return "Draw"
+}
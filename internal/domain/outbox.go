@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+)
+
+// OutboxStatus tells how far a queued game got on its way to the backend.
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxConfirmed OutboxStatus = "confirmed"
+	OutboxFailed    OutboxStatus = "failed"
+)
+
+func ParseOutboxStatus(s string) (OutboxStatus, error) {
+	for _, status := range []OutboxStatus{OutboxPending, OutboxConfirmed, OutboxFailed} {
+		if s == string(status) {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("unknown status %q", s)
+}
+
+// OutboxEntry is a finished game queued locally until a backend reached over
+// the network, like the chain, stores it. TxHash is the transaction that
+// stored it, or that failed to, once mined.
+type OutboxEntry struct {
+	Game      *Game
+	Status    OutboxStatus
+	TxHash    string
+	Attempts  int
+	LastError string
+	QueuedAt  string
+	UpdatedAt string
+}
+
+// OutboxRepository lists the games queued for the backend, the most recently
+// queued first.
+type OutboxRepository interface {
+	GetOutboxEntries(ctx context.Context) ([]*OutboxEntry, error)
+}
@@ -0,0 +1,23 @@
+package domain
+
+type Rating struct {
+	Player     string
+	Rating     float64
+	Deviation  float64
+	Volatility float64
+	Games      int
+}
+
+// RatingEngine updates the skill rating of two players after a game between
+// them. Score is 1 if a won, 0.5 for a draw and 0 if b won.
+type RatingEngine interface {
+	Name() string
+	NewRating(player string) *Rating
+	Update(a, b *Rating, score float64) (*Rating, *Rating)
+}
+
+type RatingRepository interface {
+	GetRatings(system string) ([]*Rating, error)
+	GetRating(system, player string) (*Rating, error)
+	SaveRatings(system string, ratings []*Rating) error
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Rule struct {
+	Winner Move
+	Loser  Move
+	Verb   string
+}
+
+// RuleSet defines the moves available in a game and which move beats which.
+// Name is the stable identifier persisted with every game.
+type RuleSet struct {
+	Name        string
+	DisplayName string
+	Moves       []Move
+	Rules       []Rule
+}
+
+var RockPaperScissors = &RuleSet{
+	Name:        "rps",
+	DisplayName: "Rock Paper Scissors",
+	Moves:       []Move{Rock, Paper, Scissors},
+	Rules: []Rule{
+		{Winner: Rock, Loser: Scissors, Verb: "crushes"},
+		{Winner: Paper, Loser: Rock, Verb: "covers"},
+		{Winner: Scissors, Loser: Paper, Verb: "cuts"},
+	},
+}
+
+var RockPaperScissorsLizardSpock = &RuleSet{
+	Name:        "rpsls",
+	DisplayName: "Rock Paper Scissors Lizard Spock",
+	Moves:       []Move{Rock, Paper, Scissors, Lizard, Spock},
+	Rules: []Rule{
+		{Winner: Rock, Loser: Scissors, Verb: "crushes"},
+		{Winner: Rock, Loser: Lizard, Verb: "crushes"},
+		{Winner: Paper, Loser: Rock, Verb: "covers"},
+		{Winner: Paper, Loser: Spock, Verb: "disproves"},
+		{Winner: Scissors, Loser: Paper, Verb: "cuts"},
+		{Winner: Scissors, Loser: Lizard, Verb: "decapitates"},
+		{Winner: Lizard, Loser: Paper, Verb: "eats"},
+		{Winner: Lizard, Loser: Spock, Verb: "poisons"},
+		{Winner: Spock, Loser: Scissors, Verb: "smashes"},
+		{Winner: Spock, Loser: Rock, Verb: "vaporizes"},
+	},
+}
+
+var DefaultRuleSet = RockPaperScissors
+
+var ruleSets = []*RuleSet{RockPaperScissors, RockPaperScissorsLizardSpock}
+
+func RuleSets() []*RuleSet {
+	return ruleSets
+}
+
+// RuleSetByName returns the rule set with the given name. Games stored
+// before rule sets existed have no name and are Rock Paper Scissors.
+func RuleSetByName(name string) (*RuleSet, error) {
+	if name == "" {
+		return DefaultRuleSet, nil
+	}
+	for _, rules := range ruleSets {
+		if rules.Name == strings.ToLower(name) {
+			return rules, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown rule set %q", name)
+}
+
+func (r *RuleSet) IsValidMove(move Move) bool {
+	for _, m := range r.Moves {
+		if m == move {
+			return true
+		}
+	}
+	return false
+}
+
+// DetermineWinner returns 0 for a draw, 1 if move1 wins and 2 if move2 wins.
+func (r *RuleSet) DetermineWinner(move1, move2 Move) int {
+	if move1 == move2 {
+		return 0 // Draw
+	}
+	if _, ok := r.rule(move1, move2); ok {
+		return 1 // Player 1 wins
+	}
+	return 2 // Player 2 wins
+}
+
+// Counters returns the moves that beat the given move.
+func (r *RuleSet) Counters(move Move) []Move {
+	var counters []Move
+	for _, rule := range r.Rules {
+		if rule.Loser == move {
+			counters = append(counters, rule.Winner)
+		}
+	}
+	return counters
+}
+
+// Describe explains the outcome of a round, e.g. "Paper covers Rock".
+func (r *RuleSet) Describe(move1, move2 Move) string {
+	if rule, ok := r.rule(move1, move2); ok {
+		return fmt.Sprintf("%s %s %s", rule.Winner, rule.Verb, rule.Loser)
+	}
+	if rule, ok := r.rule(move2, move1); ok {
+		return fmt.Sprintf("%s %s %s", rule.Winner, rule.Verb, rule.Loser)
+	}
+	return fmt.Sprintf("%s ties %s", move1, move2)
+}
+
+// ParseMove accepts either the full name of a move or its shortcut.
+func (r *RuleSet) ParseMove(input string) (Move, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	for _, m := range r.Moves {
+		if input == strings.ToLower(m.String()) || input == m.Shortcut() {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid move %q for %s", input, r.DisplayName)
+}
+
+func (r *RuleSet) rule(winner, loser Move) (Rule, bool) {
+	for _, rule := range r.Rules {
+		if rule.Winner == winner && rule.Loser == loser {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+type PlayerStats struct {
+	Player        string
+	Games         int
+	Wins          int
+	Losses        int
+	Draws         int
+	LongestStreak int
+	FavoriteMove  string
+}
+
+type HeadToHead struct {
+	Player   string
+	Opponent string
+	Wins     int
+	Losses   int
+	Draws    int
+}
+
+type StatsRepository interface {
+	GetLeaderboard(ctx context.Context, limit int) ([]*PlayerStats, error)
+	GetPlayerStats(ctx context.Context, player string) (*PlayerStats, error)
+	GetHeadToHead(ctx context.Context, player string) ([]*HeadToHead, error)
+}
+
+func (s *PlayerStats) WinRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Games)
+}
+
+// ComputeLeaderboard aggregates the stats of every player from a list of
+// games, for repositories that cannot aggregate natively. Players are ranked
+// by wins, then win rate, then name. A limit of 0 returns every player.
+func ComputeLeaderboard(games []*Game, limit int) []*PlayerStats {
+	statsByPlayer := make(map[string]*PlayerStats)
+	moveCounts := make(map[string]map[Move]int)
+	currentStreaks := make(map[string]int)
+
+	for _, game := range sortedByPlayedAt(games) {
+		players := []string{game.Player1}
+		if game.Player2 != game.Player1 {
+			players = append(players, game.Player2)
+		}
+
+		for _, player := range players {
+			stats, ok := statsByPlayer[player]
+			if !ok {
+				stats = &PlayerStats{Player: player}
+				statsByPlayer[player] = stats
+				moveCounts[player] = make(map[Move]int)
+			}
+
+			stats.Games++
+			switch game.Winner {
+			case player:
+				stats.Wins++
+				currentStreaks[player]++
+				if currentStreaks[player] > stats.LongestStreak {
+					stats.LongestStreak = currentStreaks[player]
+				}
+			case "Draw":
+				stats.Draws++
+				currentStreaks[player] = 0
+			default:
+				stats.Losses++
+				currentStreaks[player] = 0
+			}
+		}
+
+		for _, round := range game.Rounds {
+			moveCounts[game.Player1][round.Move1]++
+			moveCounts[game.Player2][round.Move2]++
+		}
+	}
+
+	leaderboard := make([]*PlayerStats, 0, len(statsByPlayer))
+	for player, stats := range statsByPlayer {
+		stats.FavoriteMove = favoriteMove(moveCounts[player])
+		leaderboard = append(leaderboard, stats)
+	}
+
+	sort.Slice(leaderboard, func(i, j int) bool {
+		a, b := leaderboard[i], leaderboard[j]
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		if a.WinRate() != b.WinRate() {
+			return a.WinRate() > b.WinRate()
+		}
+		return a.Player < b.Player
+	})
+
+	if limit > 0 && len(leaderboard) > limit {
+		leaderboard = leaderboard[:limit]
+	}
+	return leaderboard
+}
+
+// ComputePlayerStats returns the stats of a single player. A player who never
+// played gets empty stats.
+func ComputePlayerStats(games []*Game, player string) *PlayerStats {
+	var playerGames []*Game
+	for _, game := range games {
+		if game.Player1 == player || game.Player2 == player {
+			playerGames = append(playerGames, game)
+		}
+	}
+
+	for _, stats := range ComputeLeaderboard(playerGames, 0) {
+		if stats.Player == player {
+			return stats
+		}
+	}
+	return &PlayerStats{Player: player}
+}
+
+// ComputeHeadToHead returns the record of a player against each opponent,
+// ordered by number of games played together.
+func ComputeHeadToHead(games []*Game, player string) []*HeadToHead {
+	recordsByOpponent := make(map[string]*HeadToHead)
+
+	for _, game := range games {
+		var opponent string
+		switch player {
+		case game.Player1:
+			opponent = game.Player2
+		case game.Player2:
+			opponent = game.Player1
+		default:
+			continue
+		}
+
+		record, ok := recordsByOpponent[opponent]
+		if !ok {
+			record = &HeadToHead{Player: player, Opponent: opponent}
+			recordsByOpponent[opponent] = record
+		}
+
+		switch game.Winner {
+		case "Draw":
+			record.Draws++
+		case player:
+			record.Wins++
+		default:
+			record.Losses++
+		}
+	}
+
+	records := make([]*HeadToHead, 0, len(recordsByOpponent))
+	for _, record := range recordsByOpponent {
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		aGames, bGames := a.Wins+a.Losses+a.Draws, b.Wins+b.Losses+b.Draws
+		if aGames != bGames {
+			return aGames > bGames
+		}
+		return a.Opponent < b.Opponent
+	})
+
+	return records
+}
+
+func favoriteMove(counts map[Move]int) string {
+	favorite := Move(-1)
+	for move, count := range counts {
+		if favorite < 0 || count > counts[favorite] || (count == counts[favorite] && move < favorite) {
+			favorite = move
+		}
+	}
+	if favorite < 0 {
+		return ""
+	}
+	return favorite.String()
+}
+
+func sortedByPlayedAt(games []*Game) []*Game {
+	sorted := make([]*Game, len(games))
+	copy(sorted, games)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, errA := time.Parse(time.RFC3339Nano, sorted[i].PlayedAt)
+		b, errB := time.Parse(time.RFC3339Nano, sorted[j].PlayedAt)
+		if errA != nil || errB != nil {
+			return sorted[i].PlayedAt < sorted[j].PlayedAt
+		}
+		return a.Before(b)
+	})
+
+	return sorted
+}
@@ -0,0 +1,608 @@
+package domain
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+type TournamentFormat int
+
+const (
+	SingleElimination TournamentFormat = iota
+	DoubleElimination
+	RoundRobin
+	Swiss
+)
+
+var tournamentFormatCodes = map[TournamentFormat]string{
+	SingleElimination: "single",
+	DoubleElimination: "double",
+	RoundRobin:        "roundrobin",
+	Swiss:             "swiss",
+}
+
+func (f TournamentFormat) Code() string {
+	return tournamentFormatCodes[f]
+}
+
+func (f TournamentFormat) String() string {
+	switch f {
+	case SingleElimination:
+		return "Single elimination"
+	case DoubleElimination:
+		return "Double elimination"
+	case RoundRobin:
+		return "Round robin"
+	case Swiss:
+		return "Swiss"
+	default:
+		return "Unknown"
+	}
+}
+
+func ParseTournamentFormat(s string) (TournamentFormat, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for format, code := range tournamentFormatCodes {
+		if s == code {
+			return format, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tournament format %q", s)
+}
+
+// IsElimination reports whether players are knocked out, in which case
+// matches must have a winner.
+func (f TournamentFormat) IsElimination() bool {
+	return f == SingleElimination || f == DoubleElimination
+}
+
+// Brackets of the matches of a double elimination tournament.
+const (
+	WinnersBracket = "winners"
+	LosersBracket  = "losers"
+	GrandFinal     = "final"
+)
+
+// TournamentMatch is a game between two players of a tournament. A match
+// without Player2 is a bye, won by Player1 without playing.
+type TournamentMatch struct {
+	Round   int
+	Number  int
+	Bracket string // double elimination only
+	Player1 string
+	Player2 string
+	Winner  string // "Draw" in round robin and Swiss, empty until played
+	GameID  string
+}
+
+func (m *TournamentMatch) IsBye() bool {
+	return m.Player2 == ""
+}
+
+func (m *TournamentMatch) Played() bool {
+	return m.Winner != ""
+}
+
+// Loser returns the player who lost the match, if any.
+func (m *TournamentMatch) Loser() string {
+	switch m.Winner {
+	case m.Player1:
+		return m.Player2
+	case m.Player2:
+		return m.Player1
+	default:
+		return ""
+	}
+}
+
+// Tournament holds its players in seed order and every match generated so
+// far. Rounds are paired one at a time, once every match of the previous
+// round is played, so a tournament can be stored and resumed at any point.
+type Tournament struct {
+	ID          string
+	Name        string
+	Format      TournamentFormat
+	MatchFormat MatchFormat
+	RuleSet     string
+	Players     []string
+	SwissRounds int
+	Matches     []*TournamentMatch
+	Winner      string
+	CreatedAt   string
+}
+
+type TournamentRepository interface {
+	SaveTournament(tournament *Tournament) error
+	GetTournament(id string) (*Tournament, error)
+	ListTournaments() ([]*Tournament, error)
+}
+
+// TournamentStanding is the record of a player in a tournament. Wins and byes
+// are worth a point, draws half a point.
+type TournamentStanding struct {
+	Player     string
+	Seed       int
+	Played     int
+	Wins       int
+	Draws      int
+	Losses     int
+	Points     float64
+	Buchholz   float64 // sum of the points of the opponents, Swiss only
+	Eliminated bool    // elimination formats only
+	lastRound  int
+}
+
+// NewTournament seeds the players in the given order and pairs the first
+// round. Swiss tournaments without a number of rounds play enough rounds to
+// single out a winner.
+func NewTournament(id, name string, format TournamentFormat, matchFormat MatchFormat, rules *RuleSet, players []string, swissRounds int, createdAt string) (*Tournament, error) {
+	if _, ok := tournamentFormatCodes[format]; !ok {
+		return nil, fmt.Errorf("unknown tournament format")
+	}
+	if err := matchFormat.Validate(); err != nil {
+		return nil, err
+	}
+	if len(players) < 2 {
+		return nil, fmt.Errorf("a tournament needs at least 2 players")
+	}
+
+	seen := make(map[string]bool, len(players))
+	for _, player := range players {
+		if err := ValidatePlayerName(player); err != nil {
+			return nil, fmt.Errorf("invalid player name %q: %w", player, err)
+		}
+		if seen[strings.ToLower(player)] {
+			return nil, fmt.Errorf("player %s is entered twice", player)
+		}
+		seen[strings.ToLower(player)] = true
+	}
+
+	if format == Swiss {
+		if swissRounds == 0 {
+			swissRounds = DefaultSwissRounds(len(players))
+		}
+		if swissRounds < 1 || swissRounds >= len(players)+len(players)%2 {
+			return nil, fmt.Errorf("a Swiss tournament of %d players needs between 1 and %d rounds", len(players), len(players)+len(players)%2-1)
+		}
+	} else {
+		swissRounds = 0
+	}
+
+	t := &Tournament{
+		ID:          id,
+		Name:        name,
+		Format:      format,
+		MatchFormat: matchFormat,
+		RuleSet:     rules.Name,
+		Players:     append([]string(nil), players...),
+		SwissRounds: swissRounds,
+		CreatedAt:   createdAt,
+	}
+	t.advance()
+	return t, nil
+}
+
+// DefaultSwissRounds returns the number of Swiss rounds needed to single out
+// a winner among the given number of players.
+func DefaultSwissRounds(players int) int {
+	return bits.Len(uint(players - 1))
+}
+
+func (t *Tournament) Finished() bool {
+	return t.Winner != ""
+}
+
+// CurrentRound returns the last round paired so far.
+func (t *Tournament) CurrentRound() int {
+	round := 0
+	for _, m := range t.Matches {
+		round = max(round, m.Round)
+	}
+	return round
+}
+
+// Round returns the matches of a round, in order.
+func (t *Tournament) Round(round int) []*TournamentMatch {
+	var matches []*TournamentMatch
+	for _, m := range t.Matches {
+		if m.Round == round {
+			matches = append(matches, m)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Number < matches[j].Number })
+	return matches
+}
+
+// Pending returns the matches of the current round still to be played.
+func (t *Tournament) Pending() []*TournamentMatch {
+	var pending []*TournamentMatch
+	for _, m := range t.Round(t.CurrentRound()) {
+		if !m.Played() {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// Record records the result of a game played for a match, and pairs the
+// next round once the current one is complete. Drawn games do not settle
+// elimination matches, which are then played again.
+func (t *Tournament) Record(m *TournamentMatch, winner, gameID string) error {
+	if m.Played() {
+		return fmt.Errorf("match %s vs %s was already played", m.Player1, m.Player2)
+	}
+	if winner != m.Player1 && winner != m.Player2 && winner != "Draw" {
+		return fmt.Errorf("%s did not play in match %s vs %s", winner, m.Player1, m.Player2)
+	}
+
+	m.GameID = gameID
+	if winner == "Draw" && t.Format.IsElimination() {
+		return nil
+	}
+	m.Winner = winner
+	t.advance()
+	return nil
+}
+
+// advance pairs new rounds while the current one is complete, and sets the
+// winner once no round is left.
+func (t *Tournament) advance() {
+	for !t.Finished() && len(t.Pending()) == 0 {
+		round := t.nextRound()
+		if len(round) == 0 {
+			t.Winner = t.Standings()[0].Player
+			return
+		}
+
+		number := t.CurrentRound() + 1
+		for i, m := range round {
+			m.Round = number
+			m.Number = i + 1
+			if m.IsBye() {
+				m.Winner = m.Player1
+			}
+			t.Matches = append(t.Matches, m)
+		}
+	}
+}
+
+func (t *Tournament) nextRound() []*TournamentMatch {
+	switch t.Format {
+	case SingleElimination:
+		return t.nextEliminationRound("")
+	case DoubleElimination:
+		return t.nextDoubleEliminationRound()
+	case RoundRobin:
+		return t.nextRoundRobinRound()
+	case Swiss:
+		return t.nextSwissRound()
+	default:
+		return nil
+	}
+}
+
+// BracketSize returns the number of slots of the first round of an
+// elimination bracket, filled with byes past the number of players.
+func (t *Tournament) BracketSize() int {
+	return 1 << bits.Len(uint(len(t.Players)-1))
+}
+
+// nextEliminationRound pairs the winners of the previous round of bracket in
+// order, or seeds the players in the first round so that the top seeds get
+// the byes and only meet late.
+func (t *Tournament) nextEliminationRound(bracket string) []*TournamentMatch {
+	if t.CurrentRound() == 0 {
+		slots := seedSlots(t.BracketSize())
+		round := make([]*TournamentMatch, 0, len(slots)/2)
+		for i := 0; i < len(slots); i += 2 {
+			m := &TournamentMatch{Bracket: bracket, Player1: t.Players[slots[i]]}
+			if slots[i+1] < len(t.Players) {
+				m.Player2 = t.Players[slots[i+1]]
+			}
+			round = append(round, m)
+		}
+		return round
+	}
+
+	var previous []*TournamentMatch
+	for _, m := range t.Round(t.CurrentRound()) {
+		if m.Bracket == bracket {
+			previous = append(previous, m)
+		}
+	}
+	if len(previous) < 2 {
+		return nil
+	}
+
+	round := make([]*TournamentMatch, 0, len(previous)/2)
+	for i := 0; i+1 < len(previous); i += 2 {
+		round = append(round, &TournamentMatch{
+			Bracket: bracket,
+			Player1: previous[i].Winner,
+			Player2: previous[i+1].Winner,
+		})
+	}
+	return round
+}
+
+// seedSlots returns the seed of each slot of a bracket, e.g. 1 8 4 5 2 7 3 6
+// for 8 slots, 0-based.
+func seedSlots(size int) []int {
+	slots := []int{0}
+	for len(slots) < size {
+		next := make([]int, 0, len(slots)*2)
+		for _, seed := range slots {
+			next = append(next, seed, len(slots)*2-1-seed)
+		}
+		slots = next
+	}
+	return slots
+}
+
+// nextDoubleEliminationRound plays a round of the winners bracket alongside
+// a round of the losers bracket, where players go after their first loss.
+// The winners of both brackets meet in the grand final, played again if the
+// winner of the losers bracket wins it.
+func (t *Tournament) nextDoubleEliminationRound() []*TournamentMatch {
+	if t.CurrentRound() == 0 {
+		return t.nextEliminationRound(WinnersBracket)
+	}
+
+	losses := t.losses()
+	var undefeated, oneLoss []string
+	for _, player := range t.Players {
+		switch losses[player] {
+		case 0:
+			undefeated = append(undefeated, player)
+		case 1:
+			oneLoss = append(oneLoss, player)
+		}
+	}
+
+	switch {
+	case len(undefeated)+len(oneLoss) == 1:
+		return nil
+	case len(undefeated) == 0 && len(oneLoss) == 2:
+		// The winner of the losers bracket won the grand final
+		return []*TournamentMatch{{Bracket: GrandFinal, Player1: oneLoss[0], Player2: oneLoss[1]}}
+	case len(undefeated) == 1 && len(oneLoss) == 1:
+		return []*TournamentMatch{{Bracket: GrandFinal, Player1: undefeated[0], Player2: oneLoss[0]}}
+	}
+
+	round := t.nextEliminationRound(WinnersBracket)
+
+	// Players waiting for an opponent go first, then the winners of the
+	// losers bracket and the players who just lost in the winners bracket.
+	previous := t.Round(t.CurrentRound())
+	inPrevious := make(map[string]bool)
+	for _, m := range previous {
+		inPrevious[m.Player1] = true
+		inPrevious[m.Player2] = true
+	}
+	var losers []string
+	for _, player := range oneLoss {
+		if !inPrevious[player] {
+			losers = append(losers, player)
+		}
+	}
+	for _, m := range previous {
+		if m.Bracket == LosersBracket {
+			losers = append(losers, m.Winner)
+		}
+	}
+	for _, m := range previous {
+		if m.Bracket == WinnersBracket && !m.IsBye() {
+			losers = append(losers, m.Loser())
+		}
+	}
+
+	for i := 0; i+1 < len(losers); i += 2 {
+		round = append(round, &TournamentMatch{Bracket: LosersBracket, Player1: losers[i], Player2: losers[i+1]})
+	}
+	return round
+}
+
+func (t *Tournament) losses() map[string]int {
+	losses := make(map[string]int)
+	for _, m := range t.Matches {
+		if loser := m.Loser(); loser != "" {
+			losses[loser]++
+		}
+	}
+	return losses
+}
+
+// nextRoundRobinRound pairs the players with the circle method, so that
+// everyone meets everyone once. With an odd number of players one of them
+// sits out each round.
+func (t *Tournament) nextRoundRobinRound() []*TournamentMatch {
+	players := append([]string(nil), t.Players...)
+	if len(players)%2 == 1 {
+		players = append(players, "")
+	}
+
+	number := t.CurrentRound()
+	if number == len(players)-1 {
+		return nil
+	}
+
+	// Rotate every player but the first one place per round
+	rotated := append([]string{players[0]}, players[1:]...)
+	for i := 0; i < number; i++ {
+		last := rotated[len(rotated)-1]
+		copy(rotated[2:], rotated[1:len(rotated)-1])
+		rotated[1] = last
+	}
+
+	var round []*TournamentMatch
+	for i := 0; i < len(rotated)/2; i++ {
+		player1, player2 := rotated[i], rotated[len(rotated)-1-i]
+		if player1 == "" || player2 == "" {
+			continue
+		}
+		round = append(round, &TournamentMatch{Player1: player1, Player2: player2})
+	}
+	return round
+}
+
+// nextSwissRound pairs players with the same score where possible, without
+// rematches. With an odd number of players the lowest ranked player who has
+// not had a bye gets one.
+func (t *Tournament) nextSwissRound() []*TournamentMatch {
+	if t.CurrentRound() == t.SwissRounds {
+		return nil
+	}
+
+	standings := t.Standings()
+	players := make([]string, len(standings))
+	for i, standing := range standings {
+		players[i] = standing.Player
+	}
+
+	var round []*TournamentMatch
+	if len(players)%2 == 1 {
+		byes := make(map[string]bool)
+		for _, m := range t.Matches {
+			if m.IsBye() {
+				byes[m.Player1] = true
+			}
+		}
+		bye := len(players) - 1
+		for i := len(players) - 1; i >= 0; i-- {
+			if !byes[players[i]] {
+				bye = i
+				break
+			}
+		}
+		round = append(round, &TournamentMatch{Player1: players[bye]})
+		players = append(players[:bye:bye], players[bye+1:]...)
+	}
+
+	played := make(map[[2]string]bool)
+	for _, m := range t.Matches {
+		played[[2]string{m.Player1, m.Player2}] = true
+		played[[2]string{m.Player2, m.Player1}] = true
+	}
+
+	pairs, ok := pairWithoutRematches(players, played)
+	if !ok {
+		// Everyone left has met, so rematches cannot be avoided
+		pairs = nil
+		for i := 0; i+1 < len(players); i += 2 {
+			pairs = append(pairs, [2]string{players[i], players[i+1]})
+		}
+	}
+	for _, pair := range pairs {
+		round = append(round, &TournamentMatch{Player1: pair[0], Player2: pair[1]})
+	}
+
+	// The bye goes last
+	if len(round) > 0 && round[0].IsBye() {
+		round = append(round[1:], round[0])
+	}
+	return round
+}
+
+// pairWithoutRematches pairs each player in order with the highest ranked
+// player left they have not played, backtracking when the rest cannot be
+// paired.
+func pairWithoutRematches(players []string, played map[[2]string]bool) ([][2]string, bool) {
+	if len(players) == 0 {
+		return nil, true
+	}
+
+	first := players[0]
+	for i := 1; i < len(players); i++ {
+		if played[[2]string{first, players[i]}] {
+			continue
+		}
+
+		rest := make([]string, 0, len(players)-2)
+		rest = append(rest, players[1:i]...)
+		rest = append(rest, players[i+1:]...)
+		if pairs, ok := pairWithoutRematches(rest, played); ok {
+			return append([][2]string{{first, players[i]}}, pairs...), true
+		}
+	}
+	return nil, false
+}
+
+// Standings ranks the players. Elimination formats rank the players still
+// in first, then by how far they went. Round robin ranks by points, Swiss by
+// points then Buchholz. Remaining ties go to the better seed.
+func (t *Tournament) Standings() []*TournamentStanding {
+	byPlayer := make(map[string]*TournamentStanding, len(t.Players))
+	standings := make([]*TournamentStanding, len(t.Players))
+	for i, player := range t.Players {
+		standings[i] = &TournamentStanding{Player: player, Seed: i + 1}
+		byPlayer[player] = standings[i]
+	}
+
+	for _, m := range t.Matches {
+		if !m.Played() {
+			continue
+		}
+		if m.IsBye() {
+			byPlayer[m.Player1].Points++
+			continue
+		}
+
+		for _, player := range []string{m.Player1, m.Player2} {
+			standing := byPlayer[player]
+			standing.Played++
+			standing.lastRound = m.Round
+			switch m.Winner {
+			case player:
+				standing.Wins++
+				standing.Points++
+			case "Draw":
+				standing.Draws++
+				standing.Points += 0.5
+			default:
+				standing.Losses++
+			}
+		}
+	}
+
+	for _, m := range t.Matches {
+		if m.Played() && !m.IsBye() {
+			byPlayer[m.Player1].Buchholz += byPlayer[m.Player2].Points
+			byPlayer[m.Player2].Buchholz += byPlayer[m.Player1].Points
+		}
+	}
+
+	maxLosses := map[TournamentFormat]int{SingleElimination: 1, DoubleElimination: 2}[t.Format]
+	for _, standing := range standings {
+		standing.Eliminated = maxLosses > 0 && standing.Losses >= maxLosses
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if t.Format.IsElimination() {
+			if a.Eliminated != b.Eliminated {
+				return !a.Eliminated
+			}
+			if a.lastRound != b.lastRound {
+				return a.lastRound > b.lastRound
+			}
+			if a.Losses != b.Losses {
+				return a.Losses < b.Losses
+			}
+			return a.Seed < b.Seed
+		}
+
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if t.Format == Swiss && a.Buchholz != b.Buchholz {
+			return a.Buchholz > b.Buchholz
+		}
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		return a.Seed < b.Seed
+	})
+	return standings
+}
@@ -16,7 +16,7 @@ func NewMockRandomGenerator(moves []domain.Move) *MockRandomGenerator {
 	}
 }
 
-func (m *MockRandomGenerator) GenerateMove() domain.Move {
+func (m *MockRandomGenerator) GenerateMove(rules *domain.RuleSet) domain.Move {
 	if m.Index >= len(m.Moves) {
 		m.Index = 0
 	}
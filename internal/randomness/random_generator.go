@@ -1,23 +1,74 @@
 package randomness
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"math/big"
+	mathrand "math/rand/v2"
+	"sync"
 
 	"protofire-game/internal/domain"
 )
 
-type DefaultRandomGenerator struct {
-	rand *rand.Rand
+// SeededRandomGenerator is a deterministic generator for reproducible games.
+// The startup seed drives a sequence of per-game seeds, and every game is
+// played with its own PCG stream so it can be replayed on its own.
+type SeededRandomGenerator struct {
+	mu    sync.Mutex
+	seeds *mathrand.Rand
+	rand  *mathrand.Rand
 }
 
-func NewDefaultRandomGenerator() *DefaultRandomGenerator {
-	source := rand.NewSource(time.Now().UnixNano())
-	return &DefaultRandomGenerator{
-		rand: rand.New(source),
+func NewSeededRandomGenerator(seed uint64) *SeededRandomGenerator {
+	return &SeededRandomGenerator{
+		seeds: mathrand.New(mathrand.NewPCG(seed, 0)),
+		rand:  newGameRand(seed),
 	}
 }
 
-func (g *DefaultRandomGenerator) GenerateMove() domain.Move {
-	return domain.Move(g.rand.Intn(3)) // 0 = Rock, 1 = Paper, 2 = Scissors
+func newGameRand(seed uint64) *mathrand.Rand {
+	return mathrand.New(mathrand.NewPCG(seed, seed))
+}
+
+func (g *SeededRandomGenerator) NextSeed() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.seeds.Uint64()
+}
+
+func (g *SeededRandomGenerator) Reseed(seed uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rand = newGameRand(seed)
+}
+
+// Split returns an independent generator seeded from the sequence of this
+// one, for games played concurrently.
+func (g *SeededRandomGenerator) Split() *SeededRandomGenerator {
+	return NewSeededRandomGenerator(g.NextSeed())
+}
+
+func (g *SeededRandomGenerator) GenerateMove(rules *domain.RuleSet) domain.Move {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return rules.Moves[g.rand.IntN(len(rules.Moves))]
+}
+
+// CryptoRandomGenerator draws every move from crypto/rand, so bot moves
+// cannot be predicted from previous ones.
+type CryptoRandomGenerator struct{}
+
+func NewCryptoRandomGenerator() *CryptoRandomGenerator {
+	return &CryptoRandomGenerator{}
+}
+
+func (g *CryptoRandomGenerator) GenerateMove(rules *domain.RuleSet) domain.Move {
+	// crypto/rand never returns an error since Go 1.24
+	n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(rules.Moves))))
+	return rules.Moves[n.Int64()]
+}
+
+// NewRandomSeed returns a seed for a SeededRandomGenerator when none is given.
+func NewRandomSeed() uint64 {
+	n, _ := rand.Int(rand.Reader, new(big.Int).SetUint64(^uint64(0)))
+	return n.Uint64()
 }
@@ -0,0 +1,35 @@
+package randomness
+
+import (
+	"fmt"
+
+	"protofire-game/internal/domain"
+)
+
+// ReplayBotMoves replays a stored game against the bot from its seed and
+// returns the moves the bot plays, given the same moves from its opponent.
+func ReplayBotMoves(game *domain.Game) ([]domain.Move, error) {
+	if game.Mode != domain.PlayerVsBot {
+		return nil, fmt.Errorf("only games against the bot can be replayed")
+	}
+	if game.Seed == nil {
+		return nil, fmt.Errorf("game was not played with a seeded generator")
+	}
+
+	rules, err := domain.RuleSetByName(game.RuleSet)
+	if err != nil {
+		return nil, err
+	}
+
+	randGen := NewSeededRandomGenerator(*game.Seed)
+	strategy, ok := NewStrategies(randGen)[game.Difficulty]
+	if !ok {
+		return nil, fmt.Errorf("difficulty %s is not available", game.Difficulty)
+	}
+
+	moves := make([]domain.Move, len(game.Rounds))
+	for i := range game.Rounds {
+		moves[i] = strategy.NextMove(rules, game.Rounds[:i])
+	}
+	return moves, nil
+}
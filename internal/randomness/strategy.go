@@ -0,0 +1,215 @@
+package randomness
+
+import (
+	"fmt"
+
+	"protofire-game/internal/domain"
+)
+
+// NewStrategies returns the bot strategy used for each difficulty. Every
+// strategy falls back to randGen when it has nothing to learn from yet.
+func NewStrategies(randGen domain.RandomGenerator) map[domain.Difficulty]domain.Strategy {
+	frequency := NewFrequencyStrategy(randGen)
+
+	return map[domain.Difficulty]domain.Strategy{
+		domain.Easy:   NewRandomStrategy(randGen),
+		domain.Medium: frequency,
+		domain.Hard:   NewMarkovStrategy(2, frequency),
+		domain.Expert: NewEnsembleStrategy(randGen,
+			frequency,
+			NewMarkovStrategy(1, frequency),
+			NewMarkovStrategy(2, frequency),
+			NewMarkovStrategy(3, frequency),
+			NewWinStayLoseShiftStrategy(randGen),
+		),
+	}
+}
+
+// RandomStrategy ignores the history and plays a random move.
+type RandomStrategy struct {
+	randGen domain.RandomGenerator
+}
+
+func NewRandomStrategy(randGen domain.RandomGenerator) *RandomStrategy {
+	return &RandomStrategy{randGen: randGen}
+}
+
+func (s *RandomStrategy) NextMove(rules *domain.RuleSet, history []domain.RoundResult) domain.Move {
+	return s.randGen.GenerateMove(rules)
+}
+
+// FrequencyStrategy expects the opponent to play their most frequent move.
+type FrequencyStrategy struct {
+	fallback domain.RandomGenerator
+}
+
+func NewFrequencyStrategy(fallback domain.RandomGenerator) *FrequencyStrategy {
+	return &FrequencyStrategy{fallback: fallback}
+}
+
+func (s *FrequencyStrategy) NextMove(rules *domain.RuleSet, history []domain.RoundResult) domain.Move {
+	if len(history) == 0 {
+		return s.fallback.GenerateMove(rules)
+	}
+
+	counts := make(map[domain.Move]int)
+	for _, round := range history {
+		counts[round.Move1]++
+	}
+
+	return counter(rules, mostLikely(counts, history))
+}
+
+// MarkovStrategy expects the opponent to repeat what they played after the
+// same sequence of their last order moves.
+type MarkovStrategy struct {
+	order    int
+	fallback domain.Strategy
+}
+
+func NewMarkovStrategy(order int, fallback domain.Strategy) *MarkovStrategy {
+	if order < 1 {
+		order = 1
+	}
+	return &MarkovStrategy{order: order, fallback: fallback}
+}
+
+func (s *MarkovStrategy) NextMove(rules *domain.RuleSet, history []domain.RoundResult) domain.Move {
+	if len(history) <= s.order {
+		return s.fallback.NextMove(rules, history)
+	}
+
+	context := opponentMoves(history[len(history)-s.order:])
+	counts := make(map[domain.Move]int)
+	for i := s.order; i < len(history); i++ {
+		if opponentMoves(history[i-s.order:i]) == context {
+			counts[history[i].Move1]++
+		}
+	}
+
+	if len(counts) == 0 {
+		return s.fallback.NextMove(rules, history)
+	}
+	return counter(rules, mostLikely(counts, history))
+}
+
+// WinStayLoseShiftStrategy exploits the common human habit of repeating a
+// move that just won and switching after a loss to the move that would have
+// beaten the one they lost to.
+type WinStayLoseShiftStrategy struct {
+	fallback domain.RandomGenerator
+}
+
+func NewWinStayLoseShiftStrategy(fallback domain.RandomGenerator) *WinStayLoseShiftStrategy {
+	return &WinStayLoseShiftStrategy{fallback: fallback}
+}
+
+func (s *WinStayLoseShiftStrategy) NextMove(rules *domain.RuleSet, history []domain.RoundResult) domain.Move {
+	if len(history) == 0 {
+		return s.fallback.GenerateMove(rules)
+	}
+
+	last := history[len(history)-1]
+	switch rules.DetermineWinner(last.Move1, last.Move2) {
+	case 1: // The opponent won and stays
+		return counter(rules, last.Move1)
+	case 2: // The opponent lost and shifts
+		return counter(rules, counter(rules, last.Move2))
+	default:
+		return s.fallback.GenerateMove(rules)
+	}
+}
+
+// EnsembleStrategy is an Iocaine Powder style meta-strategy. Every strategy
+// is played as is and second-guessed up to twice, assuming the opponent
+// anticipates it. Each variant is scored on how it would have done in the
+// rounds played so far, with recent rounds weighing more, and the best
+// scoring one is played.
+type EnsembleStrategy struct {
+	strategies []domain.Strategy
+	fallback   domain.RandomGenerator
+	decay      float64
+}
+
+const (
+	ensembleDecay     = 0.85
+	ensembleRotations = 3
+)
+
+func NewEnsembleStrategy(fallback domain.RandomGenerator, strategies ...domain.Strategy) *EnsembleStrategy {
+	return &EnsembleStrategy{
+		strategies: strategies,
+		fallback:   fallback,
+		decay:      ensembleDecay,
+	}
+}
+
+func (s *EnsembleStrategy) NextMove(rules *domain.RuleSet, history []domain.RoundResult) domain.Move {
+	if len(history) == 0 || len(s.strategies) == 0 {
+		return s.fallback.GenerateMove(rules)
+	}
+
+	scores := make([]float64, len(s.strategies)*ensembleRotations)
+	for t := range history {
+		for i, move := range s.candidates(rules, history[:t]) {
+			scores[i] *= s.decay
+			switch rules.DetermineWinner(move, history[t].Move1) {
+			case 1:
+				scores[i]++
+			case 2:
+				scores[i]--
+			}
+		}
+	}
+
+	candidates := s.candidates(rules, history)
+	best := 0
+	for i := range candidates {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	return candidates[best]
+}
+
+func (s *EnsembleStrategy) candidates(rules *domain.RuleSet, history []domain.RoundResult) []domain.Move {
+	candidates := make([]domain.Move, 0, len(s.strategies)*ensembleRotations)
+	for _, strategy := range s.strategies {
+		move := strategy.NextMove(rules, history)
+		for i := 0; i < ensembleRotations; i++ {
+			candidates = append(candidates, move)
+			move = counter(rules, move)
+		}
+	}
+	return candidates
+}
+
+// counter returns the first move of the rule set that beats the given move.
+func counter(rules *domain.RuleSet, move domain.Move) domain.Move {
+	counters := rules.Counters(move)
+	if len(counters) == 0 {
+		return move
+	}
+	return counters[0]
+}
+
+// mostLikely returns the move with the highest count, breaking ties in
+// favor of the move the opponent played most recently.
+func mostLikely(counts map[domain.Move]int, history []domain.RoundResult) domain.Move {
+	best := history[len(history)-1].Move1
+	for i := len(history) - 1; i >= 0; i-- {
+		move := history[i].Move1
+		if counts[move] > counts[best] {
+			best = move
+		}
+	}
+	return best
+}
+
+func opponentMoves(rounds []domain.RoundResult) string {
+	key := ""
+	for _, round := range rounds {
+		key += fmt.Sprintf("%d,", round.Move1)
+	}
+	return key
+}
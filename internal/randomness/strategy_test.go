@@ -0,0 +1,107 @@
+package randomness
+
+import (
+	"testing"
+
+	"protofire-game/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// play runs rounds between a strategy and an opponent that picks its moves
+// from the history, and returns how many rounds the strategy won.
+func play(strategy domain.Strategy, rules *domain.RuleSet, rounds int, opponent func(history []domain.RoundResult) domain.Move) int {
+	var history []domain.RoundResult
+	wins := 0
+	for i := 0; i < rounds; i++ {
+		botMove := strategy.NextMove(rules, history)
+		opponentMove := opponent(history)
+		round := domain.RoundResult{Move1: opponentMove, Move2: botMove}
+		if rules.DetermineWinner(opponentMove, botMove) == 2 {
+			wins++
+			round.Winner = "Bot"
+		}
+		history = append(history, round)
+	}
+	return wins
+}
+
+func cycle(moves ...domain.Move) func(history []domain.RoundResult) domain.Move {
+	return func(history []domain.RoundResult) domain.Move {
+		return moves[len(history)%len(moves)]
+	}
+}
+
+func TestFrequencyStrategy(t *testing.T) {
+	randGen := NewMockRandomGenerator([]domain.Move{domain.Rock})
+	strategy := NewFrequencyStrategy(randGen)
+
+	assert.Equal(t, domain.Rock, strategy.NextMove(domain.RockPaperScissors, nil))
+
+	history := []domain.RoundResult{
+		{Move1: domain.Scissors}, {Move1: domain.Rock}, {Move1: domain.Scissors}, {Move1: domain.Paper},
+	}
+	assert.Equal(t, domain.Rock, strategy.NextMove(domain.RockPaperScissors, history))
+
+	// Ties go to the most recent move
+	history = append(history, domain.RoundResult{Move1: domain.Paper})
+	assert.Equal(t, domain.Scissors, strategy.NextMove(domain.RockPaperScissors, history))
+
+	// Rock is beaten by Paper and Spock, the first counter in the rules wins
+	history = []domain.RoundResult{{Move1: domain.Rock}}
+	assert.Equal(t, domain.Paper, strategy.NextMove(domain.RockPaperScissorsLizardSpock, history))
+}
+
+func TestMarkovStrategyLearnsCycles(t *testing.T) {
+	randGen := NewMockRandomGenerator([]domain.Move{domain.Rock})
+	strategy := NewMarkovStrategy(2, NewRandomStrategy(randGen))
+
+	wins := play(strategy, domain.RockPaperScissors, 30, cycle(domain.Rock, domain.Rock, domain.Paper, domain.Scissors))
+	assert.GreaterOrEqual(t, wins, 24)
+
+	wins = play(strategy, domain.RockPaperScissorsLizardSpock, 30, cycle(domain.Spock, domain.Lizard, domain.Spock, domain.Rock))
+	assert.GreaterOrEqual(t, wins, 24)
+}
+
+func TestWinStayLoseShiftStrategy(t *testing.T) {
+	randGen := NewMockRandomGenerator([]domain.Move{domain.Paper})
+	strategy := NewWinStayLoseShiftStrategy(randGen)
+
+	// The opponent won with Rock, expect Rock again
+	history := []domain.RoundResult{{Move1: domain.Rock, Move2: domain.Scissors}}
+	assert.Equal(t, domain.Paper, strategy.NextMove(domain.RockPaperScissors, history))
+
+	// The opponent lost to Paper, expect Scissors
+	history = []domain.RoundResult{{Move1: domain.Rock, Move2: domain.Paper}}
+	assert.Equal(t, domain.Rock, strategy.NextMove(domain.RockPaperScissors, history))
+
+	// Draws give nothing to exploit
+	history = []domain.RoundResult{{Move1: domain.Rock, Move2: domain.Rock}}
+	assert.Equal(t, domain.Paper, strategy.NextMove(domain.RockPaperScissors, history))
+}
+
+func TestEnsembleStrategyAdapts(t *testing.T) {
+	randGen := NewMockRandomGenerator([]domain.Move{domain.Rock, domain.Paper, domain.Scissors})
+	strategies := NewStrategies(randGen)
+	expert := strategies[domain.Expert]
+
+	// An opponent that always counters the bot's last move beats a plain
+	// frequency strategy but not the ensemble, which second-guesses it.
+	counterLast := func(history []domain.RoundResult) domain.Move {
+		if len(history) == 0 {
+			return domain.Rock
+		}
+		return counter(domain.RockPaperScissors, history[len(history)-1].Move2)
+	}
+	assert.GreaterOrEqual(t, play(expert, domain.RockPaperScissors, 50, counterLast), 35)
+
+	assert.GreaterOrEqual(t, play(expert, domain.RockPaperScissors, 50, cycle(domain.Paper)), 45)
+	assert.GreaterOrEqual(t, play(expert, domain.RockPaperScissors, 50, cycle(domain.Rock, domain.Paper, domain.Scissors)), 35)
+}
+
+func TestNewStrategiesCoversEveryDifficulty(t *testing.T) {
+	strategies := NewStrategies(NewMockRandomGenerator([]domain.Move{domain.Rock}))
+	for d := domain.Easy; d <= domain.Expert; d++ {
+		assert.Contains(t, strategies, d, d.String())
+	}
+}
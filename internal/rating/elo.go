@@ -0,0 +1,49 @@
+package rating
+
+import (
+	"math"
+
+	"protofire-game/internal/domain"
+)
+
+const (
+	DefaultEloK   = 32
+	initialRating = 1500
+)
+
+type Elo struct {
+	k float64
+}
+
+func NewElo(k float64) *Elo {
+	if k <= 0 {
+		k = DefaultEloK
+	}
+	return &Elo{k: k}
+}
+
+func (e *Elo) Name() string {
+	return "elo"
+}
+
+func (e *Elo) NewRating(player string) *domain.Rating {
+	return &domain.Rating{
+		Player: player,
+		Rating: initialRating,
+	}
+}
+
+func (e *Elo) Update(a, b *domain.Rating, score float64) (*domain.Rating, *domain.Rating) {
+	expected := 1 / (1 + math.Pow(10, (b.Rating-a.Rating)/400))
+	delta := e.k * (score - expected)
+
+	newA := *a
+	newA.Rating += delta
+	newA.Games++
+
+	newB := *b
+	newB.Rating -= delta
+	newB.Games++
+
+	return &newA, &newB
+}
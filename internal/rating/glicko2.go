@@ -0,0 +1,120 @@
+package rating
+
+import (
+	"math"
+
+	"protofire-game/internal/domain"
+)
+
+// Glicko-2 as described in http://www.glicko.net/glicko/glicko2.pdf, with
+// every game treated as its own rating period.
+
+const (
+	DefaultGlicko2Tau = 0.5
+
+	initialDeviation  = 350
+	initialVolatility = 0.06
+	glicko2Scale      = 173.7178
+	convergence       = 0.000001
+)
+
+type Glicko2 struct {
+	tau float64
+}
+
+func NewGlicko2(tau float64) *Glicko2 {
+	if tau <= 0 {
+		tau = DefaultGlicko2Tau
+	}
+	return &Glicko2{tau: tau}
+}
+
+func (g *Glicko2) Name() string {
+	return "glicko2"
+}
+
+func (g *Glicko2) NewRating(player string) *domain.Rating {
+	return &domain.Rating{
+		Player:     player,
+		Rating:     initialRating,
+		Deviation:  initialDeviation,
+		Volatility: initialVolatility,
+	}
+}
+
+func (g *Glicko2) Update(a, b *domain.Rating, score float64) (*domain.Rating, *domain.Rating) {
+	return g.update(a, []result{{b, score}}), g.update(b, []result{{a, 1 - score}})
+}
+
+type result struct {
+	opponent *domain.Rating
+	score    float64
+}
+
+// update rates a player over a rating period made of the given results.
+func (g *Glicko2) update(player *domain.Rating, results []result) *domain.Rating {
+	mu := (player.Rating - initialRating) / glicko2Scale
+	phi := player.Deviation / glicko2Scale
+
+	var vInv, improvement float64
+	for _, r := range results {
+		muJ := (r.opponent.Rating - initialRating) / glicko2Scale
+		phiJ := r.opponent.Deviation / glicko2Scale
+
+		gPhiJ := 1 / math.Sqrt(1+3*phiJ*phiJ/(math.Pi*math.Pi))
+		expected := 1 / (1 + math.Exp(-gPhiJ*(mu-muJ)))
+		vInv += gPhiJ * gPhiJ * expected * (1 - expected)
+		improvement += gPhiJ * (r.score - expected)
+	}
+	v := 1 / vInv
+	delta := v * improvement
+
+	sigma := g.volatility(phi, player.Volatility, v, delta)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*improvement
+
+	updated := *player
+	updated.Rating = glicko2Scale*newMu + initialRating
+	updated.Deviation = glicko2Scale * newPhi
+	updated.Volatility = sigma
+	updated.Games += len(results)
+	return &updated
+}
+
+// volatility finds the new volatility with the Illinois algorithm (step 5).
+func (g *Glicko2) volatility(phi, sigma, v, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-d)/(2*d*d) - (x-a)/(g.tau*g.tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*g.tau) < 0 {
+			k++
+		}
+		B = a - k*g.tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > convergence {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+
+	return math.Exp(A / 2)
+}
@@ -0,0 +1,61 @@
+package rating
+
+import (
+	"testing"
+
+	"protofire-game/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEloUpdate(t *testing.T) {
+	elo := NewElo(32)
+
+	a, b := elo.Update(elo.NewRating("Alice"), elo.NewRating("Bob"), 1)
+	assert.Equal(t, 1516.0, a.Rating)
+	assert.Equal(t, 1484.0, b.Rating)
+	assert.Equal(t, 1, a.Games)
+	assert.Equal(t, 1, b.Games)
+
+	// The favorite gains little from a win and loses a lot from a loss
+	favorite := &domain.Rating{Player: "Alice", Rating: 1800}
+	underdog := &domain.Rating{Player: "Bob", Rating: 1400}
+	a, _ = elo.Update(favorite, underdog, 1)
+	assert.InDelta(t, 1802.9, a.Rating, 0.1)
+	a, b = elo.Update(favorite, underdog, 0)
+	assert.InDelta(t, 1770.9, a.Rating, 0.1)
+	assert.InDelta(t, 1429.1, b.Rating, 0.1)
+
+	// A draw between equals changes nothing
+	a, b = elo.Update(elo.NewRating("Alice"), elo.NewRating("Bob"), 0.5)
+	assert.Equal(t, 1500.0, a.Rating)
+	assert.Equal(t, 1500.0, b.Rating)
+}
+
+// Example from the Glicko-2 paper.
+func TestGlicko2Update(t *testing.T) {
+	glicko := NewGlicko2(0.5)
+
+	player := &domain.Rating{Player: "Alice", Rating: 1500, Deviation: 200, Volatility: 0.06}
+	updated := glicko.update(player, []result{
+		{&domain.Rating{Rating: 1400, Deviation: 30}, 1},
+		{&domain.Rating{Rating: 1550, Deviation: 100}, 0},
+		{&domain.Rating{Rating: 1700, Deviation: 300}, 0},
+	})
+
+	assert.InDelta(t, 1464.06, updated.Rating, 0.01)
+	assert.InDelta(t, 151.52, updated.Deviation, 0.01)
+	assert.InDelta(t, 0.05999, updated.Volatility, 0.00001)
+	assert.Equal(t, 3, updated.Games)
+}
+
+func TestGlicko2SingleGame(t *testing.T) {
+	glicko := NewGlicko2(0)
+
+	a, b := glicko.Update(glicko.NewRating("Alice"), glicko.NewRating("Bob"), 1)
+	assert.Greater(t, a.Rating, 1500.0)
+	assert.Less(t, b.Rating, 1500.0)
+	assert.InDelta(t, a.Rating-1500, 1500-b.Rating, 0.0001)
+	assert.Less(t, a.Deviation, 350.0)
+	assert.Less(t, b.Deviation, 350.0)
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations are SQL files named after the schema version they upgrade to,
+// as in 0002_add_rule_set.sql. Once shipped a migration never changes: a new
+// schema change is a new file with the next version.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+func loadMigrations() ([]migration, error) {
+	files, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no version", file.Name())
+		}
+
+		query, err := migrationFiles.ReadFile(path.Join("migrations", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, query: string(query)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migration %s should be version %d", m.name, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// migrate brings the database schema up to date. Each migration is applied
+// in its own transaction along with its version, so a failed migration
+// leaves the database at the previous version.
+func migrate(db *sql.DB, migrations []migration) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		applied_at DATETIME NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		// A database created before migrations has the schema of the first
+		// release, version 1, and is upgraded from there.
+		legacy, err := hasTable(db, "game_results")
+		if err != nil {
+			return err
+		}
+		if legacy {
+			if err := setSchemaVersion(db, 1); err != nil {
+				return err
+			}
+			current = 1
+		}
+	}
+
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than the latest known, %d", current, len(migrations))
+	}
+
+	for _, m := range migrations[current:] {
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.query); err != nil {
+		return err
+	}
+	if err := setSchemaVersion(tx, m.version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func schemaVersion(db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+func setSchemaVersion(db interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}, version int) error {
+	_, err := db.Exec(`INSERT INTO schema_version (version, applied_at) VALUES (?, ?)`,
+		version, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// hasTable tells whether the database has a table.
+func hasTable(db *sql.DB, table string) (bool, error) {
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,7 @@
+CREATE TABLE game_results (
+	id TEXT PRIMARY KEY,
+	player1 TEXT NOT NULL,
+	player2 TEXT NOT NULL,
+	winner TEXT NOT NULL,
+	played_at DATETIME NOT NULL
+);
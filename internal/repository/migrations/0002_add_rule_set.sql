@@ -0,0 +1,2 @@
+-- Games stored before rule sets existed were all Rock Paper Scissors.
+ALTER TABLE game_results ADD COLUMN rule_set TEXT NOT NULL DEFAULT 'rps';
@@ -0,0 +1,8 @@
+CREATE TABLE rounds (
+	game_id TEXT NOT NULL REFERENCES game_results(id) ON DELETE CASCADE,
+	round_number INTEGER NOT NULL,
+	move1 INTEGER NOT NULL,
+	move2 INTEGER NOT NULL,
+	winner TEXT NOT NULL,
+	PRIMARY KEY (game_id, round_number)
+);
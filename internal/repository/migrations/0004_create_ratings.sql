@@ -0,0 +1,14 @@
+CREATE TABLE ratings (
+	system TEXT NOT NULL,
+	player TEXT NOT NULL,
+	rating REAL NOT NULL,
+	deviation REAL NOT NULL,
+	volatility REAL NOT NULL,
+	games INTEGER NOT NULL,
+	PRIMARY KEY (system, player)
+);
+
+-- Games stored before the mode was recorded can only be told apart by the
+-- name the CLI gives to the bot. 1 is Player vs Bot.
+ALTER TABLE game_results ADD COLUMN mode INTEGER NOT NULL DEFAULT 0;
+UPDATE game_results SET mode = 1 WHERE player2 = 'Bot';
@@ -0,0 +1,2 @@
+ALTER TABLE rounds ADD COLUMN commitment TEXT NOT NULL DEFAULT '';
+ALTER TABLE rounds ADD COLUMN salt TEXT NOT NULL DEFAULT '';
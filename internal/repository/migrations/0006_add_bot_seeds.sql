@@ -0,0 +1,2 @@
+ALTER TABLE game_results ADD COLUMN difficulty INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE game_results ADD COLUMN seed INTEGER;
@@ -0,0 +1,29 @@
+CREATE TABLE tournaments (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	format INTEGER NOT NULL,
+	match_format TEXT NOT NULL,
+	rule_set TEXT NOT NULL,
+	swiss_rounds INTEGER NOT NULL,
+	winner TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE tournament_players (
+	tournament_id TEXT NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+	seed INTEGER NOT NULL,
+	player TEXT NOT NULL,
+	PRIMARY KEY (tournament_id, seed)
+);
+
+CREATE TABLE tournament_matches (
+	tournament_id TEXT NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+	round INTEGER NOT NULL,
+	number INTEGER NOT NULL,
+	bracket TEXT NOT NULL,
+	player1 TEXT NOT NULL,
+	player2 TEXT NOT NULL,
+	winner TEXT NOT NULL,
+	game_id TEXT NOT NULL,
+	PRIMARY KEY (tournament_id, round, number)
+);
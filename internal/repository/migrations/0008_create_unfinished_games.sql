@@ -0,0 +1,24 @@
+CREATE TABLE unfinished_games (
+	id TEXT PRIMARY KEY,
+	player1 TEXT NOT NULL,
+	player2 TEXT NOT NULL,
+	mode INTEGER NOT NULL,
+	rule_set TEXT NOT NULL,
+	match_format TEXT NOT NULL,
+	difficulty INTEGER NOT NULL,
+	seed INTEGER,
+	played_at DATETIME NOT NULL
+);
+
+CREATE TABLE unfinished_rounds (
+	game_id TEXT NOT NULL REFERENCES unfinished_games(id) ON DELETE CASCADE,
+	round_number INTEGER NOT NULL,
+	move1 INTEGER NOT NULL,
+	move2 INTEGER NOT NULL,
+	winner TEXT NOT NULL,
+	commitment TEXT NOT NULL,
+	salt TEXT NOT NULL,
+	PRIMARY KEY (game_id, round_number)
+);
+
+ALTER TABLE game_results ADD COLUMN forfeit INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,12 @@
+-- Games were stored with the local time of the machine, with its offset, and
+-- imported ones could have fractional seconds. Stored in UTC to the second,
+-- like new games, their dates sort and compare as text, so history queries
+-- can use the indexes.
+UPDATE game_results
+SET played_at = strftime('%Y-%m-%dT%H:%M:%SZ', played_at)
+WHERE strftime('%Y-%m-%dT%H:%M:%SZ', played_at) IS NOT NULL;
+
+CREATE INDEX game_results_played_at ON game_results (played_at);
+CREATE INDEX game_results_player1 ON game_results (player1, played_at);
+CREATE INDEX game_results_player2 ON game_results (player2, played_at);
+CREATE INDEX game_results_winner ON game_results (winner, played_at);
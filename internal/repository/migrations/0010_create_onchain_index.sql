@@ -0,0 +1,35 @@
+-- The GameResultStored events read from a contract, so that on-chain history
+-- is only fetched from the last synced block. The hashes of the blocks with
+-- events and of the last synced block tell when a reorg replaced them.
+CREATE TABLE onchain_sync (
+	contract TEXT PRIMARY KEY,
+	block_number INTEGER NOT NULL,
+	block_hash TEXT NOT NULL
+);
+
+CREATE TABLE onchain_blocks (
+	contract TEXT NOT NULL,
+	number INTEGER NOT NULL,
+	hash TEXT NOT NULL,
+	timestamp INTEGER NOT NULL,
+	PRIMARY KEY (contract, number)
+);
+
+CREATE TABLE onchain_games (
+	contract TEXT NOT NULL,
+	block_number INTEGER NOT NULL,
+	log_index INTEGER NOT NULL,
+	id TEXT NOT NULL,
+	player1 TEXT NOT NULL,
+	player2 TEXT NOT NULL,
+	winner TEXT NOT NULL,
+	mode INTEGER NOT NULL,
+	rule_set TEXT NOT NULL,
+	played_at TEXT NOT NULL,
+	PRIMARY KEY (contract, block_number, log_index)
+);
+
+CREATE INDEX onchain_games_played_at ON onchain_games (contract, played_at);
+CREATE INDEX onchain_games_player1 ON onchain_games (contract, player1, played_at);
+CREATE INDEX onchain_games_player2 ON onchain_games (contract, player2, played_at);
+CREATE INDEX onchain_games_winner ON onchain_games (contract, winner, played_at);
@@ -0,0 +1,31 @@
+-- Finished games waiting to be stored on-chain, so that they are not lost
+-- while the node cannot be reached. next_attempt_at is in Unix milliseconds.
+CREATE TABLE outbox (
+	game_id TEXT PRIMARY KEY,
+	contract TEXT NOT NULL,
+	player1 TEXT NOT NULL,
+	player2 TEXT NOT NULL,
+	winner TEXT NOT NULL,
+	mode INTEGER NOT NULL,
+	rule_set TEXT NOT NULL,
+	played_at TEXT NOT NULL,
+	status TEXT NOT NULL,
+	tx_hash TEXT NOT NULL DEFAULT '',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	next_attempt_at INTEGER NOT NULL,
+	queued_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE INDEX outbox_due ON outbox (contract, status, next_attempt_at);
+
+-- The signed transactions sent for a pending game, in the order they were
+-- sent. They share a nonce, so once one is mined the others cannot be, and
+-- they are waited on again after a restart instead of sending a new one.
+CREATE TABLE outbox_transactions (
+	game_id TEXT NOT NULL,
+	seq INTEGER NOT NULL,
+	raw BLOB NOT NULL,
+	PRIMARY KEY (game_id, seq)
+);
@@ -0,0 +1,3 @@
+-- The address that stored each on-chain game. Games read from the events of
+-- contracts deployed before it was recorded have none.
+ALTER TABLE onchain_games ADD COLUMN submitter TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Games of the on-chain index are looked up by ID, as by GET /games/{id}.
+CREATE INDEX onchain_games_id ON onchain_games (contract, id);
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"protofire-game/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestDatabase opens an empty database file, to be prepared by the test
+// before the repository opens it.
+func openTestDatabase(t *testing.T) (*sql.DB, string) {
+	dbPath := filepath.Join(t.TempDir(), "protofire-game.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, dbPath
+}
+
+func testSchemaVersion(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	version, err := schemaVersion(db)
+	require.NoError(t, err)
+	return version
+}
+
+func TestMigrationsAreNumberedInOrder(t *testing.T) {
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.version, m.name)
+		assert.NotEmpty(t, m.query, m.name)
+	}
+}
+
+func TestMigrateNewDatabase(t *testing.T) {
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+
+	repo := newTestSQLiteRepository(t)
+	assert.Equal(t, len(migrations), testSchemaVersion(t, repo.db))
+
+	// Migrations already applied are not applied again
+	require.NoError(t, migrate(repo.db, migrations))
+	var applied int
+	require.NoError(t, repo.db.QueryRow(`SELECT COUNT(*) FROM schema_version`).Scan(&applied))
+	assert.Equal(t, len(migrations), applied)
+}
+
+func TestMigrateV1Database(t *testing.T) {
+	db, dbPath := openTestDatabase(t)
+	fixture, err := os.ReadFile(filepath.Join("testdata", "v1.sql"))
+	require.NoError(t, err)
+	_, err = db.Exec(string(fixture))
+	require.NoError(t, err)
+
+	repo, err := NewSQLiteRepository(dbPath)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), testSchemaVersion(t, repo.db))
+
+	// Games stored before keep their results, with the defaults of the
+	// columns added since
+	history, err := repo.GetGameHistory(context.Background())
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	for _, game := range history {
+		assert.Equal(t, domain.RockPaperScissors.Name, game.RuleSet, game.ID)
+		assert.Empty(t, game.Rounds, game.ID)
+		assert.Nil(t, game.Seed, game.ID)
+		assert.False(t, game.Forfeit, game.ID)
+	}
+	assert.Equal(t, "Draw", history[0].Winner)
+	assert.Equal(t, "2025-01-03T10:00:00Z", history[0].PlayedAt)
+	assert.Equal(t, domain.PlayerVsBot, history[1].Mode)
+	assert.Equal(t, domain.PlayerVsPlayer, history[2].Mode)
+
+	// and new games use every table
+	seed := uint64(42)
+	game := &domain.Game{
+		ID:         "new",
+		Player1:    "Alice",
+		Player2:    "Bot",
+		Winner:     "Alice",
+		Mode:       domain.PlayerVsBot,
+		RuleSet:    domain.RockPaperScissorsLizardSpock.Name,
+		Difficulty: domain.Expert,
+		Seed:       &seed,
+		PlayedAt:   "2025-02-01T10:00:00Z",
+		Rounds: []domain.RoundResult{
+			{Move1: domain.Spock, Move2: domain.Rock, Winner: "Alice", Commitment: "c0ffee", Salt: "5a17"},
+		},
+	}
+	require.NoError(t, repo.SaveGame(context.Background(), game))
+	require.NoError(t, repo.SaveRatings("elo", []*domain.Rating{{Player: "Alice", Rating: 1516, Games: 1}}))
+
+	history, err = repo.GetGameHistory(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, game, history[0])
+}
+
+func TestMigrateStoresDatesInUTC(t *testing.T) {
+	db, dbPath := openTestDatabase(t)
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.NoError(t, migrate(db, migrations[:8]))
+	_, err = db.Exec(`INSERT INTO game_results (id, player1, player2, winner, mode, played_at) VALUES
+		('offset', 'Alice', 'Bob', 'Alice', 0, '2025-01-01T12:00:00+02:00'),
+		('fraction', 'Alice', 'Bob', 'Bob', 0, '2025-01-01T11:00:00.5Z'),
+		('utc', 'Alice', 'Bob', 'Draw', 0, '2025-01-01T09:00:00Z')`)
+	require.NoError(t, err)
+
+	repo, err := NewSQLiteRepository(dbPath)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	history, err := repo.GetGameHistory(context.Background())
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "2025-01-01T11:00:00Z", history[0].PlayedAt)
+	assert.Equal(t, "2025-01-01T10:00:00Z", history[1].PlayedAt)
+	assert.Equal(t, "2025-01-01T09:00:00Z", history[2].PlayedAt)
+}
+
+func TestFailedMigrationIsRolledBack(t *testing.T) {
+	db, _ := openTestDatabase(t)
+
+	migrations := []migration{
+		{version: 1, name: "0001_create_games", query: `CREATE TABLE games (id TEXT PRIMARY KEY);`},
+		{version: 2, name: "0002_broken", query: `CREATE TABLE players (name TEXT); ALTER TABLE missing ADD COLUMN x INTEGER;`},
+	}
+	err := migrate(db, migrations)
+	assert.ErrorContains(t, err, "migration 0002_broken")
+	assert.Equal(t, 1, testSchemaVersion(t, db))
+
+	var tables int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'players'`).Scan(&tables))
+	assert.Zero(t, tables)
+
+	// Fixed, the migration is applied on the next start
+	migrations[1].query = `CREATE TABLE players (name TEXT);`
+	require.NoError(t, migrate(db, migrations))
+	assert.Equal(t, 2, testSchemaVersion(t, db))
+}
+
+func TestMigrateNewerDatabase(t *testing.T) {
+	db, _ := openTestDatabase(t)
+	migrations := []migration{{version: 1, name: "0001_create_games", query: `CREATE TABLE games (id TEXT PRIMARY KEY);`}}
+	require.NoError(t, migrate(db, migrations))
+
+	_, err := db.Exec(`INSERT INTO schema_version (version, applied_at) VALUES (2, '2025-01-01T10:00:00Z')`)
+	require.NoError(t, err)
+	assert.EqualError(t, migrate(db, migrations), "database schema version 2 is newer than the latest known, 1")
+}
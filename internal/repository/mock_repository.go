@@ -1,28 +1,211 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
 	"protofire-game/internal/domain"
 )
 
 type MockRepository struct {
-	Games []*domain.Game
+	Games       []*domain.Game
+	Ratings     map[string]map[string]*domain.Rating
+	Tournaments map[string]*domain.Tournament
+	Unfinished  map[string]*domain.UnfinishedGame
+	mu          sync.Mutex
 }
 
 func NewMockRepository() *MockRepository {
 	return &MockRepository{
-		Games: make([]*domain.Game, 0),
+		Games:       make([]*domain.Game, 0),
+		Ratings:     make(map[string]map[string]*domain.Rating),
+		Tournaments: make(map[string]*domain.Tournament),
+		Unfinished:  make(map[string]*domain.UnfinishedGame),
 	}
 }
 
-func (m *MockRepository) SaveGame(result *domain.Game) error {
+// The games and their stats fail like a real store once the context is done.
+func (m *MockRepository) SaveGame(ctx context.Context, result *domain.Game) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Games = append(m.Games, result)
 	return nil
 }
 
-func (m *MockRepository) GetGameHistory() ([]*domain.Game, error) {
-	return m.Games, nil
+func (m *MockRepository) GetGameHistory(ctx context.Context) ([]*domain.Game, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*domain.Game(nil), m.Games...), nil
+}
+
+func (m *MockRepository) GetGame(ctx context.Context, id string) (*domain.Game, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, game := range m.Games {
+		if game.ID == id {
+			return game, nil
+		}
+	}
+	return nil, fmt.Errorf("game %s %w", id, domain.ErrNotFound)
+}
+
+func (m *MockRepository) QueryGameHistory(ctx context.Context, query domain.HistoryQuery) (*domain.HistoryPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return domain.QueryGames(m.Games, query), nil
 }
 
 func (m *MockRepository) Close() error {
 	return nil
 }
+
+func (m *MockRepository) GetLeaderboard(ctx context.Context, limit int) ([]*domain.PlayerStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return domain.ComputeLeaderboard(m.Games, limit), nil
+}
+
+func (m *MockRepository) GetPlayerStats(ctx context.Context, player string) (*domain.PlayerStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return domain.ComputePlayerStats(m.Games, player), nil
+}
+
+func (m *MockRepository) GetHeadToHead(ctx context.Context, player string) ([]*domain.HeadToHead, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return domain.ComputeHeadToHead(m.Games, player), nil
+}
+
+func (m *MockRepository) GetRatings(system string) ([]*domain.Rating, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ratings := make([]*domain.Rating, 0, len(m.Ratings[system]))
+	for _, rating := range m.Ratings[system] {
+		ratings = append(ratings, rating)
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].Rating != ratings[j].Rating {
+			return ratings[i].Rating > ratings[j].Rating
+		}
+		return ratings[i].Player < ratings[j].Player
+	})
+	return ratings, nil
+}
+
+func (m *MockRepository) GetRating(system, player string) (*domain.Rating, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Ratings[system][player], nil
+}
+
+func (m *MockRepository) SaveRatings(system string, ratings []*domain.Rating) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Ratings[system] == nil {
+		m.Ratings[system] = make(map[string]*domain.Rating)
+	}
+	for _, rating := range ratings {
+		m.Ratings[system][rating.Player] = rating
+	}
+	return nil
+}
+
+// Tournaments are copied in and out, as a real store would, so that changes
+// not saved are lost.
+func (m *MockRepository) SaveTournament(tournament *domain.Tournament) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Tournaments[tournament.ID] = copyTournament(tournament)
+	return nil
+}
+
+func (m *MockRepository) GetTournament(id string) (*domain.Tournament, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tournament, ok := m.Tournaments[id]
+	if !ok {
+		return nil, nil
+	}
+	return copyTournament(tournament), nil
+}
+
+func (m *MockRepository) ListTournaments() ([]*domain.Tournament, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tournaments := make([]*domain.Tournament, 0, len(m.Tournaments))
+	for _, tournament := range m.Tournaments {
+		tournaments = append(tournaments, copyTournament(tournament))
+	}
+	sort.Slice(tournaments, func(i, j int) bool {
+		return tournaments[i].CreatedAt > tournaments[j].CreatedAt
+	})
+	return tournaments, nil
+}
+
+func copyTournament(tournament *domain.Tournament) *domain.Tournament {
+	copied := *tournament
+	copied.Players = append([]string(nil), tournament.Players...)
+	copied.Matches = make([]*domain.TournamentMatch, len(tournament.Matches))
+	for i, match := range tournament.Matches {
+		matchCopy := *match
+		copied.Matches[i] = &matchCopy
+	}
+	return &copied
+}
+
+func (m *MockRepository) SaveUnfinishedGame(unfinished *domain.UnfinishedGame) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Unfinished[unfinished.Game.ID] = copyUnfinishedGame(unfinished)
+	return nil
+}
+
+func (m *MockRepository) GetUnfinishedGames() ([]*domain.UnfinishedGame, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	games := make([]*domain.UnfinishedGame, 0, len(m.Unfinished))
+	for _, unfinished := range m.Unfinished {
+		games = append(games, copyUnfinishedGame(unfinished))
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].Game.PlayedAt > games[j].Game.PlayedAt
+	})
+	return games, nil
+}
+
+func (m *MockRepository) DeleteUnfinishedGame(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Unfinished, id)
+	return nil
+}
+
+func copyUnfinishedGame(unfinished *domain.UnfinishedGame) *domain.UnfinishedGame {
+	game := *unfinished.Game
+	game.Rounds = append([]domain.RoundResult(nil), unfinished.Game.Rounds...)
+	return &domain.UnfinishedGame{Game: &game, Format: unfinished.Format}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// nonceManager hands out the nonces of a signer without asking the node for
+// every transaction, so that a transaction can be sent while the ones before
+// it are not mined yet. The counter is read from the node's pending nonce by
+// sync, and again for the next nonce after a resync.
+type nonceManager struct {
+	client ethereum.PendingStateReader
+	from   common.Address
+
+	mu     sync.Mutex
+	next   uint64
+	synced bool
+}
+
+func newNonceManager(client ethereum.PendingStateReader, from common.Address) *nonceManager {
+	return &nonceManager{
+		client: client,
+		from:   from,
+	}
+}
+
+// sync reads the counter from the node's pending nonce.
+func (m *nonceManager) sync(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.syncLocked(ctx)
+}
+
+func (m *nonceManager) syncLocked(ctx context.Context) error {
+	nonce, err := m.client.PendingNonceAt(ctx, m.from)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %w", err)
+	}
+	m.next = nonce
+	m.synced = true
+	return nil
+}
+
+// reserve returns the next nonce, which no other transaction gets until it
+// is released.
+func (m *nonceManager) reserve(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.synced {
+		if err := m.syncLocked(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	nonce := m.next
+	m.next++
+	return nonce, nil
+}
+
+// release gives back the nonce of a transaction that was not sent. The last
+// nonce reserved is handed out again. An earlier one leaves a gap that holds
+// back the transactions after it, so the counter is read again from the
+// node, whose pending nonce stops at the gap.
+func (m *nonceManager) release(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.synced && nonce+1 == m.next {
+		m.next--
+		return
+	}
+	m.synced = false
+}
+
+// resync reads the counter again from the node for the next nonce, after
+// another client of the same signer used the nonces reserved here.
+func (m *nonceManager) resync() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.synced = false
+}
+
+// isNonceTaken tells whether a transaction was refused because its nonce was
+// already used by another one, mined or pending. The node only sends the
+// message of the error.
+func isNonceTaken(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "nonce too low") || strings.Contains(message, "replacement transaction underpriced")
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingNonceReader is a node whose pending nonce is set by the test.
+type pendingNonceReader struct {
+	ethereum.PendingStateReader
+	nonce uint64
+	err   error
+	reads int
+}
+
+func (r *pendingNonceReader) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	r.reads++
+	return r.nonce, r.err
+}
+
+func TestNonceManager(t *testing.T) {
+	ctx := context.Background()
+	node := &pendingNonceReader{nonce: 7}
+	nonces := newNonceManager(node, common.Address{})
+	require.NoError(t, nonces.sync(ctx))
+
+	reserve := func() uint64 {
+		nonce, err := nonces.reserve(ctx)
+		require.NoError(t, err)
+		return nonce
+	}
+
+	assert.Equal(t, []uint64{7, 8, 9}, []uint64{reserve(), reserve(), reserve()})
+	assert.Equal(t, 1, node.reads, "the node is only asked on sync")
+
+	nonces.release(9)
+	assert.Equal(t, uint64(9), reserve(), "the last nonce is reserved again")
+
+	// Releasing 8 leaves a gap, which the node's pending nonce stops at.
+	nonces.release(8)
+	node.nonce = 8
+	assert.Equal(t, uint64(8), reserve())
+	assert.Equal(t, 2, node.reads)
+
+	node.nonce = 12
+	nonces.resync()
+	assert.Equal(t, uint64(12), reserve())
+	assert.Equal(t, 3, node.reads)
+}
+
+func TestNonceManagerSyncFailsWithTheNode(t *testing.T) {
+	node := &pendingNonceReader{err: errors.New("connection refused")}
+	nonces := newNonceManager(node, common.Address{})
+	assert.ErrorContains(t, nonces.sync(context.Background()), "connection refused")
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// syncIndex reads the GameResultStored events of the blocks mined since the
+// last sync into the index. The first sync starts from the first block with
+// an event. Blocks are synced maxBlocksPerQuery at a time, each range saved
+// as it is read, so a sync that is given up continues from the last range.
+func (r *OnChainRepository) syncIndex(ctx context.Context) error {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+
+	contract := r.contractAddr.Hex()
+	tip, err := r.index.indexTip(ctx, contract)
+	if err != nil {
+		return err
+	}
+	if tip != nil {
+		if tip, err = r.rollbackReorg(ctx, contract, tip); err != nil {
+			return err
+		}
+	}
+
+	latestBlock, err := r.client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %w", err)
+	}
+
+	var fromBlock uint64
+	if tip == nil {
+		topics := [][]common.Hash{r.gameEventIDs()}
+		if fromBlock, err = r.firstEventBlock(ctx, topics, latestBlock); err != nil {
+			return err
+		}
+	} else {
+		fromBlock = tip.Number + 1
+	}
+
+	for fromBlock <= latestBlock {
+		toBlock := min(fromBlock+maxBlocksPerQuery-1, latestBlock)
+		synced, err := r.syncBlocks(ctx, contract, fromBlock, toBlock)
+		if err != nil {
+			return err
+		}
+		// A range replaced by a reorg while it was read is read again.
+		if synced {
+			fromBlock = toBlock + 1
+		}
+	}
+	return nil
+}
+
+// syncBlocks indexes the events of a range of blocks. It returns false,
+// without saving anything, when the last block of the range changed while
+// the events were read.
+func (r *OnChainRepository) syncBlocks(ctx context.Context, contract string, fromBlock, toBlock uint64) (bool, error) {
+	before, err := r.client.HeaderByNumber(ctx, new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return false, fmt.Errorf("failed to get block %d: %w", toBlock, err)
+	}
+
+	logs, err := r.client.FilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{r.contractAddr},
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Topics:    [][]common.Hash{r.gameEventIDs()},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get logs from block %d to %d: %w", fromBlock, toBlock, err)
+	}
+
+	after, err := r.client.HeaderByNumber(ctx, new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return false, fmt.Errorf("failed to get block %d: %w", toBlock, err)
+	}
+	if after.Hash() != before.Hash() {
+		return false, nil
+	}
+
+	blocks := make(map[common.Hash]indexedBlock)
+	var games []indexedGame
+	for _, log := range logs {
+		game, ok := r.decodeGameResult(log)
+		if !ok {
+			continue
+		}
+
+		block, exists := blocks[log.BlockHash]
+		if !exists {
+			header, err := r.client.HeaderByHash(ctx, log.BlockHash)
+			if err != nil {
+				return false, fmt.Errorf("failed to get block: %w", err)
+			}
+			block = indexedBlock{Number: log.BlockNumber, Hash: log.BlockHash, Time: header.Time}
+			blocks[log.BlockHash] = block
+		}
+		if game.PlayedAt == "" {
+			game.PlayedAt = time.Unix(int64(block.Time), 0).UTC().Format(time.RFC3339)
+		}
+		games = append(games, indexedGame{BlockNumber: log.BlockNumber, LogIndex: log.Index, Game: game})
+	}
+
+	eventBlocks := make([]indexedBlock, 0, len(blocks))
+	for _, block := range blocks {
+		eventBlocks = append(eventBlocks, block)
+	}
+	tip := indexedBlock{Number: toBlock, Hash: after.Hash(), Time: after.Time}
+	if err := r.index.saveIndexed(ctx, contract, eventBlocks, games, tip); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// rollbackReorg checks that the last synced block is still on the chain. If
+// a reorg replaced it, the index is rolled back to the newest block with
+// events that is still there, or emptied if there is none, and the block it
+// continues from is returned.
+func (r *OnChainRepository) rollbackReorg(ctx context.Context, contract string, tip *indexedBlock) (*indexedBlock, error) {
+	onChain, err := r.isOnChain(ctx, *tip)
+	if err != nil || onChain {
+		return tip, err
+	}
+
+	blocks, err := r.index.indexedBlocks(ctx, contract, tip.Number)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range blocks {
+		onChain, err := r.isOnChain(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		if onChain {
+			return &block, r.index.rollbackIndex(ctx, contract, &block)
+		}
+	}
+	return nil, r.index.rollbackIndex(ctx, contract, nil)
+}
+
+// isOnChain tells whether the block is still the one at its number.
+func (r *OnChainRepository) isOnChain(ctx context.Context, block indexedBlock) (bool, error) {
+	header, err := r.client.HeaderByNumber(ctx, new(big.Int).SetUint64(block.Number))
+	if errors.Is(err, ethereum.NotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get block %d: %w", block.Number, err)
+	}
+	return header.Hash() == block.Hash, nil
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"protofire-game/internal/domain"
+)
+
+const (
+	outboxPollInterval = 5 * time.Second
+	// The wait before the next attempt to store a game doubles from
+	// outboxFirstBackoff after each failed one, up to outboxMaxBackoff.
+	outboxFirstBackoff = 5 * time.Second
+	outboxMaxBackoff   = 5 * time.Minute
+	// maxOutboxWorkers is how many queued games are stored at once. Their
+	// transactions are in flight together, each with its own nonce.
+	maxOutboxWorkers = 16
+	// outboxAttemptTimeout gives up an attempt whose transaction is not
+	// mined. It is waited on again on the next attempt.
+	outboxAttemptTimeout = 10 * time.Minute
+)
+
+// SetOutbox queues the games saved in a SQLite database, instead of storing
+// them on-chain before SaveGame returns. RunOutbox stores them from there,
+// so that the games finished while the node is down are not lost. The
+// outbox is closed with the repository.
+func (r *OnChainRepository) SetOutbox(outbox *SQLiteRepository) {
+	r.outbox = outbox
+	r.outboxWake = make(chan struct{}, 1)
+}
+
+// GetOutboxEntries returns the games queued for the contract, the most
+// recently queued first. Without an outbox, there are none.
+func (r *OnChainRepository) GetOutboxEntries(ctx context.Context) ([]*domain.OutboxEntry, error) {
+	if r.outbox == nil {
+		return []*domain.OutboxEntry{}, nil
+	}
+	return r.outbox.outboxEntries(ctx, r.contractAddr.Hex())
+}
+
+func (r *OnChainRepository) enqueueGame(ctx context.Context, game *domain.Game) error {
+	// A game the contract cannot store is refused now rather than failing
+	// in the background.
+	if _, err := encodeGameResult(game); err != nil {
+		return err
+	}
+	if err := r.outbox.enqueueOutbox(ctx, r.contractAddr.Hex(), game, time.Now()); err != nil {
+		return err
+	}
+
+	select {
+	case r.outboxWake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// RunOutbox stores the queued games on-chain until the context is done,
+// retrying the failed attempts with a growing backoff. A game is failed for
+// good when its transaction reverts. Transactions sent before the worker was
+// stopped are waited on again rather than sent anew, so that no game is
+// stored twice. Without an outbox, games are stored as they are saved and it
+// returns right away.
+func (r *OnChainRepository) RunOutbox(ctx context.Context) error {
+	if r.outbox == nil {
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		inFlight = make(map[string]bool)
+	)
+	defer wg.Wait()
+
+	ticker := time.NewTicker(r.outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		entries, err := r.outbox.dueOutboxEntries(ctx, r.contractAddr.Hex(), time.Now())
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to read outbox: %w", err)
+		}
+
+		mu.Lock()
+		for _, entry := range entries {
+			id := entry.Game.ID
+			if inFlight[id] || len(inFlight) >= maxOutboxWorkers {
+				continue
+			}
+			inFlight[id] = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.storeQueued(ctx, entry)
+
+				mu.Lock()
+				delete(inFlight, id)
+				mu.Unlock()
+				select {
+				case r.outboxWake <- struct{}{}:
+				default:
+				}
+			}()
+		}
+		mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-r.outboxWake:
+		}
+	}
+}
+
+// storeQueued makes an attempt to store a queued game and records how it
+// went. An attempt cut short by the worker stopping is not recorded.
+func (r *OnChainRepository) storeQueued(ctx context.Context, entry *domain.OutboxEntry) {
+	attemptCtx, cancel := context.WithTimeout(ctx, outboxAttemptTimeout)
+	defer cancel()
+
+	id := entry.Game.ID
+	receipt, err := r.sendQueued(attemptCtx, entry.Game)
+	if ctx.Err() != nil {
+		return
+	}
+
+	// The status is recorded even if the worker stops meanwhile. When it
+	// cannot be, the game stays pending and its transactions are waited on
+	// again.
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+	var recordErr error
+	switch {
+	case err == nil:
+		recordErr = r.outbox.finishOutbox(ctx, id, domain.OutboxConfirmed, receipt.TxHash.Hex(), nil, now)
+	case errors.Is(err, ErrTransactionReverted):
+		recordErr = r.outbox.finishOutbox(ctx, id, domain.OutboxFailed, receipt.TxHash.Hex(), err, now)
+	default:
+		if errors.Is(err, ErrTransactionReplaced) {
+			recordErr = r.outbox.clearOutboxTransactions(ctx, id)
+		}
+		backoff := r.outboxBackoff << min(entry.Attempts, 16)
+		if backoff <= 0 || backoff > outboxMaxBackoff {
+			backoff = outboxMaxBackoff
+		}
+		recordErr = errors.Join(recordErr, r.outbox.retryOutbox(ctx, id, err, now.Add(backoff), now))
+	}
+	if recordErr != nil {
+		log.Printf("Failed to record the on-chain attempt of game %s: %v", id, recordErr)
+	}
+}
+
+// sendQueued waits on the transactions already sent for the game, sending
+// the last one again in case the node dropped it, or sends a new one. Every
+// transaction is kept before it is sent.
+func (r *OnChainRepository) sendQueued(ctx context.Context, game *domain.Game) (*types.Receipt, error) {
+	sent, err := r.outbox.outboxTransactions(ctx, game.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sent) > 0 {
+		r.client.SendTransaction(ctx, sent[len(sent)-1])
+	} else {
+		data, err := r.storeGameResultCall(game)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := r.txs.submit(ctx, r.contractAddr, data, func(tx *types.Transaction) error {
+			// Only the transaction signed last is sent.
+			if err := r.outbox.clearOutboxTransactions(ctx, game.ID); err != nil {
+				return err
+			}
+			return r.outbox.addOutboxTransaction(ctx, game.ID, tx)
+		})
+		if err != nil {
+			return nil, err
+		}
+		sent = []*types.Transaction{tx}
+	}
+
+	return r.txs.wait(ctx, sent, func(tx *types.Transaction) error {
+		return r.outbox.addOutboxTransaction(ctx, game.ID, tx)
+	})
+}
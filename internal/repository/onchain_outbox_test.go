@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math/big"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"protofire-game/internal/domain"
+)
+
+// newOutboxRepository returns a repository of the contract reached through
+// the client, queuing its games in the index of the chain.
+func (c *testChain) newOutboxRepository(t *testing.T, client ethereumClient, contract common.Address) *OnChainRepository {
+	repo, err := newOnChainRepository(client, contract, hex.EncodeToString(crypto.FromECDSA(c.key)))
+	require.NoError(t, err)
+	repo.SetIndex(c.index)
+	repo.SetOutbox(c.index)
+	repo.outboxPollInterval = 10 * time.Millisecond
+	repo.outboxBackoff = 10 * time.Millisecond
+	repo.txs.pollInterval = 5 * time.Millisecond
+	return repo
+}
+
+// runOutbox runs the worker of the repository until the returned function is
+// called.
+func runOutbox(t *testing.T, repo *OnChainRepository) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- repo.RunOutbox(ctx)
+	}()
+	return func() {
+		cancel()
+		assert.ErrorIs(t, <-stopped, context.Canceled)
+	}
+}
+
+func outboxEntry(t *testing.T, repo *OnChainRepository, id string) *domain.OutboxEntry {
+	entries, err := repo.GetOutboxEntries(context.Background())
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if entry.Game.ID == id {
+			return entry
+		}
+	}
+	t.Fatalf("game %s is not queued", id)
+	return nil
+}
+
+var errNodeDown = errors.New("connection refused")
+
+// downClient is a node that cannot be reached while down is set.
+type downClient struct {
+	ethereumClient
+	down atomic.Bool
+}
+
+func (c *downClient) ChainID(ctx context.Context) (*big.Int, error) {
+	if c.down.Load() {
+		return nil, errNodeDown
+	}
+	return c.ethereumClient.ChainID(ctx)
+}
+
+func (c *downClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	if c.down.Load() {
+		return 0, errNodeDown
+	}
+	return c.ethereumClient.EstimateGas(ctx, msg)
+}
+
+func TestOutboxRetriesWhileTheNodeIsDown(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+	client := &downClient{ethereumClient: chain.client}
+	client.down.Store(true)
+	repo := chain.newOutboxRepository(t, client, chain.repo.contractAddr)
+
+	game := &domain.Game{ID: "game-1", Player1: "Alice", Player2: "Bob", Winner: "Alice", RuleSet: "rps", PlayedAt: "2025-01-01T10:00:00Z"}
+	require.NoError(t, repo.SaveGame(ctx, game))
+	assert.Equal(t, domain.OutboxPending, outboxEntry(t, repo, game.ID).Status)
+
+	stopMining := chain.mining()
+	defer stopMining()
+	stop := runOutbox(t, repo)
+	defer stop()
+
+	require.Eventually(t, func() bool {
+		return outboxEntry(t, repo, game.ID).Attempts >= 2
+	}, 5*time.Second, 5*time.Millisecond)
+	entry := outboxEntry(t, repo, game.ID)
+	assert.Equal(t, domain.OutboxPending, entry.Status)
+	assert.Contains(t, entry.LastError, errNodeDown.Error())
+	assert.Empty(t, entry.TxHash)
+
+	client.down.Store(false)
+	require.Eventually(t, func() bool {
+		return outboxEntry(t, repo, game.ID).Status == domain.OutboxConfirmed
+	}, 5*time.Second, 5*time.Millisecond)
+
+	page, err := repo.QueryGameHistory(ctx, domain.HistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, page.Games, 1)
+	assert.Equal(t, "Alice", page.Games[0].Winner)
+	receipt, err := chain.backend.Client().TransactionReceipt(ctx, common.HexToHash(outboxEntry(t, repo, game.ID).TxHash))
+	require.NoError(t, err)
+	require.Len(t, receipt.Logs, 1)
+	assert.Equal(t, receipt.Logs[0].Topics[3], common.BigToHash(big.NewInt(0)), "the transaction stored the game")
+}
+
+func TestOutboxLogsAttemptsThatCannotBeRecorded(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+	client := &downClient{ethereumClient: chain.client}
+	client.down.Store(true)
+	repo := chain.newOutboxRepository(t, client, chain.repo.contractAddr)
+
+	game := &domain.Game{ID: "game-1", Player1: "Alice", Player2: "Bob", Winner: "Alice", RuleSet: "rps", PlayedAt: "2025-01-01T10:00:00Z"}
+	require.NoError(t, repo.SaveGame(ctx, game))
+	_, err := chain.index.db.Exec(`CREATE TRIGGER outbox_full BEFORE UPDATE ON outbox BEGIN SELECT RAISE(ABORT, 'disk full'); END`)
+	require.NoError(t, err)
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	repo.storeQueued(ctx, outboxEntry(t, repo, game.ID))
+
+	assert.Contains(t, logged.String(), "game-1")
+	assert.Contains(t, logged.String(), "disk full")
+	assert.Zero(t, outboxEntry(t, repo, game.ID).Attempts)
+}
+
+func TestOutboxWaitsOnTransactionsSentBeforeARestart(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+
+	// The node loses the transaction sent before the worker is stopped.
+	dropping := &droppingClient{ethereumClient: chain.client, drop: 1}
+	repo := chain.newOutboxRepository(t, dropping, chain.repo.contractAddr)
+	game := &domain.Game{ID: "game-1", Player1: "Alice", Player2: "Bob", Winner: "Bob", RuleSet: "rps", PlayedAt: "2025-01-01T10:00:00Z"}
+	require.NoError(t, repo.SaveGame(ctx, game))
+
+	stop := runOutbox(t, repo)
+	require.Eventually(t, func() bool {
+		sent, err := chain.index.outboxTransactions(ctx, game.ID)
+		return err == nil && len(sent) == 1
+	}, 5*time.Second, 5*time.Millisecond)
+	stop()
+	sent, err := chain.index.outboxTransactions(ctx, game.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, outboxEntry(t, repo, game.ID).Attempts, "stopping the worker is not a failed attempt")
+
+	restarted := chain.newOutboxRepository(t, chain.client, chain.repo.contractAddr)
+	stopMining := chain.mining()
+	defer stopMining()
+	stop = runOutbox(t, restarted)
+	defer stop()
+
+	require.Eventually(t, func() bool {
+		return outboxEntry(t, restarted, game.ID).Status == domain.OutboxConfirmed
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, sent[0].Hash().Hex(), outboxEntry(t, restarted, game.ID).TxHash)
+	assert.Equal(t, []string{"Alice-Bob"}, historyPlayers(t, restarted, domain.HistoryQuery{}))
+}
+
+func TestOutboxFailsRevertedGames(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+
+	opts, err := bind.NewKeyedTransactorWithChainID(chain.key, big.NewInt(1337))
+	require.NoError(t, err)
+	constructor := program.New().ReturnViaCodeCopy(onceContractCode()).Bytes()
+	once, _, _, err := bind.DeployContract(opts, abi.ABI{}, constructor, chain.backend.Client())
+	require.NoError(t, err)
+	chain.backend.Commit()
+
+	// Both games are estimated before either is mined, so the second one
+	// only reverts once mined.
+	repo := chain.newOutboxRepository(t, chain.client, once)
+	games := []*domain.Game{
+		{ID: "game-1", Player1: "Alice", Player2: "Bob", Winner: "Alice", RuleSet: "rps", PlayedAt: "2025-01-01T10:00:00Z"},
+		{ID: "game-2", Player1: "Carol", Player2: "Dave", Winner: "Draw", RuleSet: "rps", PlayedAt: "2025-01-01T10:01:00Z"},
+	}
+	for _, game := range games {
+		require.NoError(t, repo.SaveGame(ctx, game))
+	}
+	from := crypto.PubkeyToAddress(chain.key.PublicKey)
+	nonce, err := chain.backend.Client().PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+
+	stop := runOutbox(t, repo)
+	defer stop()
+	require.Eventually(t, func() bool {
+		pending, err := chain.backend.Client().PendingNonceAt(ctx, from)
+		return err == nil && pending == nonce+2
+	}, 5*time.Second, 5*time.Millisecond)
+	chain.backend.Commit()
+
+	require.Eventually(t, func() bool {
+		for _, game := range games {
+			if outboxEntry(t, repo, game.ID).Status == domain.OutboxPending {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 5*time.Millisecond)
+
+	statuses := map[domain.OutboxStatus]*domain.OutboxEntry{}
+	for _, game := range games {
+		entry := outboxEntry(t, repo, game.ID)
+		statuses[entry.Status] = entry
+		assert.NotEmpty(t, entry.TxHash)
+	}
+	require.Contains(t, statuses, domain.OutboxConfirmed)
+	require.Contains(t, statuses, domain.OutboxFailed)
+	assert.Contains(t, statuses[domain.OutboxFailed].LastError, ErrTransactionReverted.Error())
+}
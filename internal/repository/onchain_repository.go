@@ -1,20 +1,23 @@
 package repository
 
 import (
+	"bytes"
 	"context"
-	"crypto/ecdsa"
+	_ "embed"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"bytes"
-
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -23,15 +26,59 @@ import (
 )
 
 const (
-	GasLimit          = 80000
 	maxBlocksPerQuery = 1000
+	// maxGamesPerTransaction keeps a batch of games well under the gas limit
+	// of a block, at about 50000 gas a game.
+	maxGamesPerTransaction = 100
 )
 
+//go:embed abi/protofire-game.json
+var contractABIJSON []byte
+
+// The contracts deployed before games recorded their index, rule set, mode,
+// time and submitter are still read, with the ABI they had.
+//
+//go:embed abi/protofire-game-legacy.json
+var legacyABIJSON []byte
+
+// ethereumClient is the part of the node API the repository uses, served by
+// ethclient.Client and by the simulated backend of the tests.
+type ethereumClient interface {
+	ethereum.BlockNumberReader
+	ethereum.ChainIDReader
+	ethereum.ChainReader
+	ethereum.ChainStateReader
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+	ethereum.GasPricer
+	ethereum.GasPricer1559
+	ethereum.LogFilterer
+	ethereum.PendingStateReader
+	ethereum.TransactionReader
+	ethereum.TransactionSender
+}
+
+// nodeStartTimeout is how long the node may take to answer when the
+// repository is created.
+const nodeStartTimeout = 30 * time.Second
+
 type OnChainRepository struct {
-	client       *ethclient.Client
+	client       ethereumClient
 	abi          abi.ABI
+	legacyABI    abi.ABI
 	contractAddr common.Address
-	signer       string
+	txs          *txManager
+	pollInterval time.Duration
+
+	// index keeps the events already read, see SetIndex.
+	index  *SQLiteRepository
+	syncMu sync.Mutex
+
+	// outbox queues the games saved, see SetOutbox.
+	outbox             *SQLiteRepository
+	outboxWake         chan struct{}
+	outboxPollInterval time.Duration
+	outboxBackoff      time.Duration
 }
 
 func NewOnChainRepository() (*OnChainRepository, error) {
@@ -50,35 +97,80 @@ func NewOnChainRepository() (*OnChainRepository, error) {
 		return nil, fmt.Errorf("SIGNER environment variable is not set")
 	}
 
-	abiFile, err := os.ReadFile("internal/repository/abi/protofire-game.json")
+	client, err := ethclient.Dial(nodeRPC)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read ABI file: %w", err)
+		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
 	}
 
-	contractABI, err := abi.JSON(bytes.NewReader(abiFile))
+	repo, err := newOnChainRepository(client, common.HexToAddress(contractAddr), signer)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	// Reading the signer's nonce on start makes a node that cannot be
+	// reached fail now rather than when the first game is stored.
+	ctx, cancel := context.WithTimeout(context.Background(), nodeStartTimeout)
+	defer cancel()
+	if err := repo.txs.nonces.sync(ctx); err != nil {
+		repo.Close()
+		return nil, fmt.Errorf("failed to reach the node: %w", err)
+	}
+	return repo, nil
+}
+
+func newOnChainRepository(client ethereumClient, contractAddr common.Address, signer string) (*OnChainRepository, error) {
+	contractABI, err := abi.JSON(bytes.NewReader(contractABIJSON))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse ABI: %w", err)
 	}
+	legacyABI, err := abi.JSON(bytes.NewReader(legacyABIJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse legacy ABI: %w", err)
+	}
 
-	client, err := ethclient.Dial(nodeRPC)
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(signer, "0x"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
+		return nil, fmt.Errorf("failed to parse signer key: %w", err)
 	}
 
 	return &OnChainRepository{
 		client:       client,
 		abi:          contractABI,
-		contractAddr: common.HexToAddress(contractAddr),
-		signer:       signer,
+		legacyABI:    legacyABI,
+		contractAddr: contractAddr,
+		txs:          newTxManager(client, key),
+		pollInterval: watchPollInterval,
+
+		outboxPollInterval: outboxPollInterval,
+		outboxBackoff:      outboxFirstBackoff,
 	}, nil
 }
 
-func (r *OnChainRepository) Close() {
-	if r.client != nil {
-		r.client.Close()
+// SetIndex keeps the GameResultStored events in a SQLite database, so that
+// history is only read from the chain from the last block synced. The index
+// is closed with the repository.
+func (r *OnChainRepository) SetIndex(index *SQLiteRepository) {
+	r.index = index
+}
+
+func (r *OnChainRepository) Close() error {
+	if closer, ok := r.client.(interface{ Close() }); ok {
+		closer.Close()
+	}
+	var err error
+	if r.index != nil {
+		err = r.index.Close()
 	}
+	// The outbox is usually kept in the database of the index.
+	if r.outbox != nil && r.outbox != r.index {
+		err = errors.Join(err, r.outbox.Close())
+	}
+	return err
 }
 
+// GetGameResult reads the game at an index of the contract. Games of a legacy
+// contract have no time or submitter.
 func (r *OnChainRepository) GetGameResult(ctx context.Context, index uint64) (*domain.Game, error) {
 	data, err := r.abi.Pack("getGameResult", big.NewInt(int64(index)))
 	if err != nil {
@@ -94,58 +186,354 @@ func (r *OnChainRepository) GetGameResult(ctx context.Context, index uint64) (*d
 	}
 
 	var (
-		player1 [15]byte
-		player2 [15]byte
-		winner  uint8
+		player1   [15]byte
+		player2   [15]byte
+		winner    uint8
+		ruleSet   uint8
+		mode      uint8
+		timestamp uint64
+		submitter common.Address
 	)
 
-	err = r.abi.UnpackIntoInterface(&[]interface{}{&player1, &player2, &winner}, "getGameResult", result)
+	// A legacy contract returns the players and the winner only.
+	outputs := []interface{}{&player1, &player2, &winner, &ruleSet, &mode, &timestamp, &submitter}
+	contractABI := r.abi
+	if len(result) == len(r.legacyABI.Methods["getGameResult"].Outputs)*32 {
+		outputs = outputs[:3]
+		contractABI = r.legacyABI
+	}
+	err = contractABI.UnpackIntoInterface(&outputs, "getGameResult", result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unpack result: %w", err)
 	}
 
-	var winnerName string
-	switch winner {
-	case 0:
-		winnerName = "Draw"
-	case 1:
-		winnerName = string(bytes.TrimRight(player1[:], "\x00"))
-	case 2:
-		winnerName = string(bytes.TrimRight(player2[:], "\x00"))
+	game := &domain.Game{
+		ID:      onChainGameID(index),
+		Player1: string(bytes.TrimRight(player1[:], "\x00")),
+		Player2: string(bytes.TrimRight(player2[:], "\x00")),
+	}
+	if len(outputs) == 3 {
+		decodeLegacyResult(game, winner)
+		return game, nil
 	}
+	game.PlayedAt = time.Unix(int64(timestamp), 0).UTC().Format(time.RFC3339)
+	game.Submitter = submitter.Hex()
+	decodeResult(game, winner, ruleSet, mode)
 
-	return &domain.Game{
-		ID:       fmt.Sprintf("game_%d", index),
-		Player1:  string(bytes.TrimRight(player1[:], "\x00")),
-		Player2:  string(bytes.TrimRight(player2[:], "\x00")),
-		Winner:   winnerName,
-		PlayedAt: time.Now().Format(time.RFC3339Nano),
-	}, nil
+	return game, nil
+}
+
+// onChainGameID is the ID of the game at an index of the contract.
+func onChainGameID(index uint64) string {
+	return fmt.Sprintf("game_%d", index)
+}
+
+// parseOnChainGameID reads the index of a game_<index> ID.
+func parseOnChainGameID(id string) (uint64, bool) {
+	digits, ok := strings.CutPrefix(id, "game_")
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || onChainGameID(index) != id {
+		return 0, false
+	}
+	return index, true
 }
 
-func (r *OnChainRepository) GetGameHistory() ([]*domain.Game, error) {
-	ctx := context.Background()
+// legacyGameID is the ID of the game of a legacy event: its transaction and
+// the index of the log in the block, since a transaction of storeGameResults
+// stores many games.
+func legacyGameID(log types.Log) string {
+	return fmt.Sprintf("%s-%d", log.TxHash.Hex(), log.Index)
+}
+
+// parseLegacyGameID reads the transaction and the log index of the ID of a
+// legacy game.
+func parseLegacyGameID(id string) (common.Hash, uint, bool) {
+	hash, index, ok := strings.Cut(id, "-")
+	if !ok {
+		return common.Hash{}, 0, false
+	}
+	txHash, err := hexutil.Decode(hash)
+	if err != nil || len(txHash) != common.HashLength {
+		return common.Hash{}, 0, false
+	}
+	logIndex, err := strconv.ParseUint(index, 10, 32)
+	if err != nil {
+		return common.Hash{}, 0, false
+	}
+	return common.BytesToHash(txHash), uint(logIndex), true
+}
+
+// gameEventIDs are the topics of the GameResultStored event of the contract
+// and of the legacy one, which are both read.
+func (r *OnChainRepository) gameEventIDs() []common.Hash {
+	return []common.Hash{r.abi.Events["GameResultStored"].ID, r.legacyABI.Events["GameResultStored"].ID}
+}
+
+func (r *OnChainRepository) GetGameHistory(ctx context.Context) ([]*domain.Game, error) {
+	page, err := r.QueryGameHistory(ctx, domain.HistoryQuery{})
+	if err != nil {
+		return nil, err
+	}
+	return page.Games, nil
+}
+
+// GetGame looks a game up by ID, in the index once synced when there is one.
+// Otherwise the event of a game_<index> game is found through its indexed
+// index field, and the one of a legacy game in the receipt of its
+// transaction, so that the history is not read.
+func (r *OnChainRepository) GetGame(ctx context.Context, id string) (*domain.Game, error) {
+	if r.index != nil {
+		if err := r.syncIndex(ctx); err != nil {
+			return nil, err
+		}
+		return r.index.indexedGame(ctx, r.contractAddr.Hex(), id)
+	}
+
+	var (
+		game *domain.Game
+		err  error
+	)
+	if index, ok := parseOnChainGameID(id); ok {
+		game, err = r.findGame(ctx, index)
+	} else if txHash, logIndex, ok := parseLegacyGameID(id); ok {
+		game, err = r.findLegacyGame(ctx, txHash, logIndex)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, fmt.Errorf("game %s %w", id, domain.ErrNotFound)
+	}
+	return game, nil
+}
 
+// findGame returns the game of the event with the index, or nil if there is
+// none. The block of the event is binary searched like the first one of the
+// history.
+func (r *OnChainRepository) findGame(ctx context.Context, index uint64) (*domain.Game, error) {
+	topics := [][]common.Hash{
+		{r.abi.Events["GameResultStored"].ID}, {}, {},
+		{common.BigToHash(new(big.Int).SetUint64(index))},
+	}
 	latestBlock, err := r.client.BlockNumber(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest block: %w", err)
 	}
+	block, err := r.firstEventBlock(ctx, topics, latestBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := r.client.FilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{r.contractAddr},
+		FromBlock: new(big.Int).SetUint64(block),
+		ToBlock:   new(big.Int).SetUint64(block),
+		Topics:    topics,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs of block %d: %w", block, err)
+	}
+	for _, log := range logs {
+		if game, ok := r.decodeGameResult(log); ok {
+			return game, nil
+		}
+	}
+	return nil, nil
+}
+
+// findLegacyGame returns the game of a legacy event, from the receipt of its
+// transaction, or nil if there is none.
+func (r *OnChainRepository) findLegacyGame(ctx context.Context, txHash common.Hash, logIndex uint) (*domain.Game, error) {
+	receipt, err := r.client.TransactionReceipt(ctx, txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt of %s: %w", txHash.Hex(), err)
+	}
+
+	for _, log := range receipt.Logs {
+		if log.Index != logIndex || log.Address != r.contractAddr {
+			continue
+		}
+		game, ok := r.decodeGameResult(*log)
+		if !ok || game.PlayedAt != "" {
+			return nil, nil
+		}
+		header, err := r.client.HeaderByHash(ctx, log.BlockHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block: %w", err)
+		}
+		game.PlayedAt = time.Unix(int64(header.Time), 0).UTC().Format(time.RFC3339)
+		return game, nil
+	}
+	return nil, nil
+}
+
+// QueryGameHistory reads the GameResultStored events. A player filter, or a
+// winner filter other than a draw, only fetches the events of that player,
+// through the topics of the indexed player1 and player2 fields. The other
+// filters are applied to the decoded events. The events of a legacy contract
+// carry no time, so the block timestamps of their games are only fetched for
+// the games of the page unless the query has a date range.
+// With an index, the events are synced into it and queried from there.
+func (r *OnChainRepository) QueryGameHistory(ctx context.Context, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	if r.index != nil {
+		if err := r.syncIndex(ctx); err != nil {
+			return nil, err
+		}
+		return r.index.queryIndexedGames(ctx, r.contractAddr.Hex(), q)
+	}
+
+	eventIDs := r.gameEventIDs()
+
+	player := q.Player
+	if player == "" && q.Winner != "" && q.Winner != "Draw" {
+		player = q.Winner
+	}
+
+	topicSets := [][][]common.Hash{{eventIDs}}
+	if player != "" {
+		topic := playerTopic(player)
+		topicSets = [][][]common.Hash{
+			{eventIDs, {topic}},
+			{eventIDs, {}, {topic}},
+		}
+	}
+
+	// A player playing themselves is found by both filters.
+	seen := make(map[string]bool)
+	var logs []types.Log
+	for _, topics := range topicSets {
+		found, err := r.filterLogs(ctx, topics)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range found {
+			key := fmt.Sprintf("%s/%d", log.TxHash.Hex(), log.Index)
+			if !seen[key] {
+				seen[key] = true
+				logs = append(logs, log)
+			}
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
 
 	// Create a map to store block timestamps to avoid fetching the same block multiple times
 	blockTimestamps := make(map[common.Hash]time.Time)
+	setPlayedAt := func(game *domain.Game, log types.Log) error {
+		if game.PlayedAt != "" {
+			return nil
+		}
+		playedAt, exists := blockTimestamps[log.BlockHash]
+		if !exists {
+			header, err := r.client.HeaderByHash(ctx, log.BlockHash)
+			if err != nil {
+				return fmt.Errorf("failed to get block: %w", err)
+			}
+			playedAt = time.Unix(int64(header.Time), 0).UTC()
+			blockTimestamps[log.BlockHash] = playedAt
+		}
+		game.PlayedAt = playedAt.Format(time.RFC3339)
+		return nil
+	}
+	hasDateRange := !q.From.IsZero() || !q.To.IsZero()
+
+	var games []*domain.Game
+	gameLogs := make(map[*domain.Game]types.Log)
+	for _, log := range logs {
+		game, ok := r.decodeGameResult(log)
+		if !ok {
+			continue
+		}
+		if hasDateRange {
+			if err := setPlayedAt(game, log); err != nil {
+				return nil, err
+			}
+		}
+		if !q.Matches(game) {
+			continue
+		}
+		games = append(games, game)
+		gameLogs[game] = log
+	}
 
-	var results []*domain.Game
+	if q.Order == domain.NewestFirst {
+		for i, j := 0, len(games)-1; i < j; i, j = i+1, j-1 {
+			games[i], games[j] = games[j], games[i]
+		}
+	}
 
-	eventSig := r.abi.Events["GameResultStored"].ID
+	page := q.Page(games)
+	if !hasDateRange {
+		for _, game := range page.Games {
+			if err := setPlayedAt(game, gameLogs[game]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return page, nil
+}
+
+// filterLogs returns the logs of the contract matching the topics, querying
+// maxBlocksPerQuery blocks at a time from the first block with a match.
+func (r *OnChainRepository) filterLogs(ctx context.Context, topics [][]common.Hash) ([]types.Log, error) {
+	latestBlock, err := r.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+
+	firstEventBlock, err := r.firstEventBlock(ctx, topics, latestBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []types.Log
+
+	// Query logs in chunks of maxBlocksPerQuery blocks
+	for fromBlock := firstEventBlock; fromBlock <= latestBlock; fromBlock += maxBlocksPerQuery {
+		toBlock := fromBlock + maxBlocksPerQuery - 1
+		if toBlock > latestBlock {
+			toBlock = latestBlock
+		}
 
-	// Find the first block with an event using binary search
+		query := ethereum.FilterQuery{
+			Addresses: []common.Address{r.contractAddr},
+			FromBlock: big.NewInt(int64(fromBlock)),
+			ToBlock:   big.NewInt(int64(toBlock)),
+			Topics:    topics,
+		}
+
+		logs, err := r.client.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get logs from block %d to %d: %w", fromBlock, toBlock, err)
+		}
+		results = append(results, logs...)
+	}
+
+	return results, nil
+}
+
+// firstEventBlock binary searches the first block with a log matching the
+// topics. Without any, it is 0.
+func (r *OnChainRepository) firstEventBlock(ctx context.Context, topics [][]common.Hash, latestBlock uint64) (uint64, error) {
 	firstEventBlock := uint64(0)
 	left := uint64(0)
 	right := latestBlock
 
 	for left <= right {
 		mid := (left + right) / 2
-		startBlock := mid
+		// Near the start of the chain, the window reaches back to block 0,
+		// so that no block before mid is left out.
+		startBlock := uint64(0)
 		if mid > maxBlocksPerQuery {
 			startBlock = mid - maxBlocksPerQuery
 		}
@@ -154,187 +542,273 @@ func (r *OnChainRepository) GetGameHistory() ([]*domain.Game, error) {
 			Addresses: []common.Address{r.contractAddr},
 			FromBlock: big.NewInt(int64(startBlock)),
 			ToBlock:   big.NewInt(int64(mid)),
-			Topics:    [][]common.Hash{{eventSig}},
+			Topics:    topics,
 		}
 
 		logs, err := r.client.FilterLogs(ctx, query)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get logs: %w", err)
+			return 0, fmt.Errorf("failed to get logs: %w", err)
 		}
 
 		if len(logs) > 0 {
 			firstEventBlock = logs[0].BlockNumber
+			if firstEventBlock == 0 {
+				break
+			}
 			right = firstEventBlock - 1
 		} else {
 			left = mid + 1
 		}
 	}
 
-	// Query logs in chunks of maxBlocksPerQuery blocks
-	for fromBlock := firstEventBlock; fromBlock <= latestBlock; fromBlock += maxBlocksPerQuery {
-		toBlock := fromBlock + maxBlocksPerQuery - 1
-		if toBlock > latestBlock {
-			toBlock = latestBlock
-		}
+	return firstEventBlock, nil
+}
 
-		query := ethereum.FilterQuery{
-			Addresses: []common.Address{r.contractAddr},
-			FromBlock: big.NewInt(int64(fromBlock)),
-			ToBlock:   big.NewInt(int64(toBlock)),
-			Topics:    [][]common.Hash{{eventSig}},
-		}
+// decodeGameResult reads the game of a GameResultStored event. The game is
+// named after its index in the contract and played at the time it was
+// stored. Events of a legacy contract have no index or time: their game is
+// named after its log, and the time of its block is left for the caller to
+// fetch.
+func (r *OnChainRepository) decodeGameResult(log types.Log) (*domain.Game, bool) {
+	// Extract indexed parameters from topics
+	// Topics[0] is the event signature
+	// Topics[1] is player1 (indexed)
+	// Topics[2] is player2 (indexed)
+	// Topics[3] is the index of the game (indexed), but in legacy events
+	if len(log.Topics) < 3 {
+		return nil, false
+	}
 
-		logs, err := r.client.FilterLogs(ctx, query)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get logs from block %d to %d: %w", fromBlock, toBlock, err)
-		}
+	player1Bytes := log.Topics[1].Bytes()
+	player2Bytes := log.Topics[2].Bytes()
 
-		for _, log := range logs {
-			event := struct {
-				Winner uint8
-			}{}
+	result := &domain.Game{
+		Player1: string(bytes.TrimRight(player1Bytes[:15], "\x00")),
+		Player2: string(bytes.TrimRight(player2Bytes[:15], "\x00")),
+	}
 
-			err = r.abi.UnpackIntoInterface(&event, "GameResultStored", log.Data)
-			if err != nil {
-				continue
-			}
+	switch {
+	case log.Topics[0] == r.abi.Events["GameResultStored"].ID && len(log.Topics) == 4:
+		event := struct {
+			Winner    uint8
+			RuleSet   uint8
+			Mode      uint8
+			Submitter common.Address
+			Timestamp uint64
+		}{}
+		if err := r.abi.UnpackIntoInterface(&event, "GameResultStored", log.Data); err != nil {
+			return nil, false
+		}
+		result.ID = onChainGameID(log.Topics[3].Big().Uint64())
+		result.PlayedAt = time.Unix(int64(event.Timestamp), 0).UTC().Format(time.RFC3339)
+		result.Submitter = event.Submitter.Hex()
+		decodeResult(result, event.Winner, event.RuleSet, event.Mode)
+	case log.Topics[0] == r.legacyABI.Events["GameResultStored"].ID && len(log.Topics) == 3:
+		event := struct {
+			Winner uint8
+		}{}
+		if err := r.legacyABI.UnpackIntoInterface(&event, "GameResultStored", log.Data); err != nil {
+			return nil, false
+		}
+		result.ID = legacyGameID(log)
+		decodeLegacyResult(result, event.Winner)
+	default:
+		return nil, false
+	}
+	return result, true
+}
 
-			// Extract indexed parameters from topics
-			// Topics[0] is the event signature
-			// Topics[1] is player1 (indexed)
-			// Topics[2] is player2 (indexed)
-			if len(log.Topics) != 3 {
-				continue
-			}
+// playerTopic is the topic of an indexed bytes15 player name: the name left
+// aligned in 32 bytes.
+func playerTopic(player string) common.Hash {
+	var name [15]byte
+	copy(name[:], player)
 
-			player1Bytes := log.Topics[1].Bytes()
-			player2Bytes := log.Topics[2].Bytes()
-
-			// Get time from the block timestamp where the event was emitted(from cache if available)
-			playedAt, exists := blockTimestamps[log.BlockHash]
-			if !exists {
-				block, err := r.client.BlockByHash(ctx, log.BlockHash)
-				if err != nil {
-					return nil, fmt.Errorf("failed to get block: %w", err)
-				}
-				playedAt = time.Unix(int64(block.Time()), 0)
-				blockTimestamps[log.BlockHash] = playedAt
-			}
+	var topic common.Hash
+	copy(topic[:], name[:])
+	return topic
+}
 
-			result := &domain.Game{
-				ID:       log.TxHash.Hex(),
-				Player1:  string(bytes.TrimRight(player1Bytes[:15], "\x00")),
-				Player2:  string(bytes.TrimRight(player2Bytes[:15], "\x00")),
-				PlayedAt: playedAt.Format(time.RFC3339Nano),
-			}
+// SaveGame stores the game on-chain, or queues it when an outbox is set.
+func (r *OnChainRepository) SaveGame(ctx context.Context, result *domain.Game) error {
+	if r.outbox != nil {
+		return r.enqueueGame(ctx, result)
+	}
 
-			switch event.Winner {
-			case 0:
-				result.Winner = "Draw"
-			case 1:
-				result.Winner = result.Player1
-			case 2:
-				result.Winner = result.Player2
-			}
+	data, err := r.storeGameResultCall(result)
+	if err != nil {
+		return err
+	}
+	_, err = r.txs.send(ctx, r.contractAddr, data)
+	return err
+}
 
-			results = append(results, result)
+// SaveGames stores the games with storeGameResults, maxGamesPerTransaction
+// games a transaction, one transaction after the other. Unlike SaveGame it
+// does not queue them in the outbox, so the caller learns whether they were
+// stored. When a transaction fails, the games of the ones before are stored.
+func (r *OnChainRepository) SaveGames(ctx context.Context, results []*domain.Game) error {
+	for start := 0; start < len(results); start += maxGamesPerTransaction {
+		end := min(start+maxGamesPerTransaction, len(results))
+		data, err := r.storeGameResultsCall(results[start:end])
+		if err != nil {
+			return err
+		}
+		if _, err := r.txs.send(ctx, r.contractAddr, data); err != nil {
+			return fmt.Errorf("failed to store games %d to %d: %w", start+1, end, err)
 		}
 	}
-
-	return results, nil
+	return nil
 }
 
-func (r *OnChainRepository) SaveGame(result *domain.Game) error {
-	ctx := context.Background()
+// gameResult is the GameResult struct of the contract.
+type gameResult struct {
+	Player1 [15]byte
+	Player2 [15]byte
+	Winner  uint8
+	RuleSet uint8
+	Mode    uint8
+}
 
-	var player1Bytes [15]byte
-	var player2Bytes [15]byte
-	copy(player1Bytes[:], []byte(result.Player1))
-	copy(player2Bytes[:], []byte(result.Player2))
+func encodeGameResult(game *domain.Game) (gameResult, error) {
+	var result gameResult
+	copy(result.Player1[:], []byte(game.Player1))
+	copy(result.Player2[:], []byte(game.Player2))
 
-	var winnerNum uint8
-	switch result.Winner {
+	switch game.Winner {
 	case "Draw":
-		winnerNum = 0
-	case result.Player1:
-		winnerNum = 1
-	case result.Player2:
-		winnerNum = 2
+		result.Winner = 0
+	case game.Player1:
+		result.Winner = 1
+	case game.Player2:
+		result.Winner = 2
 	default:
-		return fmt.Errorf("invalid winner value")
+		return gameResult{}, fmt.Errorf("invalid winner value")
 	}
 
-	return r.StoreGameResult(ctx, player1Bytes, player2Bytes, winnerNum)
+	ruleSet := game.RuleSet
+	if ruleSet == "" {
+		ruleSet = domain.DefaultRuleSet.Name
+	}
+	code, ok := ruleSetCodes[ruleSet]
+	if !ok {
+		return gameResult{}, fmt.Errorf("rule set %q cannot be stored on-chain", ruleSet)
+	}
+	result.RuleSet = code
+
+	mode, ok := modeCodes[game.Mode]
+	if !ok {
+		return gameResult{}, fmt.Errorf("mode %v cannot be stored on-chain", game.Mode)
+	}
+	result.Mode = mode
+	return result, nil
 }
 
-func (r *OnChainRepository) StoreGameResult(ctx context.Context, player1, player2 [15]byte, winner uint8) error {
-	data, err := r.abi.Pack("storeGameResult", player1, player2, winner)
+// storeGameResultCall packs the call of storeGameResult storing the game.
+func (r *OnChainRepository) storeGameResultCall(game *domain.Game) ([]byte, error) {
+	result, err := encodeGameResult(game)
 	if err != nil {
-		return fmt.Errorf("failed to pack data: %w", err)
+		return nil, err
 	}
 
-	chainID, err := r.client.ChainID(ctx)
+	data, err := r.abi.Pack("storeGameResult", result.Player1, result.Player2, result.Winner, result.RuleSet, result.Mode)
 	if err != nil {
-		return fmt.Errorf("failed to get chain ID: %w", err)
+		return nil, fmt.Errorf("failed to pack data: %w", err)
+	}
+	return data, nil
+}
+
+// storeGameResultsCall packs the call of storeGameResults storing the games.
+func (r *OnChainRepository) storeGameResultsCall(games []*domain.Game) ([]byte, error) {
+	results := make([]gameResult, len(games))
+	for i, game := range games {
+		result, err := encodeGameResult(game)
+		if err != nil {
+			return nil, fmt.Errorf("game %s: %w", game.ID, err)
+		}
+		results[i] = result
 	}
 
-	privateKeyECDSA, err := crypto.HexToECDSA(strings.TrimPrefix(r.signer, "0x"))
+	data, err := r.abi.Pack("storeGameResults", results)
 	if err != nil {
-		return fmt.Errorf("failed to parse signer key: %w", err)
+		return nil, fmt.Errorf("failed to pack data: %w", err)
 	}
+	return data, nil
+}
 
-	publicKey := privateKeyECDSA.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return fmt.Errorf("error casting public key to ECDSA")
+// The contract keeps the winner of a game as 0 for a draw, or the player who
+// won, 1 or 2, next to the codes of its rule set and mode.
+var ruleSetCodes = map[string]uint8{
+	domain.RockPaperScissors.Name:            0,
+	domain.RockPaperScissorsLizardSpock.Name: 1,
+}
+
+var modeCodes = map[domain.GameType]uint8{
+	domain.PlayerVsPlayer: 0,
+	domain.PlayerVsBot:    1,
+}
+
+func decodeResult(result *domain.Game, winner, ruleSet, mode uint8) {
+	switch winner {
+	case 0:
+		result.Winner = "Draw"
+	case 1:
+		result.Winner = result.Player1
+	case 2:
+		result.Winner = result.Player2
 	}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := r.client.PendingNonceAt(ctx, fromAddress)
-	if err != nil {
-		return fmt.Errorf("failed to get nonce: %w", err)
+	for gameType, code := range modeCodes {
+		if code == mode {
+			result.Mode = gameType
+		}
 	}
 
-	gasPrice, err := r.client.SuggestGasPrice(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get gas price: %w", err)
+	for name, code := range ruleSetCodes {
+		if code == ruleSet {
+			result.RuleSet = name
+		}
 	}
+}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKeyECDSA, chainID)
-	if err != nil {
-		return fmt.Errorf("failed to create transactor: %w", err)
-	}
-
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)
-	auth.GasLimit = GasLimit
-	auth.GasPrice = gasPrice
-
-	tx := types.NewTx(&types.DynamicFeeTx{
-		ChainID:   chainID,
-		Nonce:     nonce,
-		To:        &r.contractAddr,
-		Value:     big.NewInt(0),
-		Gas:       GasLimit,
-		GasFeeCap: gasPrice,
-		GasTipCap: big.NewInt(1),
-		Data:      data,
-	})
+// decodeLegacyResult reads the game of a legacy contract, which only keeps
+// the winner: its games were played with rps between players.
+func decodeLegacyResult(result *domain.Game, winner uint8) {
+	decodeResult(result, winner, ruleSetCodes[domain.RockPaperScissors.Name], modeCodes[domain.PlayerVsPlayer])
+}
 
-	signedTx, err := types.SignTx(tx, types.NewLondonSigner(chainID), privateKeyECDSA)
+func (r *OnChainRepository) StoreGameResult(ctx context.Context, player1, player2 [15]byte, winner, ruleSet, mode uint8) error {
+	data, err := r.abi.Pack("storeGameResult", player1, player2, winner, ruleSet, mode)
 	if err != nil {
-		return fmt.Errorf("failed to sign transaction: %w", err)
+		return fmt.Errorf("failed to pack data: %w", err)
 	}
 
-	err = r.client.SendTransaction(ctx, signedTx)
+	_, err = r.txs.send(ctx, r.contractAddr, data)
+	return err
+}
+
+// GetLeaderboard aggregates the GameResultStored events since the contract
+// only stores individual results.
+func (r *OnChainRepository) GetLeaderboard(ctx context.Context, limit int) ([]*domain.PlayerStats, error) {
+	games, err := r.GetGameHistory(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to send transaction: %w", err)
+		return nil, err
 	}
+	return domain.ComputeLeaderboard(games, limit), nil
+}
 
-	_, err = bind.WaitMined(ctx, r.client, signedTx)
+func (r *OnChainRepository) GetPlayerStats(ctx context.Context, player string) (*domain.PlayerStats, error) {
+	games, err := r.GetGameHistory(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to wait for transaction to be mined: %w", err)
+		return nil, err
 	}
+	return domain.ComputePlayerStats(games, player), nil
+}
 
-	return nil
+func (r *OnChainRepository) GetHeadToHead(ctx context.Context, player string) ([]*domain.HeadToHead, error) {
+	games, err := r.GetGameHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.ComputeHeadToHead(games, player), nil
 }
@@ -0,0 +1,445 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"protofire-game/internal/domain"
+)
+
+// testContractRuntime is the runtime code of a contract built by forge, which
+// make generate/bytecode writes to testdata: protofire-game for the contract
+// deployed now, and protofire-game-v1 for the one first deployed.
+func testContractRuntime(t *testing.T, name string) []byte {
+	runtime, err := os.ReadFile(filepath.Join("testdata", name+".bin"))
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("testdata/%s.bin is missing, run make generate/bytecode", name)
+	}
+	require.NoError(t, err)
+	code, err := hexutil.Decode(strings.TrimSpace(string(runtime)))
+	require.NoError(t, err)
+	return code
+}
+
+// recordingClient keeps the log queries sent to the node, and tells when a
+// subscription is asked for.
+type recordingClient struct {
+	ethereumClient
+	queries    []ethereum.FilterQuery
+	subscribed chan struct{}
+	// httpOnly refuses subscriptions, as a node reached over HTTP does.
+	httpOnly bool
+}
+
+func (c *recordingClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.queries = append(c.queries, q)
+	return c.ethereumClient.FilterLogs(ctx, q)
+}
+
+func (c *recordingClient) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, logs chan<- types.Log) (ethereum.Subscription, error) {
+	defer close(c.subscribed)
+	if c.httpOnly {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	return c.ethereumClient.SubscribeFilterLogs(ctx, q, logs)
+}
+
+type testChain struct {
+	backend  *simulated.Backend
+	client   *recordingClient
+	repo     *OnChainRepository
+	index    *SQLiteRepository
+	contract *bind.BoundContract
+	key      *ecdsa.PrivateKey
+}
+
+// newTestChain deploys the contract on a simulated chain, which only mines a
+// block on Commit.
+func newTestChain(t *testing.T) *testChain {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		from: {Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)},
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	contractABI, err := abi.JSON(bytes.NewReader(contractABIJSON))
+	require.NoError(t, err)
+	constructor := program.New().ReturnViaCodeCopy(testContractRuntime(t, "protofire-game")).Bytes()
+
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	require.NoError(t, err)
+	address, _, contract, err := bind.DeployContract(opts, contractABI, constructor, backend.Client())
+	require.NoError(t, err)
+	backend.Commit()
+
+	client := &recordingClient{ethereumClient: backend.Client(), subscribed: make(chan struct{})}
+	repo, err := newOnChainRepository(client, address, hex.EncodeToString(crypto.FromECDSA(key)))
+	require.NoError(t, err)
+	index := newTestSQLiteRepository(t)
+	repo.SetIndex(index)
+
+	return &testChain{
+		backend:  backend,
+		client:   client,
+		repo:     repo,
+		index:    index,
+		contract: contract,
+		key:      key,
+	}
+}
+
+// storeGame sends the result of a game, to be mined on the next Commit. The
+// transaction takes the nonce and the tip given, when not nil.
+func (c *testChain) storeGame(t *testing.T, game *domain.Game, nonce, tip *big.Int) {
+	opts, err := bind.NewKeyedTransactorWithChainID(c.key, big.NewInt(1337))
+	require.NoError(t, err)
+	opts.Nonce = nonce
+	if tip != nil {
+		opts.GasTipCap = tip
+		opts.GasFeeCap = new(big.Int).Mul(tip, big.NewInt(10))
+	}
+
+	result, err := encodeGameResult(game)
+	require.NoError(t, err)
+
+	_, err = c.contract.Transact(opts, "storeGameResult", result.Player1, result.Player2, result.Winner, result.RuleSet, result.Mode)
+	require.NoError(t, err)
+}
+
+func historyPlayers(t *testing.T, repo *OnChainRepository, q domain.HistoryQuery) []string {
+	page, err := repo.QueryGameHistory(context.Background(), q)
+	require.NoError(t, err)
+	players := []string{}
+	for _, game := range page.Games {
+		players = append(players, game.Player1+"-"+game.Player2)
+	}
+	return players
+}
+
+func TestOnChainIndexSyncsFromLastBlock(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+
+	chain.storeGame(t, &domain.Game{Player1: "Alice", Player2: "Bob", Winner: "Alice", Mode: domain.PlayerVsBot}, nil, nil)
+	chain.backend.Commit()
+
+	games, err := chain.repo.GetGameHistory(ctx)
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+	assert.Equal(t, "Alice", games[0].Winner)
+	assert.Equal(t, domain.PlayerVsBot, games[0].Mode)
+	assert.Equal(t, "rps", games[0].RuleSet)
+	assert.NotEmpty(t, games[0].ID)
+	header, err := chain.backend.Client().HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(int64(header.Time), 0).UTC().Format(time.RFC3339), games[0].PlayedAt)
+
+	tip, err := chain.index.indexTip(ctx, chain.repo.contractAddr.Hex())
+	require.NoError(t, err)
+	require.NotNil(t, tip)
+	assert.Equal(t, header.Number.Uint64(), tip.Number)
+
+	chain.storeGame(t, &domain.Game{Player1: "Carol", Player2: "Dave", Winner: "Draw"}, nil, nil)
+	chain.backend.Commit()
+	chain.backend.Commit()
+
+	chain.client.queries = nil
+	assert.Equal(t, []string{"Carol-Dave", "Alice-Bob"}, historyPlayers(t, chain.repo, domain.HistoryQuery{}))
+	require.NotEmpty(t, chain.client.queries)
+	for _, q := range chain.client.queries {
+		assert.Greater(t, q.FromBlock.Uint64(), tip.Number, "blocks already synced are read again")
+	}
+
+	chain.client.queries = nil
+	assert.Equal(t, []string{"Alice-Bob"}, historyPlayers(t, chain.repo, domain.HistoryQuery{Player: "Alice"}))
+	assert.Equal(t, []string{"Alice-Bob", "Carol-Dave"}, historyPlayers(t, chain.repo, domain.HistoryQuery{Order: domain.OldestFirst}))
+	assert.Empty(t, chain.client.queries, "no block was mined since the last sync")
+}
+
+func TestOnChainIndexRollsBackReorgs(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+
+	chain.storeGame(t, &domain.Game{Player1: "Alice", Player2: "Bob", Winner: "Bob"}, nil, nil)
+	chain.backend.Commit()
+	parent, err := chain.backend.Client().HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+
+	nonce, err := chain.backend.Client().PendingNonceAt(ctx, crypto.PubkeyToAddress(chain.key.PublicKey))
+	require.NoError(t, err)
+
+	chain.storeGame(t, &domain.Game{Player1: "Carol", Player2: "Dave", Winner: "Carol"}, big.NewInt(int64(nonce)), nil)
+	chain.backend.Commit()
+	assert.Equal(t, []string{"Carol-Dave", "Alice-Bob"}, historyPlayers(t, chain.repo, domain.HistoryQuery{}))
+
+	// A longer chain from the parent replaces the game of Carol and Dave,
+	// whose transaction is outbid by one with the same nonce.
+	require.NoError(t, chain.backend.Fork(parent.Hash()))
+	chain.storeGame(t, &domain.Game{Player1: "Erin", Player2: "Frank", Winner: "Frank"}, big.NewInt(int64(nonce)), big.NewInt(100_000_000_000))
+	chain.backend.Commit()
+	chain.backend.Commit()
+
+	assert.Equal(t, []string{"Erin-Frank", "Alice-Bob"}, historyPlayers(t, chain.repo, domain.HistoryQuery{}))
+	assert.Equal(t, []string{}, historyPlayers(t, chain.repo, domain.HistoryQuery{Player: "Carol"}))
+}
+
+func TestOnChainWatchGames(t *testing.T) {
+	tests := []struct {
+		name     string
+		httpOnly bool
+	}{
+		{"subscription", false},
+		{"polling", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newTestChain(t)
+			chain.client.httpOnly = tt.httpOnly
+			chain.repo.pollInterval = 10 * time.Millisecond
+
+			chain.storeGame(t, &domain.Game{Player1: "Alice", Player2: "Bob", Winner: "Alice"}, nil, nil)
+			chain.backend.Commit()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			games := make(chan *domain.Game, 10)
+			watched := make(chan error, 1)
+			go func() {
+				watched <- chain.repo.WatchGames(ctx, func(game *domain.Game) { games <- game })
+			}()
+			<-chain.client.subscribed
+
+			chain.storeGame(t, &domain.Game{Player1: "Carol", Player2: "Dave", Winner: "Dave"}, nil, nil)
+			chain.backend.Commit()
+
+			select {
+			case game := <-games:
+				assert.Equal(t, "Carol", game.Player1)
+				assert.Equal(t, "Dave", game.Winner)
+				assert.NotEmpty(t, game.PlayedAt)
+			case <-time.After(5 * time.Second):
+				t.Fatal("the new game was not watched")
+			}
+
+			cancel()
+			assert.ErrorIs(t, <-watched, context.Canceled)
+			assert.Empty(t, games, "games stored before the watch are not watched")
+		})
+	}
+}
+
+// gasUsed returns the gas and the number of transactions of the blocks mined
+// while save runs.
+func (c *testChain) gasUsed(t *testing.T, save func()) (gas uint64, txs int) {
+	ctx := context.Background()
+	from, err := c.backend.Client().BlockNumber(ctx)
+	require.NoError(t, err)
+	save()
+	to, err := c.backend.Client().BlockNumber(ctx)
+	require.NoError(t, err)
+
+	for number := from + 1; number <= to; number++ {
+		block, err := c.backend.Client().BlockByNumber(ctx, new(big.Int).SetUint64(number))
+		require.NoError(t, err)
+		gas += block.GasUsed()
+		txs += len(block.Transactions())
+	}
+	return gas, txs
+}
+
+func TestOnChainSaveGamesInOneTransaction(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+	chain.repo.txs.pollInterval = 5 * time.Millisecond
+
+	var games []*domain.Game
+	var players []string
+	for i := range 10 {
+		player := fmt.Sprintf("Player%d", i)
+		games = append(games, &domain.Game{Player1: player, Player2: "Bot", Winner: "Bot", Mode: domain.PlayerVsBot})
+		players = append(players, player+"-Bot")
+	}
+
+	stop := chain.mining()
+	singleGas, singleTxs := chain.gasUsed(t, func() {
+		for _, game := range games {
+			require.NoError(t, chain.repo.SaveGame(ctx, game))
+		}
+	})
+	batchGas, batchTxs := chain.gasUsed(t, func() {
+		require.NoError(t, chain.repo.SaveGames(ctx, games))
+	})
+	stop()
+
+	t.Logf("%d games with storeGameResult: %d gas in %d transactions", len(games), singleGas, singleTxs)
+	t.Logf("%d games with storeGameResults: %d gas in %d transaction, %.0f%% less", len(games), batchGas, batchTxs,
+		100-100*float64(batchGas)/float64(singleGas))
+	assert.Equal(t, len(games), singleTxs)
+	assert.Equal(t, 1, batchTxs)
+	assert.Less(t, batchGas, singleGas)
+
+	stored := historyPlayers(t, chain.repo, domain.HistoryQuery{Order: domain.OldestFirst})
+	assert.Equal(t, append(players, players...), stored)
+	page, err := chain.repo.QueryGameHistory(ctx, domain.HistoryQuery{Winner: "Bot"})
+	require.NoError(t, err)
+	assert.Equal(t, 2*len(games), page.Total)
+	assert.Equal(t, domain.PlayerVsBot, page.Games[0].Mode)
+}
+
+func TestOnChainGamesRecordTimeAndSubmitter(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+	submitter := crypto.PubkeyToAddress(chain.key.PublicKey).Hex()
+
+	chain.storeGame(t, &domain.Game{Player1: "Alice", Player2: "Bob", Winner: "Alice"}, nil, nil)
+	chain.backend.Commit()
+	chain.storeGame(t, &domain.Game{Player1: "Carol", Player2: "Dave", Winner: "Dave", Mode: domain.PlayerVsBot, RuleSet: domain.RockPaperScissorsLizardSpock.Name}, nil, nil)
+	chain.backend.Commit()
+	header, err := chain.backend.Client().HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	playedAt := time.Unix(int64(header.Time), 0).UTC().Format(time.RFC3339)
+
+	game, err := chain.repo.GetGameResult(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.Game{
+		ID:        "game_1",
+		Player1:   "Carol",
+		Player2:   "Dave",
+		Winner:    "Dave",
+		Mode:      domain.PlayerVsBot,
+		RuleSet:   domain.RockPaperScissorsLizardSpock.Name,
+		PlayedAt:  playedAt,
+		Submitter: submitter,
+	}, game)
+
+	unindexed, err := newOnChainRepository(chain.client, chain.repo.contractAddr, hex.EncodeToString(crypto.FromECDSA(chain.key)))
+	require.NoError(t, err)
+	for name, repo := range map[string]*OnChainRepository{"index": chain.repo, "events": unindexed} {
+		t.Run(name, func(t *testing.T) {
+			games, err := repo.GetGameHistory(ctx)
+			require.NoError(t, err)
+			require.Len(t, games, 2)
+			assert.Equal(t, game, games[0])
+			assert.Equal(t, "game_0", games[1].ID)
+			assert.Equal(t, submitter, games[1].Submitter)
+			assert.NotEqual(t, playedAt, games[1].PlayedAt)
+		})
+	}
+}
+
+func TestOnChainGetGame(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+
+	for _, players := range [][2]string{{"Alice", "Bob"}, {"Carol", "Dave"}, {"Erin", "Frank"}} {
+		chain.storeGame(t, &domain.Game{Player1: players[0], Player2: players[1], Winner: players[1]}, nil, nil)
+		chain.backend.Commit()
+	}
+	history, err := chain.repo.GetGameHistory(ctx)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	unindexed, err := newOnChainRepository(chain.client, chain.repo.contractAddr, hex.EncodeToString(crypto.FromECDSA(chain.key)))
+	require.NoError(t, err)
+	for name, repo := range map[string]*OnChainRepository{"index": chain.repo, "events": unindexed} {
+		t.Run(name, func(t *testing.T) {
+			chain.client.queries = nil
+			game, err := repo.GetGame(ctx, "game_1")
+			require.NoError(t, err)
+			assert.Equal(t, history[1], game)
+			for _, q := range chain.client.queries {
+				assert.Len(t, q.Topics, 4, "only the events of the game are read")
+			}
+
+			for _, id := range []string{"game_3", "game_01", "unknown", "0x12-0"} {
+				_, err := repo.GetGame(ctx, id)
+				assert.ErrorIs(t, err, domain.ErrNotFound, id)
+			}
+		})
+	}
+}
+
+func TestOnChainReadsLegacyContract(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+
+	opts, err := bind.NewKeyedTransactorWithChainID(chain.key, big.NewInt(1337))
+	require.NoError(t, err)
+	constructor := program.New().ReturnViaCodeCopy(testContractRuntime(t, "protofire-game-v1")).Bytes()
+	address, _, legacy, err := bind.DeployContract(opts, chain.repo.legacyABI, constructor, chain.backend.Client())
+	require.NoError(t, err)
+	chain.backend.Commit()
+
+	var txs []*types.Transaction
+	for _, players := range [][2]string{{"Alice", "Bob"}, {"Carol", "Dave"}} {
+		var player1, player2 [15]byte
+		copy(player1[:], players[0])
+		copy(player2[:], players[1])
+		tx, err := legacy.Transact(opts, "storeGameResult", player1, player2, uint8(2))
+		require.NoError(t, err)
+		chain.backend.Commit()
+		txs = append(txs, tx)
+	}
+	header, err := chain.backend.Client().HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+
+	indexed, err := newOnChainRepository(chain.client, address, hex.EncodeToString(crypto.FromECDSA(chain.key)))
+	require.NoError(t, err)
+	indexed.SetIndex(chain.index)
+	unindexed, err := newOnChainRepository(chain.client, address, hex.EncodeToString(crypto.FromECDSA(chain.key)))
+	require.NoError(t, err)
+
+	for name, repo := range map[string]*OnChainRepository{"index": indexed, "events": unindexed} {
+		t.Run(name, func(t *testing.T) {
+			page, err := repo.QueryGameHistory(ctx, domain.HistoryQuery{Order: domain.OldestFirst})
+			require.NoError(t, err)
+			require.Len(t, page.Games, 2)
+			assert.Equal(t, fmt.Sprintf("%s-%d", txs[0].Hash().Hex(), 0), page.Games[0].ID)
+			assert.Equal(t, "Bob", page.Games[0].Winner)
+			assert.Empty(t, page.Games[0].Submitter)
+			assert.Equal(t, domain.PlayerVsPlayer, page.Games[0].Mode)
+			assert.Equal(t, domain.RockPaperScissors.Name, page.Games[0].RuleSet)
+			assert.Equal(t, fmt.Sprintf("%s-%d", txs[1].Hash().Hex(), 0), page.Games[1].ID)
+			assert.Equal(t, "Dave", page.Games[1].Winner)
+			assert.Equal(t, time.Unix(int64(header.Time), 0).UTC().Format(time.RFC3339), page.Games[1].PlayedAt)
+			for _, game := range page.Games {
+				found, err := repo.GetGame(ctx, game.ID)
+				require.NoError(t, err)
+				assert.Equal(t, game, found)
+			}
+		})
+	}
+
+	game, err := unindexed.GetGameResult(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "game_0", game.ID)
+	assert.Equal(t, "Alice", game.Player1)
+	assert.Equal(t, "Bob", game.Winner)
+	assert.Empty(t, game.PlayedAt, "the legacy contract does not keep the time")
+	assert.Empty(t, game.Submitter)
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"protofire-game/internal/domain"
+)
+
+// watchPollInterval is how often new blocks are checked for games when the
+// node cannot push them, as over HTTP.
+const watchPollInterval = 4 * time.Second
+
+// WatchGames follows the GameResultStored events emitted from now on, by any
+// client of the contract. The events are pushed by the node with
+// eth_subscribe over WebSocket; over HTTP, new blocks are polled for them.
+// Games of blocks later replaced by a reorg are not taken back.
+func (r *OnChainRepository) WatchGames(ctx context.Context, onGame func(*domain.Game)) error {
+	latestBlock, err := r.client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %w", err)
+	}
+
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{r.contractAddr},
+		Topics:    [][]common.Hash{r.gameEventIDs()},
+	}
+	logs := make(chan types.Log)
+	sub, err := r.client.SubscribeFilterLogs(ctx, query, logs)
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return r.pollGames(ctx, query, latestBlock+1, onGame)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to game results: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-sub.Err():
+			return fmt.Errorf("game results subscription failed: %w", err)
+		case log := <-logs:
+			if log.Removed {
+				continue
+			}
+			if err := r.watchedGame(ctx, log, onGame); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// pollGames reads the events of the blocks mined since the last poll, from
+// fromBlock on.
+func (r *OnChainRepository) pollGames(ctx context.Context, query ethereum.FilterQuery, fromBlock uint64, onGame func(*domain.Game)) error {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		latestBlock, err := r.client.BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get latest block: %w", err)
+		}
+
+		for fromBlock <= latestBlock {
+			toBlock := min(fromBlock+maxBlocksPerQuery-1, latestBlock)
+			query.FromBlock = new(big.Int).SetUint64(fromBlock)
+			query.ToBlock = new(big.Int).SetUint64(toBlock)
+
+			logs, err := r.client.FilterLogs(ctx, query)
+			if err != nil {
+				return fmt.Errorf("failed to get logs from block %d to %d: %w", fromBlock, toBlock, err)
+			}
+			for _, log := range logs {
+				if err := r.watchedGame(ctx, log, onGame); err != nil {
+					return err
+				}
+			}
+			fromBlock = toBlock + 1
+		}
+	}
+}
+
+// watchedGame reports the game of an event. The game of a legacy event is
+// given the time of its block.
+func (r *OnChainRepository) watchedGame(ctx context.Context, log types.Log, onGame func(*domain.Game)) error {
+	game, ok := r.decodeGameResult(log)
+	if !ok {
+		return nil
+	}
+
+	if game.PlayedAt == "" {
+		header, err := r.client.HeaderByHash(ctx, log.BlockHash)
+		if err != nil {
+			return fmt.Errorf("failed to get block: %w", err)
+		}
+		game.PlayedAt = time.Unix(int64(header.Time), 0).UTC().Format(time.RFC3339)
+	}
+	onGame(game)
+	return nil
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"protofire-game/internal/domain"
+)
+
+// indexedBlock is a block of the chain as the index last saw it.
+type indexedBlock struct {
+	Number uint64
+	Hash   common.Hash
+	Time   uint64
+}
+
+// indexedGame is a game decoded from a GameResultStored event.
+type indexedGame struct {
+	BlockNumber uint64
+	LogIndex    uint
+	Game        *domain.Game
+}
+
+// indexTip returns the last block synced for the contract, or nil if it was
+// never synced.
+func (r *SQLiteRepository) indexTip(ctx context.Context, contract string) (*indexedBlock, error) {
+	var tip indexedBlock
+	var hash string
+	err := r.db.QueryRowContext(ctx, `
+	SELECT block_number, block_hash
+	FROM onchain_sync
+	WHERE contract = ?`, contract).Scan(&tip.Number, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting synced block: %w", err)
+	}
+	tip.Hash = common.HexToHash(hash)
+	return &tip, nil
+}
+
+// indexedBlocks returns the blocks with events below a block number, the
+// newest first.
+func (r *SQLiteRepository) indexedBlocks(ctx context.Context, contract string, below uint64) ([]indexedBlock, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT number, hash, timestamp
+	FROM onchain_blocks
+	WHERE contract = ? AND number < ?
+	ORDER BY number DESC`, contract, below)
+	if err != nil {
+		return nil, fmt.Errorf("error querying indexed blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []indexedBlock
+	for rows.Next() {
+		var block indexedBlock
+		var hash string
+		if err := rows.Scan(&block.Number, &hash, &block.Time); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		block.Hash = common.HexToHash(hash)
+		blocks = append(blocks, block)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return blocks, nil
+}
+
+// saveIndexed stores the games found up to a block along with their blocks,
+// and moves the synced block to it.
+func (r *SQLiteRepository) saveIndexed(ctx context.Context, contract string, blocks []indexedBlock, games []indexedGame, tip indexedBlock) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, block := range blocks {
+		_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO onchain_blocks (contract, number, hash, timestamp)
+		VALUES (?, ?, ?, ?)`, contract, block.Number, block.Hash.Hex(), block.Time)
+		if err != nil {
+			return fmt.Errorf("error saving block %d: %w", block.Number, err)
+		}
+	}
+
+	for _, indexed := range games {
+		game := indexed.Game
+		_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO onchain_games (contract, block_number, log_index, id, player1, player2, winner, mode, rule_set, played_at, submitter)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			contract, indexed.BlockNumber, indexed.LogIndex, game.ID, game.Player1, game.Player2, game.Winner, game.Mode, game.RuleSet, game.PlayedAt, game.Submitter)
+		if err != nil {
+			return fmt.Errorf("error saving game %s: %w", game.ID, err)
+		}
+	}
+
+	if err := setIndexTip(ctx, tx, contract, tip); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rollbackIndex drops the blocks after the ancestor, which were replaced by a
+// reorg, and their games. Without an ancestor the whole index of the contract
+// is dropped.
+func (r *SQLiteRepository) rollbackIndex(ctx context.Context, contract string, ancestor *indexedBlock) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Block numbers are never negative, so -1 keeps none.
+	keep := int64(-1)
+	if ancestor != nil {
+		keep = int64(ancestor.Number)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM onchain_games WHERE contract = ? AND block_number > ?`, contract, keep); err != nil {
+		return fmt.Errorf("error rolling back games: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM onchain_blocks WHERE contract = ? AND number > ?`, contract, keep); err != nil {
+		return fmt.Errorf("error rolling back blocks: %w", err)
+	}
+
+	if ancestor == nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM onchain_sync WHERE contract = ?`, contract); err != nil {
+			return fmt.Errorf("error rolling back synced block: %w", err)
+		}
+	} else if err := setIndexTip(ctx, tx, contract, *ancestor); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func setIndexTip(ctx context.Context, tx *sql.Tx, contract string, tip indexedBlock) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO onchain_sync (contract, block_number, block_hash)
+	VALUES (?, ?, ?)
+	ON CONFLICT(contract) DO UPDATE SET
+		block_number = excluded.block_number,
+		block_hash = excluded.block_hash`, contract, tip.Number, tip.Hash.Hex())
+	if err != nil {
+		return fmt.Errorf("error saving synced block: %w", err)
+	}
+	return nil
+}
+
+// queryIndexedGames filters, sorts and pages the indexed games of the
+// contract like QueryGameHistory does with the local games. Games keep the
+// order of their events.
+func (r *SQLiteRepository) queryIndexedGames(ctx context.Context, contract string, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	conditions, args := historyConditions(q)
+	conditions = append([]string{"contract = ?"}, conditions...)
+	args = append([]interface{}{contract}, args...)
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	page := &domain.HistoryPage{Games: []*domain.Game{}}
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM onchain_games "+where, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("error counting games: %w", err)
+	}
+
+	order := "block_number DESC, log_index DESC"
+	if q.Order == domain.OldestFirst {
+		order = "block_number, log_index"
+	}
+	limit := -1
+	if q.Limit > 0 {
+		limit = q.Limit
+	}
+
+	query := fmt.Sprintf(`
+	%s
+	ORDER BY %s
+	LIMIT ? OFFSET ?`, where, order)
+
+	games, err := r.queryIndexed(ctx, query, append(args, limit, q.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	page.Games = games
+	return page, nil
+}
+
+// indexedGame returns the indexed game of the contract with the ID.
+func (r *SQLiteRepository) indexedGame(ctx context.Context, contract, id string) (*domain.Game, error) {
+	games, err := r.queryIndexed(ctx, `
+	WHERE contract = ? AND id = ?`, contract, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, fmt.Errorf("game %s %w", id, domain.ErrNotFound)
+	}
+	return games[0], nil
+}
+
+// queryIndexed loads the indexed games selected by the clauses following
+// FROM.
+func (r *SQLiteRepository) queryIndexed(ctx context.Context, clauses string, args ...interface{}) ([]*domain.Game, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT id, player1, player2, winner, mode, rule_set, played_at, submitter
+	FROM onchain_games`+clauses, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying indexed games: %w", err)
+	}
+	defer rows.Close()
+
+	games := []*domain.Game{}
+	for rows.Next() {
+		var game domain.Game
+		err := rows.Scan(&game.ID, &game.Player1, &game.Player2, &game.Winner, &game.Mode, &game.RuleSet, &game.PlayedAt, &game.Submitter)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		games = append(games, &game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return games, nil
+}
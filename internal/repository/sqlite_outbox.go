@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"protofire-game/internal/domain"
+)
+
+// enqueueOutbox queues a game to be stored on the contract. A game already
+// queued is left as it is.
+func (r *SQLiteRepository) enqueueOutbox(ctx context.Context, contract string, game *domain.Game, now time.Time) error {
+	queuedAt := now.UTC().Format(time.RFC3339)
+	_, err := r.db.ExecContext(ctx, `
+	INSERT OR IGNORE INTO outbox (game_id, contract, player1, player2, winner, mode, rule_set, played_at, status, next_attempt_at, queued_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		game.ID, contract, game.Player1, game.Player2, game.Winner, game.Mode, game.RuleSet, game.PlayedAt,
+		domain.OutboxPending, now.UnixMilli(), queuedAt, queuedAt)
+	if err != nil {
+		return fmt.Errorf("error queuing game %s: %w", game.ID, err)
+	}
+	return nil
+}
+
+// outboxEntries returns the games queued for the contract, the most recently
+// queued first.
+func (r *SQLiteRepository) outboxEntries(ctx context.Context, contract string) ([]*domain.OutboxEntry, error) {
+	return r.queryOutbox(ctx, `
+	WHERE contract = ?
+	ORDER BY queued_at DESC, rowid DESC`, contract)
+}
+
+// dueOutboxEntries returns the pending games of the contract whose next
+// attempt is due, the first queued first.
+func (r *SQLiteRepository) dueOutboxEntries(ctx context.Context, contract string, now time.Time) ([]*domain.OutboxEntry, error) {
+	return r.queryOutbox(ctx, `
+	WHERE contract = ? AND status = ? AND next_attempt_at <= ?
+	ORDER BY queued_at, rowid`, contract, domain.OutboxPending, now.UnixMilli())
+}
+
+func (r *SQLiteRepository) queryOutbox(ctx context.Context, where string, args ...interface{}) ([]*domain.OutboxEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT game_id, player1, player2, winner, mode, rule_set, played_at, status, tx_hash, attempts, last_error, queued_at, updated_at
+	FROM outbox`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying outbox: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*domain.OutboxEntry{}
+	for rows.Next() {
+		var game domain.Game
+		entry := &domain.OutboxEntry{Game: &game}
+		err := rows.Scan(&game.ID, &game.Player1, &game.Player2, &game.Winner, &game.Mode, &game.RuleSet, &game.PlayedAt,
+			&entry.Status, &entry.TxHash, &entry.Attempts, &entry.LastError, &entry.QueuedAt, &entry.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return entries, nil
+}
+
+// outboxTransactions returns the transactions sent for a queued game, in the
+// order they were sent.
+func (r *SQLiteRepository) outboxTransactions(ctx context.Context, gameID string) ([]*types.Transaction, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT raw
+	FROM outbox_transactions
+	WHERE game_id = ?
+	ORDER BY seq`, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying outbox transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []*types.Transaction
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("error decoding transaction of game %s: %w", gameID, err)
+		}
+		txs = append(txs, tx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return txs, nil
+}
+
+// addOutboxTransaction keeps a transaction sent for a queued game, after the
+// ones sent before it.
+func (r *SQLiteRepository) addOutboxTransaction(ctx context.Context, gameID string, tx *types.Transaction) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("error encoding transaction: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `
+	INSERT INTO outbox_transactions (game_id, seq, raw)
+	SELECT ?, COALESCE(MAX(seq), 0) + 1, ?
+	FROM outbox_transactions
+	WHERE game_id = ?`, gameID, raw, gameID)
+	if err != nil {
+		return fmt.Errorf("error saving transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	return nil
+}
+
+// clearOutboxTransactions forgets the transactions sent for a queued game,
+// once none of them can be mined anymore.
+func (r *SQLiteRepository) clearOutboxTransactions(ctx context.Context, gameID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM outbox_transactions WHERE game_id = ?`, gameID); err != nil {
+		return fmt.Errorf("error clearing outbox transactions: %w", err)
+	}
+	return nil
+}
+
+// retryOutbox records a failed attempt to store a queued game, and when to
+// try again.
+func (r *SQLiteRepository) retryOutbox(ctx context.Context, gameID string, attemptErr error, next, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+	UPDATE outbox
+	SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?, updated_at = ?
+	WHERE game_id = ?`, attemptErr.Error(), next.UnixMilli(), now.UTC().Format(time.RFC3339), gameID)
+	if err != nil {
+		return fmt.Errorf("error saving attempt for game %s: %w", gameID, err)
+	}
+	return nil
+}
+
+// finishOutbox sets the final status of a queued game, with the transaction
+// mined for it, and drops the transactions sent.
+func (r *SQLiteRepository) finishOutbox(ctx context.Context, gameID string, status domain.OutboxStatus, txHash string, attemptErr error, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lastError := ""
+	if attemptErr != nil {
+		lastError = attemptErr.Error()
+	}
+	_, err = tx.ExecContext(ctx, `
+	UPDATE outbox
+	SET status = ?, tx_hash = ?, attempts = attempts + 1, last_error = ?, updated_at = ?
+	WHERE game_id = ?`, status, txHash, lastError, now.UTC().Format(time.RFC3339), gameID)
+	if err != nil {
+		return fmt.Errorf("error saving status of game %s: %w", gameID, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM outbox_transactions WHERE game_id = ?`, gameID); err != nil {
+		return fmt.Errorf("error clearing outbox transactions: %w", err)
+	}
+	return tx.Commit()
+}
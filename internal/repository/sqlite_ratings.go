@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"protofire-game/internal/domain"
+)
+
+func (r *SQLiteRepository) GetRatings(system string) ([]*domain.Rating, error) {
+	query := `
+	SELECT player, rating, deviation, volatility, games
+	FROM ratings
+	WHERE system = ?
+	ORDER BY rating DESC, player`
+
+	rows, err := r.db.Query(query, system)
+	if err != nil {
+		return nil, fmt.Errorf("error querying ratings: %w", err)
+	}
+	defer rows.Close()
+
+	var ratings []*domain.Rating
+	for rows.Next() {
+		var rating domain.Rating
+		err := rows.Scan(
+			&rating.Player,
+			&rating.Rating,
+			&rating.Deviation,
+			&rating.Volatility,
+			&rating.Games,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		ratings = append(ratings, &rating)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return ratings, nil
+}
+
+func (r *SQLiteRepository) GetRating(system, player string) (*domain.Rating, error) {
+	query := `
+	SELECT player, rating, deviation, volatility, games
+	FROM ratings
+	WHERE system = ? AND player = ?`
+
+	var rating domain.Rating
+	err := r.db.QueryRow(query, system, player).Scan(
+		&rating.Player,
+		&rating.Rating,
+		&rating.Deviation,
+		&rating.Volatility,
+		&rating.Games,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying rating: %w", err)
+	}
+
+	return &rating, nil
+}
+
+func (r *SQLiteRepository) SaveRatings(system string, ratings []*domain.Rating) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO ratings (system, player, rating, deviation, volatility, games)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT (system, player) DO UPDATE SET
+		rating = excluded.rating,
+		deviation = excluded.deviation,
+		volatility = excluded.volatility,
+		games = excluded.games`
+
+	for _, rating := range ratings {
+		_, err := tx.Exec(query,
+			system,
+			rating.Player,
+			rating.Rating,
+			rating.Deviation,
+			rating.Volatility,
+			rating.Games,
+		)
+		if err != nil {
+			return fmt.Errorf("error saving rating: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing ratings: %w", err)
+	}
+
+	return nil
+}
@@ -1,8 +1,11 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"protofire-game/internal/domain"
 
@@ -14,7 +17,10 @@ type SQLiteRepository struct {
 }
 
 func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	// SQLite leaves foreign keys off unless each connection turns them on,
+	// which the driver does for every connection of the pool, so that the
+	// rows of a game go with it.
+	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on")
 	if err != nil {
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
@@ -23,69 +29,158 @@ func NewSQLiteRepository(dbPath string) (*SQLiteRepository, error) {
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
 
-	if err := createTables(db); err != nil {
-		return nil, fmt.Errorf("error creating tables: %w", err)
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("error loading migrations: %w", err)
+	}
+	if err := migrate(db, migrations); err != nil {
+		return nil, fmt.Errorf("error migrating database: %w", err)
 	}
 
 	return &SQLiteRepository{db: db}, nil
 }
 
-func createTables(db *sql.DB) error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS game_results (
-			id TEXT PRIMARY KEY,
-			player1 TEXT NOT NULL,
-			player2 TEXT NOT NULL,
-			winner TEXT NOT NULL,
-			played_at DATETIME NOT NULL
-		)`,
+func (r *SQLiteRepository) SaveGame(ctx context.Context, result *domain.Game) error {
+	// Dates are compared as text by the history filters and ordering, so
+	// they are all stored in UTC.
+	playedAt, err := time.Parse(time.RFC3339, result.PlayedAt)
+	if err != nil {
+		return fmt.Errorf("invalid date %q: %w", result.PlayedAt, err)
 	}
 
-	for _, query := range queries {
-		if _, err := db.Exec(query); err != nil {
-			return err
-		}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	return nil
-}
-
-func (r *SQLiteRepository) SaveGame(result *domain.Game) error {
 	query := `
-	INSERT INTO game_results (id, player1, player2, winner, played_at)
-	VALUES (?, ?, ?, ?, ?)`
+	INSERT INTO game_results (id, player1, player2, winner, mode, rule_set, difficulty, seed, played_at, forfeit)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// SQLite integers are signed, so seeds are stored with their bits as is.
+	var seed sql.NullInt64
+	if result.Seed != nil {
+		seed = sql.NullInt64{Int64: int64(*result.Seed), Valid: true}
+	}
 
-	_, err := r.db.Exec(query,
+	_, err = tx.ExecContext(ctx, query,
 		result.ID,
 		result.Player1,
 		result.Player2,
 		result.Winner,
-		result.PlayedAt,
+		result.Mode,
+		result.RuleSet,
+		result.Difficulty,
+		seed,
+		playedAt.UTC().Format(time.RFC3339),
+		result.Forfeit,
 	)
 
 	if err != nil {
 		return fmt.Errorf("error saving game: %w", err)
 	}
 
+	roundQuery := `
+	INSERT INTO rounds (game_id, round_number, move1, move2, winner, commitment, salt)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	for i, round := range result.Rounds {
+		_, err = tx.ExecContext(ctx, roundQuery,
+			result.ID,
+			i+1,
+			round.Move1,
+			round.Move2,
+			round.Winner,
+			round.Commitment,
+			round.Salt,
+		)
+		if err != nil {
+			return fmt.Errorf("error saving round %d: %w", i+1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing game: %w", err)
+	}
+
 	return nil
 }
 
-func (r *SQLiteRepository) GetGameHistory() ([]*domain.Game, error) {
-	query := `
-	SELECT id, player1, player2, winner, played_at
-	FROM game_results
-	ORDER BY played_at DESC`
+func (r *SQLiteRepository) GetGameHistory(ctx context.Context) ([]*domain.Game, error) {
+	page, err := r.QueryGameHistory(ctx, domain.HistoryQuery{})
+	if err != nil {
+		return nil, err
+	}
+	return page.Games, nil
+}
 
-	rows, err := r.db.Query(query)
+// QueryGameHistory filters, sorts and pages the games in SQL, loading the
+// rounds of the games in the page only. Dates are stored in UTC, so they are
+// compared as text.
+func (r *SQLiteRepository) QueryGameHistory(ctx context.Context, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	conditions, args := historyConditions(q)
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	page := &domain.HistoryPage{Games: []*domain.Game{}}
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM game_results "+where, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("error counting games: %w", err)
+	}
+
+	// Games played in the same second keep the order they were stored in.
+	order := "played_at DESC, rowid DESC"
+	if q.Order == domain.OldestFirst {
+		order = "played_at, rowid"
+	}
+	limit := -1
+	if q.Limit > 0 {
+		limit = q.Limit
+	}
+
+	query := fmt.Sprintf(`
+	%s
+	ORDER BY %s
+	LIMIT ? OFFSET ?`, where, order)
+
+	games, err := r.queryGames(ctx, query, append(args, limit, q.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	page.Games = games
+	return page, nil
+}
+
+// GetGame looks the game up by its primary key, with its rounds.
+func (r *SQLiteRepository) GetGame(ctx context.Context, id string) (*domain.Game, error) {
+	games, err := r.queryGames(ctx, `
+	WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, fmt.Errorf("game %s %w", id, domain.ErrNotFound)
+	}
+	return games[0], nil
+}
+
+// queryGames loads the games selected by the clauses following FROM, and
+// their rounds.
+func (r *SQLiteRepository) queryGames(ctx context.Context, clauses string, args ...interface{}) ([]*domain.Game, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT id, player1, player2, winner, mode, rule_set, difficulty, seed, played_at, forfeit
+	FROM game_results`+clauses, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error querying game history: %w", err)
 	}
 	defer rows.Close()
 
-	var results []*domain.Game
-
+	games := []*domain.Game{}
 	for rows.Next() {
 		var result domain.Game
+		var seed sql.NullInt64
 		var playedAt string
 
 		err := rows.Scan(
@@ -93,21 +188,124 @@ func (r *SQLiteRepository) GetGameHistory() ([]*domain.Game, error) {
 			&result.Player1,
 			&result.Player2,
 			&result.Winner,
+			&result.Mode,
+			&result.RuleSet,
+			&result.Difficulty,
+			&seed,
 			&playedAt,
+			&result.Forfeit,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
 
+		if seed.Valid {
+			value := uint64(seed.Int64)
+			result.Seed = &value
+		}
+
 		result.PlayedAt = playedAt
-		results = append(results, &result)
+		games = append(games, &result)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return results, nil
+	if err := r.loadRounds(ctx, games); err != nil {
+		return nil, err
+	}
+
+	return games, nil
+}
+
+// historyConditions are the SQL conditions of the filters of a history query,
+// on the columns shared by game_results and onchain_games.
+func historyConditions(q domain.HistoryQuery) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if q.Player != "" {
+		conditions = append(conditions, "(player1 = ? OR player2 = ?)")
+		args = append(args, q.Player, q.Player)
+	}
+	if q.Winner != "" {
+		conditions = append(conditions, "winner = ?")
+		args = append(args, q.Winner)
+	}
+	if !q.From.IsZero() {
+		conditions = append(conditions, "played_at >= ?")
+		args = append(args, q.From.UTC().Format(time.RFC3339))
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "played_at < ?")
+		args = append(args, q.To.UTC().Format(time.RFC3339))
+	}
+	if q.Mode != nil {
+		conditions = append(conditions, "mode = ?")
+		args = append(args, *q.Mode)
+	}
+	return conditions, args
+}
+
+// maxRoundsQueryGames keeps the number of bound parameters of a single rounds
+// query below SQLite's limit.
+const maxRoundsQueryGames = 500
+
+func (r *SQLiteRepository) loadRounds(ctx context.Context, games []*domain.Game) error {
+	for start := 0; start < len(games); start += maxRoundsQueryGames {
+		end := start + maxRoundsQueryGames
+		if end > len(games) {
+			end = len(games)
+		}
+		batch := games[start:end]
+
+		gamesByID := make(map[string]*domain.Game, len(batch))
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, len(batch))
+		for i, game := range batch {
+			gamesByID[game.ID] = game
+			placeholders[i] = "?"
+			args[i] = game.ID
+		}
+
+		query := fmt.Sprintf(`
+		SELECT game_id, move1, move2, winner, commitment, salt
+		FROM rounds
+		WHERE game_id IN (%s)
+		ORDER BY game_id, round_number`, strings.Join(placeholders, ", "))
+
+		rows, err := r.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("error querying rounds: %w", err)
+		}
+
+		for rows.Next() {
+			var gameID string
+			var round domain.RoundResult
+			err := rows.Scan(
+				&gameID,
+				&round.Move1,
+				&round.Move2,
+				&round.Winner,
+				&round.Commitment,
+				&round.Salt,
+			)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("error scanning round: %w", err)
+			}
+			game := gamesByID[gameID]
+			game.Rounds = append(game.Rounds, round)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("error iterating rounds: %w", err)
+		}
+	}
+
+	return nil
 }
 
 func (r *SQLiteRepository) Close() error {
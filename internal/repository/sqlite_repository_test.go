@@ -0,0 +1,438 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"protofire-game/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLiteRepository(t *testing.T) *SQLiteRepository {
+	repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "protofire-game.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestSQLiteSaveGameWithRounds(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	game := &domain.Game{
+		ID:       "game1",
+		Player1:  "Alice",
+		Player2:  "Bob",
+		Winner:   "Alice",
+		RuleSet:  domain.RockPaperScissorsLizardSpock.Name,
+		PlayedAt: "2025-01-01T10:00:00Z",
+		Rounds: []domain.RoundResult{
+			{Move1: domain.Rock, Move2: domain.Paper, Winner: "Bob", Commitment: "c0ffee", Salt: "5a17"},
+			{Move1: domain.Spock, Move2: domain.Scissors, Winner: "Alice"},
+			{Move1: domain.Lizard, Move2: domain.Paper, Winner: "Alice"},
+		},
+	}
+	require.NoError(t, repo.SaveGame(context.Background(), game))
+	require.NoError(t, repo.SaveGame(context.Background(), &domain.Game{
+		ID:       "game2",
+		Player1:  "Carol",
+		Player2:  "Dave",
+		Winner:   "Draw",
+		RuleSet:  domain.RockPaperScissors.Name,
+		PlayedAt: "2025-01-02T10:00:00Z",
+	}))
+
+	history, err := repo.GetGameHistory(context.Background())
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	assert.Equal(t, "game2", history[0].ID)
+	assert.Empty(t, history[0].Rounds)
+
+	assert.Equal(t, game, history[1])
+}
+
+func TestSQLiteSaveGameWithSeed(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	seed := uint64(1<<63 + 12345)
+	game := &domain.Game{
+		ID:         "game1",
+		Player1:    "Alice",
+		Player2:    "Bot",
+		Winner:     "Bot",
+		Mode:       domain.PlayerVsBot,
+		RuleSet:    domain.RockPaperScissors.Name,
+		Difficulty: domain.Hard,
+		Seed:       &seed,
+		PlayedAt:   "2025-01-01T10:00:00Z",
+	}
+	require.NoError(t, repo.SaveGame(context.Background(), game))
+
+	history, err := repo.GetGameHistory(context.Background())
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, game, history[0])
+}
+
+func TestSQLiteSaveGameDuplicateRollsBackRounds(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	game := &domain.Game{
+		ID:       "game1",
+		Player1:  "Alice",
+		Player2:  "Bob",
+		Winner:   "Alice",
+		RuleSet:  domain.RockPaperScissors.Name,
+		PlayedAt: "2025-01-01T10:00:00Z",
+		Rounds: []domain.RoundResult{
+			{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Alice"},
+			{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Alice"},
+		},
+	}
+	require.NoError(t, repo.SaveGame(context.Background(), game))
+	assert.Error(t, repo.SaveGame(context.Background(), game))
+
+	history, err := repo.GetGameHistory(context.Background())
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Len(t, history[0].Rounds, 2)
+}
+
+func TestSQLiteGetGame(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	game := &domain.Game{
+		ID:       "game1",
+		Player1:  "Alice",
+		Player2:  "Bob",
+		Winner:   "Bob",
+		RuleSet:  domain.RockPaperScissors.Name,
+		PlayedAt: "2025-01-01T10:00:00Z",
+		Rounds:   []domain.RoundResult{{Move1: domain.Rock, Move2: domain.Paper, Winner: "Bob"}},
+	}
+	require.NoError(t, repo.SaveGame(context.Background(), game))
+	require.NoError(t, repo.SaveGame(context.Background(), &domain.Game{
+		ID: "game2", Player1: "Carol", Player2: "Dave", Winner: "Draw", RuleSet: domain.RockPaperScissors.Name, PlayedAt: "2025-01-01T11:00:00Z",
+	}))
+
+	found, err := repo.GetGame(context.Background(), "game1")
+	require.NoError(t, err)
+	assert.Equal(t, game, found)
+
+	_, err = repo.GetGame(context.Background(), "unknown")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestSQLiteRoundsAreDeletedWithTheirGame(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	game := &domain.Game{
+		ID:       "game1",
+		Player1:  "Alice",
+		Player2:  "Bob",
+		Winner:   "Alice",
+		PlayedAt: "2025-01-01T10:00:00Z",
+		Rounds:   []domain.RoundResult{{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Alice"}},
+	}
+	require.NoError(t, repo.SaveGame(context.Background(), game))
+
+	_, err := repo.db.Exec(`DELETE FROM game_results WHERE id = ?`, game.ID)
+	require.NoError(t, err)
+	var rounds int
+	require.NoError(t, repo.db.QueryRow(`SELECT COUNT(*) FROM rounds`).Scan(&rounds))
+	assert.Zero(t, rounds)
+
+	_, err = repo.db.Exec(`INSERT INTO rounds (game_id, round_number, move1, move2, winner) VALUES ('unknown', 1, 0, 0, 'Draw')`)
+	assert.Error(t, err, "rounds of an unknown game are refused")
+}
+
+func TestSQLiteSaveGameCanceled(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	game := &domain.Game{ID: "game1", Player1: "Alice", Player2: "Bob", Winner: "Alice", PlayedAt: "2025-01-01T10:00:00Z"}
+	assert.ErrorIs(t, repo.SaveGame(ctx, game), context.Canceled)
+
+	history, err := repo.GetGameHistory(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestSQLiteSaveGameStoresDatesInUTC(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	for id, playedAt := range map[string]string{
+		"offset":   "2025-01-01T12:00:00+02:00",
+		"fraction": "2025-01-01T11:00:00.5Z",
+	} {
+		require.NoError(t, repo.SaveGame(context.Background(), &domain.Game{
+			ID: id, Player1: "Alice", Player2: "Bob", Winner: "Alice", PlayedAt: playedAt,
+		}))
+	}
+	err := repo.SaveGame(context.Background(), &domain.Game{
+		ID: "invalid", Player1: "Alice", Player2: "Bob", Winner: "Alice", PlayedAt: "yesterday",
+	})
+	assert.ErrorContains(t, err, "invalid date")
+
+	// The game stored with an offset was played first, at 10:00 UTC
+	page, err := repo.QueryGameHistory(context.Background(), domain.HistoryQuery{
+		From: time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Games, 1)
+	assert.Equal(t, "fraction", page.Games[0].ID)
+	assert.Equal(t, "2025-01-01T11:00:00Z", page.Games[0].PlayedAt)
+
+	game, err := repo.GetGame(context.Background(), "offset")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01-01T10:00:00Z", game.PlayedAt)
+}
+
+func TestSQLiteStatsMatchInMemoryAggregation(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	games := []*domain.Game{
+		{ID: "1", Player1: "Alice", Player2: "Bob", Winner: "Alice", PlayedAt: "2025-01-01T10:00:00Z",
+			Rounds: []domain.RoundResult{
+				{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Alice"},
+				{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Alice"},
+			}},
+		{ID: "2", Player1: "Bob", Player2: "Alice", Winner: "Alice", PlayedAt: "2025-01-02T10:00:00Z",
+			Rounds: []domain.RoundResult{
+				{Move1: domain.Scissors, Move2: domain.Paper, Winner: "Bob"},
+				{Move1: domain.Scissors, Move2: domain.Rock, Winner: "Alice"},
+				{Move1: domain.Paper, Move2: domain.Scissors, Winner: "Alice"},
+			}},
+		{ID: "3", Player1: "Alice", Player2: "Carol", Winner: "Carol", PlayedAt: "2025-01-03T10:00:00Z"},
+		{ID: "4", Player1: "Alice", Player2: "Bob", Winner: "Draw", PlayedAt: "2025-01-04T10:00:00Z"},
+		{ID: "5", Player1: "Carol", Player2: "Bob", Winner: "Carol", PlayedAt: "2025-01-05T10:00:00Z"},
+		{ID: "6", Player1: "Alice", Player2: "Carol", Winner: "Alice", PlayedAt: "2025-01-06T10:00:00Z"},
+		{ID: "7", Player1: "Dave", Player2: "Carol", Winner: "Carol", PlayedAt: "2025-01-07T10:00:00Z"},
+	}
+	for _, game := range games {
+		require.NoError(t, repo.SaveGame(context.Background(), game))
+	}
+
+	leaderboard, err := repo.GetLeaderboard(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ComputeLeaderboard(games, 0), leaderboard)
+
+	leaderboard, err = repo.GetLeaderboard(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ComputeLeaderboard(games, 2), leaderboard)
+
+	for _, player := range []string{"Alice", "Bob", "Carol", "Dave", "Nobody"} {
+		stats, err := repo.GetPlayerStats(context.Background(), player)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ComputePlayerStats(games, player), stats, player)
+
+		headToHead, err := repo.GetHeadToHead(context.Background(), player)
+		require.NoError(t, err)
+		if expected := domain.ComputeHeadToHead(games, player); len(expected) > 0 {
+			assert.Equal(t, expected, headToHead, player)
+		} else {
+			assert.Empty(t, headToHead, player)
+		}
+	}
+}
+
+func TestSQLiteRatings(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	rating, err := repo.GetRating("elo", "Alice")
+	require.NoError(t, err)
+	assert.Nil(t, rating)
+
+	require.NoError(t, repo.SaveRatings("elo", []*domain.Rating{
+		{Player: "Alice", Rating: 1516, Games: 1},
+		{Player: "Bob", Rating: 1484, Games: 1},
+	}))
+	require.NoError(t, repo.SaveRatings("glicko2", []*domain.Rating{
+		{Player: "Alice", Rating: 1662, Deviation: 290, Volatility: 0.06, Games: 1},
+	}))
+	require.NoError(t, repo.SaveRatings("elo", []*domain.Rating{
+		{Player: "Bob", Rating: 1500, Games: 2},
+	}))
+
+	ratings, err := repo.GetRatings("elo")
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.Rating{
+		{Player: "Alice", Rating: 1516, Games: 1},
+		{Player: "Bob", Rating: 1500, Games: 2},
+	}, ratings)
+
+	rating, err = repo.GetRating("glicko2", "Alice")
+	require.NoError(t, err)
+	assert.Equal(t, &domain.Rating{Player: "Alice", Rating: 1662, Deviation: 290, Volatility: 0.06, Games: 1}, rating)
+}
+
+func TestSQLiteTournaments(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	missing, err := repo.GetTournament("missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	tournament, err := domain.NewTournament("t1", "Office cup", domain.DoubleElimination, domain.MatchFormat{Type: domain.FirstTo, Rounds: 2},
+		domain.RockPaperScissorsLizardSpock, []string{"Alice", "Bob", "Carol"}, 0, "2025-01-01T10:00:00Z")
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveTournament(tournament))
+
+	// Playing a match pairs the next round, and both are stored
+	pending := tournament.Pending()
+	require.Len(t, pending, 1)
+	require.NoError(t, tournament.Record(pending[0], "Carol", "game1"))
+	require.NoError(t, repo.SaveTournament(tournament))
+
+	loaded, err := repo.GetTournament("t1")
+	require.NoError(t, err)
+	assert.Equal(t, tournament, loaded)
+	assert.Len(t, loaded.Matches, 3)
+
+	other, err := domain.NewTournament("t2", "Swiss open", domain.Swiss, domain.DefaultMatchFormat,
+		domain.RockPaperScissors, []string{"Dave", "Erin"}, 0, "2025-02-01T10:00:00Z")
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveTournament(other))
+
+	tournaments, err := repo.ListTournaments()
+	require.NoError(t, err)
+	require.Len(t, tournaments, 2)
+	assert.Equal(t, other, tournaments[0])
+	assert.Equal(t, tournament, tournaments[1])
+}
+
+func TestSQLiteUnfinishedGames(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	seed := uint64(1<<63 + 7)
+	unfinished := &domain.UnfinishedGame{
+		Game: &domain.Game{
+			ID:         "game1",
+			Player1:    "Alice",
+			Player2:    "Bot",
+			Mode:       domain.PlayerVsBot,
+			RuleSet:    domain.RockPaperScissors.Name,
+			Difficulty: domain.Hard,
+			Seed:       &seed,
+			PlayedAt:   "2025-01-01T10:00:00Z",
+			Rounds: []domain.RoundResult{
+				{Move1: domain.Rock, Move2: domain.Paper, Winner: "Bot", Commitment: "c0ffee", Salt: "5a17"},
+			},
+		},
+		Format: domain.MatchFormat{Type: domain.FirstTo, Rounds: 3},
+	}
+	require.NoError(t, repo.SaveUnfinishedGame(unfinished))
+
+	// Every round stores the game again with the rounds so far
+	unfinished.Game.Rounds = append(unfinished.Game.Rounds,
+		domain.RoundResult{Move1: domain.Paper, Move2: domain.Rock, Winner: "Alice", Commitment: "beef", Salt: "f00d"})
+	require.NoError(t, repo.SaveUnfinishedGame(unfinished))
+
+	games, err := repo.GetUnfinishedGames()
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+	assert.Equal(t, unfinished, games[0])
+
+	// Forfeited games are stored as such once over
+	game := *unfinished.Game
+	game.Winner = "Bot"
+	game.Forfeit = true
+	require.NoError(t, repo.SaveGame(context.Background(), &game))
+	require.NoError(t, repo.DeleteUnfinishedGame("game1"))
+
+	games, err = repo.GetUnfinishedGames()
+	require.NoError(t, err)
+	assert.Empty(t, games)
+
+	history, err := repo.GetGameHistory(context.Background())
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, &game, history[0])
+}
+
+func TestSQLiteQueryGameHistory(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	games := []*domain.Game{
+		{ID: "g1", Player1: "Alice", Player2: "Bob", Winner: "Alice", PlayedAt: "2025-01-01T10:00:00Z"},
+		{ID: "g2", Player1: "Carol", Player2: "Alice", Winner: "Draw", PlayedAt: "2025-01-02T10:00:00Z"},
+		{ID: "g3", Player1: "Alice", Player2: "Bot", Winner: "Bot", Mode: domain.PlayerVsBot, PlayedAt: "2025-01-03T10:00:00Z",
+			Rounds: []domain.RoundResult{{Move1: domain.Rock, Move2: domain.Paper, Winner: "Bot"}}},
+		{ID: "g4", Player1: "Bob", Player2: "Carol", Winner: "Carol", PlayedAt: "2025-01-03T10:00:00Z"},
+		{ID: "g5", Player1: "Bob", Player2: "Alice", Winner: "Alice", PlayedAt: "2025-01-05T10:00:00Z"},
+	}
+	for _, game := range games {
+		game.RuleSet = domain.RockPaperScissors.Name
+		require.NoError(t, repo.SaveGame(context.Background(), game))
+	}
+
+	pvp := domain.PlayerVsPlayer
+	date := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	tests := []struct {
+		name  string
+		query domain.HistoryQuery
+		ids   []string
+		total int
+	}{
+		{"everything", domain.HistoryQuery{}, []string{"g5", "g4", "g3", "g2", "g1"}, 5},
+		{"oldest first", domain.HistoryQuery{Order: domain.OldestFirst}, []string{"g1", "g2", "g3", "g4", "g5"}, 5},
+		{"page", domain.HistoryQuery{Limit: 2, Offset: 1}, []string{"g4", "g3"}, 5},
+		{"past the end", domain.HistoryQuery{Offset: 5}, []string{}, 5},
+		{"player on either side", domain.HistoryQuery{Player: "Alice"}, []string{"g5", "g3", "g2", "g1"}, 4},
+		{"winner", domain.HistoryQuery{Winner: "Alice"}, []string{"g5", "g1"}, 2},
+		{"draws", domain.HistoryQuery{Winner: "Draw"}, []string{"g2"}, 1},
+		{"mode", domain.HistoryQuery{Player: "Alice", Mode: &pvp, Limit: 1}, []string{"g5"}, 3},
+		{"date range", domain.HistoryQuery{From: date("2025-01-02T10:00:00Z"), To: date("2025-01-05T10:00:00Z")}, []string{"g4", "g3", "g2"}, 3},
+		{"date range in another zone", domain.HistoryQuery{From: date("2025-01-03T12:00:00+02:00")}, []string{"g5", "g4", "g3"}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.QueryGameHistory(context.Background(), tt.query)
+			require.NoError(t, err)
+			ids := []string{}
+			for _, game := range page.Games {
+				ids = append(ids, game.ID)
+			}
+			assert.Equal(t, tt.ids, ids)
+			assert.Equal(t, tt.total, page.Total)
+
+			// The in-memory query of the other backends agrees
+			memory := domain.QueryGames(games, tt.query)
+			assert.Equal(t, tt.total, memory.Total)
+			assert.Equal(t, page.Games, memory.Games)
+		})
+	}
+}
+
+func TestSQLiteHistoryQueriesUseIndexes(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	for _, filter := range []string{"player1 = 'Alice' OR player2 = 'Alice'", "winner = 'Alice'", "played_at >= '2025-01-01T00:00:00Z'"} {
+		rows, err := repo.db.Query("EXPLAIN QUERY PLAN SELECT id FROM game_results WHERE " + filter + " ORDER BY played_at DESC")
+		require.NoError(t, err)
+
+		var plan []string
+		for rows.Next() {
+			var id, parent, unused int
+			var detail string
+			require.NoError(t, rows.Scan(&id, &parent, &unused, &detail))
+			plan = append(plan, detail)
+		}
+		require.NoError(t, rows.Err())
+		rows.Close()
+
+		assert.Contains(t, strings.Join(plan, "\n"), "INDEX game_results_", filter)
+	}
+}
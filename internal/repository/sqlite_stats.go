@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"protofire-game/internal/domain"
+)
+
+// playerStatsQuery aggregates per-player stats in a single pass. The longest
+// win streak is computed as the largest island of consecutive wins in each
+// player's games ordered by date, and the favorite move is the move a player
+// used in most rounds.
+const playerStatsQuery = `
+WITH player_games AS (
+	SELECT id, player1 AS player, winner, played_at FROM game_results
+	UNION ALL
+	SELECT id, player2 AS player, winner, played_at FROM game_results WHERE player2 != player1
+),
+ordered_games AS (
+	SELECT player, winner = player AS won,
+		ROW_NUMBER() OVER (PARTITION BY player ORDER BY played_at, id) AS game_number
+	FROM player_games
+),
+win_islands AS (
+	SELECT player, game_number - ROW_NUMBER() OVER (PARTITION BY player, won ORDER BY game_number) AS island
+	FROM ordered_games
+	WHERE won
+),
+streaks AS (
+	SELECT player, MAX(length) AS longest_streak
+	FROM (SELECT player, island, COUNT(*) AS length FROM win_islands GROUP BY player, island)
+	GROUP BY player
+),
+player_moves AS (
+	SELECT g.player1 AS player, r.move1 AS move FROM rounds r JOIN game_results g ON g.id = r.game_id
+	UNION ALL
+	SELECT g.player2 AS player, r.move2 AS move FROM rounds r JOIN game_results g ON g.id = r.game_id
+),
+favorite_moves AS (
+	SELECT player, move,
+		ROW_NUMBER() OVER (PARTITION BY player ORDER BY COUNT(*) DESC, move) AS move_rank
+	FROM player_moves
+	GROUP BY player, move
+)
+SELECT
+	pg.player,
+	COUNT(*) AS games,
+	SUM(pg.winner = pg.player) AS wins,
+	SUM(pg.winner != pg.player AND pg.winner != 'Draw') AS losses,
+	SUM(pg.winner = 'Draw') AS draws,
+	COALESCE(s.longest_streak, 0) AS longest_streak,
+	fm.move AS favorite_move
+FROM player_games pg
+LEFT JOIN streaks s ON s.player = pg.player
+LEFT JOIN favorite_moves fm ON fm.player = pg.player AND fm.move_rank = 1
+%s
+GROUP BY pg.player
+ORDER BY wins DESC, CAST(wins AS REAL) / COUNT(*) DESC, pg.player`
+
+func (r *SQLiteRepository) GetLeaderboard(ctx context.Context, limit int) ([]*domain.PlayerStats, error) {
+	query := fmt.Sprintf(playerStatsQuery, "")
+	args := []interface{}{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var leaderboard []*domain.PlayerStats
+	for rows.Next() {
+		stats, err := scanPlayerStats(rows)
+		if err != nil {
+			return nil, err
+		}
+		leaderboard = append(leaderboard, stats)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return leaderboard, nil
+}
+
+func (r *SQLiteRepository) GetPlayerStats(ctx context.Context, player string) (*domain.PlayerStats, error) {
+	query := fmt.Sprintf(playerStatsQuery, "WHERE pg.player = ?")
+
+	rows, err := r.db.QueryContext(ctx, query, player)
+	if err != nil {
+		return nil, fmt.Errorf("error querying player stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &domain.PlayerStats{Player: player}
+	if rows.Next() {
+		stats, err = scanPlayerStats(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (r *SQLiteRepository) GetHeadToHead(ctx context.Context, player string) ([]*domain.HeadToHead, error) {
+	query := `
+	SELECT
+		CASE WHEN player1 = ?1 THEN player2 ELSE player1 END AS opponent,
+		SUM(winner = ?1) AS wins,
+		SUM(winner != ?1 AND winner != 'Draw') AS losses,
+		SUM(winner = 'Draw') AS draws
+	FROM game_results
+	WHERE player1 = ?1 OR player2 = ?1
+	GROUP BY opponent
+	ORDER BY COUNT(*) DESC, opponent`
+
+	rows, err := r.db.QueryContext(ctx, query, player)
+	if err != nil {
+		return nil, fmt.Errorf("error querying head-to-head records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*domain.HeadToHead
+	for rows.Next() {
+		record := domain.HeadToHead{Player: player}
+		if err := rows.Scan(&record.Opponent, &record.Wins, &record.Losses, &record.Draws); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		records = append(records, &record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return records, nil
+}
+
+func scanPlayerStats(rows *sql.Rows) (*domain.PlayerStats, error) {
+	var stats domain.PlayerStats
+	var favoriteMove sql.NullInt64
+
+	err := rows.Scan(
+		&stats.Player,
+		&stats.Games,
+		&stats.Wins,
+		&stats.Losses,
+		&stats.Draws,
+		&stats.LongestStreak,
+		&favoriteMove,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error scanning row: %w", err)
+	}
+
+	if favoriteMove.Valid {
+		stats.FavoriteMove = domain.Move(favoriteMove.Int64).String()
+	}
+
+	return &stats, nil
+}
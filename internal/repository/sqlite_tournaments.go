@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"protofire-game/internal/domain"
+)
+
+// SaveTournament stores a new tournament, or the matches and winner of an
+// existing one. Players and matches already stored never change.
+func (r *SQLiteRepository) SaveTournament(tournament *domain.Tournament) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO tournaments (id, name, format, match_format, rule_set, swiss_rounds, winner, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (id) DO UPDATE SET winner = excluded.winner`
+
+	_, err = tx.Exec(query,
+		tournament.ID,
+		tournament.Name,
+		tournament.Format,
+		tournament.MatchFormat.Code(),
+		tournament.RuleSet,
+		tournament.SwissRounds,
+		tournament.Winner,
+		tournament.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving tournament: %w", err)
+	}
+
+	playerQuery := `
+	INSERT INTO tournament_players (tournament_id, seed, player)
+	VALUES (?, ?, ?)
+	ON CONFLICT (tournament_id, seed) DO NOTHING`
+
+	for i, player := range tournament.Players {
+		if _, err := tx.Exec(playerQuery, tournament.ID, i+1, player); err != nil {
+			return fmt.Errorf("error saving tournament player: %w", err)
+		}
+	}
+
+	matchQuery := `
+	INSERT INTO tournament_matches (tournament_id, round, number, bracket, player1, player2, winner, game_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (tournament_id, round, number) DO UPDATE SET
+		winner = excluded.winner,
+		game_id = excluded.game_id`
+
+	for _, match := range tournament.Matches {
+		_, err := tx.Exec(matchQuery,
+			tournament.ID,
+			match.Round,
+			match.Number,
+			match.Bracket,
+			match.Player1,
+			match.Player2,
+			match.Winner,
+			match.GameID,
+		)
+		if err != nil {
+			return fmt.Errorf("error saving tournament match: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing tournament: %w", err)
+	}
+
+	return nil
+}
+
+// GetTournament returns nil when there is no tournament with the given ID.
+func (r *SQLiteRepository) GetTournament(id string) (*domain.Tournament, error) {
+	query := `
+	SELECT id, name, format, match_format, rule_set, swiss_rounds, winner, created_at
+	FROM tournaments
+	WHERE id = ?`
+
+	tournament, err := scanTournament(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTournament(tournament); err != nil {
+		return nil, err
+	}
+	return tournament, nil
+}
+
+func (r *SQLiteRepository) ListTournaments() ([]*domain.Tournament, error) {
+	query := `
+	SELECT id, name, format, match_format, rule_set, swiss_rounds, winner, created_at
+	FROM tournaments
+	ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying tournaments: %w", err)
+	}
+	defer rows.Close()
+
+	var tournaments []*domain.Tournament
+	for rows.Next() {
+		tournament, err := scanTournament(rows)
+		if err != nil {
+			return nil, err
+		}
+		tournaments = append(tournaments, tournament)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	for _, tournament := range tournaments {
+		if err := r.loadTournament(tournament); err != nil {
+			return nil, err
+		}
+	}
+	return tournaments, nil
+}
+
+func scanTournament(row interface{ Scan(...any) error }) (*domain.Tournament, error) {
+	var tournament domain.Tournament
+	var matchFormat string
+	err := row.Scan(
+		&tournament.ID,
+		&tournament.Name,
+		&tournament.Format,
+		&matchFormat,
+		&tournament.RuleSet,
+		&tournament.SwissRounds,
+		&tournament.Winner,
+		&tournament.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning tournament: %w", err)
+	}
+
+	if tournament.MatchFormat, err = domain.ParseMatchFormat(matchFormat); err != nil {
+		return nil, fmt.Errorf("error parsing match format of tournament %s: %w", tournament.ID, err)
+	}
+	return &tournament, nil
+}
+
+// loadTournament loads the players and matches of a tournament.
+func (r *SQLiteRepository) loadTournament(tournament *domain.Tournament) error {
+	playerRows, err := r.db.Query(`
+	SELECT player
+	FROM tournament_players
+	WHERE tournament_id = ?
+	ORDER BY seed`, tournament.ID)
+	if err != nil {
+		return fmt.Errorf("error querying tournament players: %w", err)
+	}
+	defer playerRows.Close()
+
+	for playerRows.Next() {
+		var player string
+		if err := playerRows.Scan(&player); err != nil {
+			return fmt.Errorf("error scanning tournament player: %w", err)
+		}
+		tournament.Players = append(tournament.Players, player)
+	}
+	if err := playerRows.Err(); err != nil {
+		return fmt.Errorf("error iterating tournament players: %w", err)
+	}
+
+	matchRows, err := r.db.Query(`
+	SELECT round, number, bracket, player1, player2, winner, game_id
+	FROM tournament_matches
+	WHERE tournament_id = ?
+	ORDER BY round, number`, tournament.ID)
+	if err != nil {
+		return fmt.Errorf("error querying tournament matches: %w", err)
+	}
+	defer matchRows.Close()
+
+	for matchRows.Next() {
+		var match domain.TournamentMatch
+		err := matchRows.Scan(
+			&match.Round,
+			&match.Number,
+			&match.Bracket,
+			&match.Player1,
+			&match.Player2,
+			&match.Winner,
+			&match.GameID,
+		)
+		if err != nil {
+			return fmt.Errorf("error scanning tournament match: %w", err)
+		}
+		tournament.Matches = append(tournament.Matches, &match)
+	}
+	if err := matchRows.Err(); err != nil {
+		return fmt.Errorf("error iterating tournament matches: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"protofire-game/internal/domain"
+)
+
+// SaveUnfinishedGame stores a game in progress, replacing the rounds stored
+// for it before.
+func (r *SQLiteRepository) SaveUnfinishedGame(unfinished *domain.UnfinishedGame) error {
+	game := unfinished.Game
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT OR REPLACE INTO unfinished_games (id, player1, player2, mode, rule_set, match_format, difficulty, seed, played_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	var seed sql.NullInt64
+	if game.Seed != nil {
+		seed = sql.NullInt64{Int64: int64(*game.Seed), Valid: true}
+	}
+
+	_, err = tx.Exec(query,
+		game.ID,
+		game.Player1,
+		game.Player2,
+		game.Mode,
+		game.RuleSet,
+		unfinished.Format.Code(),
+		game.Difficulty,
+		seed,
+		game.PlayedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving unfinished game: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM unfinished_rounds WHERE game_id = ?`, game.ID); err != nil {
+		return fmt.Errorf("error clearing unfinished rounds: %w", err)
+	}
+
+	roundQuery := `
+	INSERT INTO unfinished_rounds (game_id, round_number, move1, move2, winner, commitment, salt)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	for i, round := range game.Rounds {
+		_, err = tx.Exec(roundQuery,
+			game.ID,
+			i+1,
+			round.Move1,
+			round.Move2,
+			round.Winner,
+			round.Commitment,
+			round.Salt,
+		)
+		if err != nil {
+			return fmt.Errorf("error saving round %d: %w", i+1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing unfinished game: %w", err)
+	}
+
+	return nil
+}
+
+// GetUnfinishedGames returns the games in progress, the most recent first.
+func (r *SQLiteRepository) GetUnfinishedGames() ([]*domain.UnfinishedGame, error) {
+	query := `
+	SELECT id, player1, player2, mode, rule_set, match_format, difficulty, seed, played_at
+	FROM unfinished_games
+	ORDER BY played_at DESC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying unfinished games: %w", err)
+	}
+	defer rows.Close()
+
+	var games []*domain.UnfinishedGame
+	gamesByID := make(map[string]*domain.Game)
+
+	for rows.Next() {
+		var game domain.Game
+		var matchFormat string
+		var seed sql.NullInt64
+
+		err := rows.Scan(
+			&game.ID,
+			&game.Player1,
+			&game.Player2,
+			&game.Mode,
+			&game.RuleSet,
+			&matchFormat,
+			&game.Difficulty,
+			&seed,
+			&game.PlayedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning unfinished game: %w", err)
+		}
+
+		if seed.Valid {
+			value := uint64(seed.Int64)
+			game.Seed = &value
+		}
+
+		format, err := domain.ParseMatchFormat(matchFormat)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing match format of game %s: %w", game.ID, err)
+		}
+
+		games = append(games, &domain.UnfinishedGame{Game: &game, Format: format})
+		gamesByID[game.ID] = &game
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	roundRows, err := r.db.Query(`
+	SELECT game_id, move1, move2, winner, commitment, salt
+	FROM unfinished_rounds
+	ORDER BY game_id, round_number`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying unfinished rounds: %w", err)
+	}
+	defer roundRows.Close()
+
+	for roundRows.Next() {
+		var gameID string
+		var round domain.RoundResult
+		err := roundRows.Scan(
+			&gameID,
+			&round.Move1,
+			&round.Move2,
+			&round.Winner,
+			&round.Commitment,
+			&round.Salt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning unfinished round: %w", err)
+		}
+		if game, ok := gamesByID[gameID]; ok {
+			game.Rounds = append(game.Rounds, round)
+		}
+	}
+	if err := roundRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unfinished rounds: %w", err)
+	}
+
+	return games, nil
+}
+
+// DeleteUnfinishedGame forgets a game in progress, once it is over.
+func (r *SQLiteRepository) DeleteUnfinishedGame(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM unfinished_rounds WHERE game_id = ?`, id); err != nil {
+		return fmt.Errorf("error deleting unfinished rounds: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM unfinished_games WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("error deleting unfinished game: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing deletion: %w", err)
+	}
+
+	return nil
+}
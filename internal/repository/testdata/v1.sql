@@ -0,0 +1,15 @@
+-- A database as created by the first release, before migrations: games
+-- only, without rounds, rule sets or modes, played at the local time of
+-- the machine.
+CREATE TABLE IF NOT EXISTS game_results (
+	id TEXT PRIMARY KEY,
+	player1 TEXT NOT NULL,
+	player2 TEXT NOT NULL,
+	winner TEXT NOT NULL,
+	played_at DATETIME NOT NULL
+);
+
+INSERT INTO game_results (id, player1, player2, winner, played_at) VALUES
+	('0b7c1f1e-6d0a-4f4e-9d8e-2f1a7c3b5e01', 'Alice', 'Bob', 'Alice', '2025-01-01T10:00:00Z'),
+	('5e2d9a34-1c7b-4b0e-8f6a-9d3c2b1a0f02', 'Alice', 'Bot', 'Bot', '2025-01-02T10:00:00Z'),
+	('a9f8e7d6-c5b4-4a3b-9c2d-1e0f9a8b7c03', 'Carol', 'Bob', 'Draw', '2025-01-03T12:00:00+02:00');
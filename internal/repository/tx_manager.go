@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ErrTransactionReverted is wrapped by the error of a transaction mined
+	// with a failed status.
+	ErrTransactionReverted = errors.New("transaction reverted")
+	// ErrTransactionReplaced is wrapped by the error of a transaction whose
+	// nonce was used by a transaction sent by another client of the signer.
+	ErrTransactionReplaced = errors.New("transaction replaced")
+)
+
+const (
+	// gasLimitMargin is added to the gas estimate, in percent, in case the
+	// state changes before the transaction is mined.
+	gasLimitMargin = 20
+	// feeBump raises the fees of a transaction sent again, in percent. Nodes
+	// only replace a pending transaction for at least 10% more.
+	feeBump = 20
+	// maxFeeBumps is how many times the fees of a transaction are raised.
+	// After that, the transactions sent are waited on as they are.
+	maxFeeBumps = 5
+	// maxNonceRetries is how many times a transaction refused for its nonce
+	// is sent again with a new one.
+	maxNonceRetries = 3
+
+	defaultResubmitAfter       = 30 * time.Second
+	defaultReceiptPollInterval = time.Second
+)
+
+// txManager sends the transactions of a signer. Their gas is estimated, and
+// their fees follow EIP-1559 unless the chain only takes legacy transactions.
+// A transaction that is not mined after resubmitAfter is sent again with the
+// same nonce and higher fees, until one of them is mined. Nonces are kept
+// locally, so concurrent transactions are in flight together.
+type txManager struct {
+	client ethereumClient
+	key    *ecdsa.PrivateKey
+	from   common.Address
+	nonces *nonceManager
+
+	resubmitAfter time.Duration
+	pollInterval  time.Duration
+
+	mu      sync.Mutex
+	chainID *big.Int
+}
+
+func newTxManager(client ethereumClient, key *ecdsa.PrivateKey) *txManager {
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	return &txManager{
+		client:        client,
+		key:           key,
+		from:          from,
+		nonces:        newNonceManager(client, from),
+		resubmitAfter: defaultResubmitAfter,
+		pollInterval:  defaultReceiptPollInterval,
+	}
+}
+
+// txFees are the fees of a legacy transaction when tipCap is nil.
+type txFees struct {
+	gasPrice *big.Int
+	tipCap   *big.Int
+	feeCap   *big.Int
+}
+
+// send calls the contract and waits for the transaction to be mined. It
+// returns the receipt of the transaction mined, or an error wrapping
+// ErrTransactionReverted if it failed.
+func (m *txManager) send(ctx context.Context, to common.Address, data []byte) (*types.Receipt, error) {
+	tx, err := m.submit(ctx, to, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return m.wait(ctx, []*types.Transaction{tx}, nil)
+}
+
+// submit sends a transaction calling the contract, without waiting for it to
+// be mined. onSigned, when given, is called with the transaction before it
+// is sent, and again if it is signed with another nonce, so it can be kept.
+func (m *txManager) submit(ctx context.Context, to common.Address, data []byte, onSigned func(*types.Transaction) error) (*types.Transaction, error) {
+	chainID, err := m.getChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gas, err := m.client.EstimateGas(ctx, ethereum.CallMsg{From: m.from, To: &to, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	gas += gas * gasLimitMargin / 100
+
+	fees, err := m.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.sendNew(ctx, chainID, to, gas, data, fees, onSigned)
+}
+
+// wait waits for one of the transactions sent with the same nonce to be
+// mined, sending the last one again with higher fees when it takes too long.
+// onSigned, when given, is called with each replacement before it is sent.
+// It returns an error wrapping ErrTransactionReplaced when the nonce was used
+// by another transaction, so that none of them can be mined anymore.
+func (m *txManager) wait(ctx context.Context, sent []*types.Transaction, onSigned func(*types.Transaction) error) (*types.Receipt, error) {
+	chainID, err := m.getChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	last := sent[len(sent)-1]
+	nonce := last.Nonce()
+	fees := txFees{gasPrice: last.GasPrice()}
+	if last.Type() != types.LegacyTxType {
+		fees = txFees{tipCap: last.GasTipCap(), feeCap: last.GasFeeCap()}
+	}
+
+	lastSent := time.Now()
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// The nonce is read before the receipts, so a transaction mined in
+		// between is not taken for another one.
+		mined, nonceErr := m.client.NonceAt(ctx, m.from, nil)
+
+		// Any of the transactions sent may be the one mined.
+		for _, tx := range sent {
+			receipt, err := m.client.TransactionReceipt(ctx, tx.Hash())
+			if err != nil {
+				continue
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return receipt, fmt.Errorf("%w: %s", ErrTransactionReverted, tx.Hash().Hex())
+			}
+			return receipt, nil
+		}
+		if nonceErr == nil && mined > nonce {
+			return nil, fmt.Errorf("%w: nonce %d of %s was used by another transaction", ErrTransactionReplaced, nonce, last.Hash().Hex())
+		}
+
+		if bumps := len(sent) - 1; time.Since(lastSent) >= m.resubmitAfter && bumps < maxFeeBumps {
+			fees = fees.bump()
+			replacement, err := m.sign(chainID, nonce, *last.To(), last.Gas(), last.Data(), fees)
+			if err != nil {
+				return nil, err
+			}
+			if onSigned != nil {
+				if err := onSigned(replacement); err != nil {
+					return nil, err
+				}
+			}
+			// A replacement that is refused, when a transaction sent before
+			// was just mined, leaves those to wait on.
+			if err := m.client.SendTransaction(ctx, replacement); err == nil {
+				sent = append(sent, replacement)
+				last = replacement
+			}
+			lastSent = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			// The transaction may still be mined after the context is done,
+			// so the error tells which one to look for.
+			return nil, fmt.Errorf("failed to wait for transaction %s to be mined: %w", last.Hash().Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendNew sends the transaction with the next nonce. When another client of
+// the signer took that nonce, it is sent again with a nonce read from the
+// node.
+func (m *txManager) sendNew(ctx context.Context, chainID *big.Int, to common.Address, gas uint64, data []byte, fees txFees, onSigned func(*types.Transaction) error) (*types.Transaction, error) {
+	for retries := 0; ; retries++ {
+		nonce, err := m.nonces.reserve(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		tx, err := m.sign(chainID, nonce, to, gas, data, fees)
+		if err != nil {
+			m.nonces.release(nonce)
+			return nil, err
+		}
+		if onSigned != nil {
+			if err := onSigned(tx); err != nil {
+				m.nonces.release(nonce)
+				return nil, err
+			}
+		}
+
+		err = m.client.SendTransaction(ctx, tx)
+		if err == nil {
+			return tx, nil
+		}
+		if isNonceTaken(err) && retries < maxNonceRetries {
+			m.nonces.resync()
+			continue
+		}
+		m.nonces.release(nonce)
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+}
+
+func (m *txManager) getChainID(ctx context.Context) (*big.Int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.chainID == nil {
+		chainID, err := m.client.ChainID(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chain ID: %w", err)
+		}
+		m.chainID = chainID
+	}
+	return m.chainID, nil
+}
+
+// suggestFees prices a transaction for the next block: the suggested tip on
+// top of twice the base fee, so it stays valid for a few blocks of rising
+// base fees. Chains without a base fee, or without eth_maxPriorityFeePerGas
+// like Harmony, get a legacy gas price.
+func (m *txManager) suggestFees(ctx context.Context) (txFees, error) {
+	header, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return txFees{}, fmt.Errorf("failed to get latest block: %w", err)
+	}
+
+	if header.BaseFee != nil {
+		if tipCap, err := m.client.SuggestGasTipCap(ctx); err == nil {
+			feeCap := new(big.Int).Mul(header.BaseFee, big.NewInt(2))
+			return txFees{tipCap: tipCap, feeCap: feeCap.Add(feeCap, tipCap)}, nil
+		}
+	}
+
+	gasPrice, err := m.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return txFees{}, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	return txFees{gasPrice: gasPrice}, nil
+}
+
+// bump raises every fee by feeBump percent, and at least by 1 wei.
+func (f txFees) bump() txFees {
+	raise := func(fee *big.Int) *big.Int {
+		if fee == nil {
+			return nil
+		}
+		raised := new(big.Int).Mul(fee, big.NewInt(100+feeBump))
+		raised.Div(raised, big.NewInt(100))
+		if raised.Cmp(fee) <= 0 {
+			raised.Add(fee, big.NewInt(1))
+		}
+		return raised
+	}
+	return txFees{gasPrice: raise(f.gasPrice), tipCap: raise(f.tipCap), feeCap: raise(f.feeCap)}
+}
+
+func (m *txManager) sign(chainID *big.Int, nonce uint64, to common.Address, gas uint64, data []byte, fees txFees) (*types.Transaction, error) {
+	var tx *types.Transaction
+	if fees.tipCap != nil {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			To:        &to,
+			Gas:       gas,
+			GasTipCap: fees.tipCap,
+			GasFeeCap: fees.feeCap,
+			Data:      data,
+		})
+	} else {
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       &to,
+			Gas:      gas,
+			GasPrice: fees.gasPrice,
+			Data:     data,
+		})
+	}
+
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), m.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	return signed, nil
+}
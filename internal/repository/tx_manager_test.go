@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"protofire-game/internal/domain"
+)
+
+// mining commits a block every few milliseconds until the returned function
+// is called, so that the transactions sent meanwhile get mined.
+func (c *testChain) mining() func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.backend.Commit()
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+func (c *testChain) newTxManager(client ethereumClient) *txManager {
+	txs := newTxManager(client, c.key)
+	txs.pollInterval = 5 * time.Millisecond
+	return txs
+}
+
+func (c *testChain) transaction(t *testing.T, hash common.Hash) *types.Transaction {
+	tx, _, err := c.backend.Client().TransactionByHash(context.Background(), hash)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestTxManagerSendsDynamicFeeTransactions(t *testing.T) {
+	chain := newTestChain(t)
+	data, err := chain.repo.abi.Pack("storeGameResult", [15]byte{'A'}, [15]byte{'B'}, uint8(1), uint8(0), uint8(0))
+	require.NoError(t, err)
+
+	stop := chain.mining()
+	receipt, err := chain.newTxManager(chain.client).send(context.Background(), chain.repo.contractAddr, data)
+	stop()
+	require.NoError(t, err)
+
+	tx := chain.transaction(t, receipt.TxHash)
+	assert.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+	assert.Greater(t, tx.Gas(), receipt.GasUsed, "the gas limit has a margin over the estimate")
+	assert.Less(t, tx.Gas(), receipt.GasUsed*2)
+
+	header, err := chain.backend.Client().HeaderByNumber(context.Background(), receipt.BlockNumber)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, tx.GasFeeCap().Cmp(header.BaseFee), 0)
+}
+
+func TestOnChainSaveGame(t *testing.T) {
+	chain := newTestChain(t)
+	chain.repo.txs.pollInterval = 5 * time.Millisecond
+
+	stop := chain.mining()
+	err := chain.repo.SaveGame(context.Background(), &domain.Game{Player1: "Alice", Player2: "Bob", Winner: "Bob"})
+	stop()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Alice-Bob"}, historyPlayers(t, chain.repo, domain.HistoryQuery{Winner: "Bob"}))
+}
+
+// legacyClient is a node without eth_maxPriorityFeePerGas.
+type legacyClient struct {
+	ethereumClient
+}
+
+func (c legacyClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return nil, errors.New("the method eth_maxPriorityFeePerGas does not exist/is not available")
+}
+
+func TestTxManagerFallsBackToLegacyTransactions(t *testing.T) {
+	chain := newTestChain(t)
+	data, err := chain.repo.abi.Pack("storeGameResult", [15]byte{'A'}, [15]byte{'B'}, uint8(0), uint8(0), uint8(0))
+	require.NoError(t, err)
+
+	stop := chain.mining()
+	receipt, err := chain.newTxManager(legacyClient{chain.client}).send(context.Background(), chain.repo.contractAddr, data)
+	stop()
+	require.NoError(t, err)
+
+	assert.Equal(t, uint8(types.LegacyTxType), chain.transaction(t, receipt.TxHash).Type())
+}
+
+// droppingClient loses the first transactions sent, as a node whose pool
+// drops them before they are mined.
+type droppingClient struct {
+	ethereumClient
+	drop    int
+	dropped []*types.Transaction
+}
+
+func (c *droppingClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if len(c.dropped) < c.drop {
+		c.dropped = append(c.dropped, tx)
+		return nil
+	}
+	return c.ethereumClient.SendTransaction(ctx, tx)
+}
+
+func TestTxManagerResubmitsWithHigherFees(t *testing.T) {
+	chain := newTestChain(t)
+	data, err := chain.repo.abi.Pack("storeGameResult", [15]byte{'A'}, [15]byte{'B'}, uint8(2), uint8(0), uint8(0))
+	require.NoError(t, err)
+
+	client := &droppingClient{ethereumClient: chain.client, drop: 2}
+	txs := chain.newTxManager(client)
+	txs.resubmitAfter = 20 * time.Millisecond
+
+	stop := chain.mining()
+	receipt, err := txs.send(context.Background(), chain.repo.contractAddr, data)
+	stop()
+	require.NoError(t, err)
+	require.Len(t, client.dropped, 2)
+
+	mined := chain.transaction(t, receipt.TxHash)
+	previous := client.dropped[1]
+	assert.Equal(t, client.dropped[0].Nonce(), mined.Nonce())
+	assert.Equal(t, previous.Nonce(), mined.Nonce())
+	assert.Greater(t, mined.GasTipCap().Cmp(previous.GasTipCap()), 0)
+	assert.Greater(t, mined.GasFeeCap().Cmp(previous.GasFeeCap()), 0)
+	assert.Greater(t, previous.GasTipCap().Cmp(client.dropped[0].GasTipCap()), 0)
+}
+
+// onceContractCode is the runtime code of a contract that can only be
+// called once: later calls revert.
+func onceContractCode() []byte {
+	p := program.New()
+	p.Push(0).Op(vm.SLOAD)
+	p.Op(vm.PUSH1)
+	revertAt := p.Size()
+	p.Append([]byte{0})
+	p.Op(vm.JUMPI)
+	p.Sstore(0, 1).Op(vm.STOP)
+	_, revert := p.Jumpdest()
+	p.Push(0).Op(vm.DUP1, vm.REVERT)
+
+	code := p.Bytes()
+	code[revertAt] = byte(revert)
+	return code
+}
+
+func TestTxManagerReportsReverts(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+
+	opts, err := bind.NewKeyedTransactorWithChainID(chain.key, big.NewInt(1337))
+	require.NoError(t, err)
+	constructor := program.New().ReturnViaCodeCopy(onceContractCode()).Bytes()
+	once, _, contract, err := bind.DeployContract(opts, abi.ABI{}, constructor, chain.backend.Client())
+	require.NoError(t, err)
+	chain.backend.Commit()
+
+	// The first call is still pending when the second one is estimated, so
+	// the second one only reverts once mined.
+	from := crypto.PubkeyToAddress(chain.key.PublicKey)
+	nonce, err := chain.backend.Client().PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	_, err = contract.RawTransact(opts, nil)
+	require.NoError(t, err)
+
+	type result struct {
+		receipt *types.Receipt
+		err     error
+	}
+	sent := make(chan result, 1)
+	go func() {
+		receipt, err := chain.newTxManager(chain.client).send(ctx, once, nil)
+		sent <- result{receipt, err}
+	}()
+	require.Eventually(t, func() bool {
+		pending, err := chain.backend.Client().PendingNonceAt(ctx, from)
+		return err == nil && pending == nonce+2
+	}, 5*time.Second, 5*time.Millisecond)
+	chain.backend.Commit()
+
+	reverted := <-sent
+	assert.ErrorIs(t, reverted.err, ErrTransactionReverted)
+	require.NotNil(t, reverted.receipt)
+	assert.Equal(t, types.ReceiptStatusFailed, reverted.receipt.Status)
+}
+
+func TestTxManagerPipelinesConcurrentTransactions(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t)
+	chain.repo.txs.pollInterval = 5 * time.Millisecond
+
+	players := []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank"}
+	errs := make(chan error, len(players))
+	stop := chain.mining()
+	for _, player := range players {
+		go func() {
+			errs <- chain.repo.SaveGame(ctx, &domain.Game{Player1: player, Player2: "Bot", Winner: player})
+		}()
+	}
+	for range players {
+		assert.NoError(t, <-errs)
+	}
+	stop()
+
+	page, err := chain.repo.QueryGameHistory(ctx, domain.HistoryQuery{Player: "Bot"})
+	require.NoError(t, err)
+	assert.Equal(t, len(players), page.Total)
+}
+
+func TestTxManagerRecoversTakenNonces(t *testing.T) {
+	tests := []struct {
+		name string
+		// mined tells whether the transaction that takes the nonce is
+		// mined, or still pending with higher fees.
+		mined bool
+	}{
+		{"nonce too low", true},
+		{"replacement underpriced", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			chain := newTestChain(t)
+			data, err := chain.repo.abi.Pack("storeGameResult", [15]byte{'A'}, [15]byte{'B'}, uint8(1), uint8(0), uint8(0))
+			require.NoError(t, err)
+			txs := chain.newTxManager(chain.client)
+
+			stop := chain.mining()
+			_, err = txs.send(ctx, chain.repo.contractAddr, data)
+			stop()
+			require.NoError(t, err)
+
+			// Another client of the signer takes the next nonce.
+			nonce, err := chain.backend.Client().PendingNonceAt(ctx, txs.from)
+			require.NoError(t, err)
+			chain.storeGame(t, &domain.Game{Player1: "Carol", Player2: "Dave", Winner: "Dave"}, big.NewInt(int64(nonce)), big.NewInt(100_000_000_000))
+			if tt.mined {
+				chain.backend.Commit()
+			}
+
+			stop = chain.mining()
+			receipt, err := txs.send(ctx, chain.repo.contractAddr, data)
+			stop()
+			require.NoError(t, err)
+			assert.Equal(t, nonce+1, chain.transaction(t, receipt.TxHash).Nonce())
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"protofire-game/internal/domain"
@@ -12,9 +14,18 @@ import (
 type GameUseCase struct {
 	repository      domain.GameRepository
 	randomGenerator domain.RandomGenerator
+	strategies      map[domain.Difficulty]domain.Strategy
+	botStrategy     domain.Strategy
+	botDifficulty   domain.Difficulty
+	ratings         *RatingUseCase
+	unfinished      domain.UnfinishedGameRepository
 	currentGame     *domain.Game
 	currentMode     domain.GameType
+	currentFormat   domain.MatchFormat
+	currentRules    *domain.RuleSet
 	currentRounds   []domain.RoundResult
+	botCommitment   *domain.RoundResult
+	saveProgress    bool
 }
 
 func NewGameUseCase(repo domain.GameRepository, randGen domain.RandomGenerator) *GameUseCase {
@@ -24,35 +35,150 @@ func NewGameUseCase(repo domain.GameRepository, randGen domain.RandomGenerator)
 	}
 }
 
-func (g *GameUseCase) StartNewGame(mode domain.GameType, player1, player2 string) error {
+// SetRatings makes every finished game update the players' ratings.
+func (g *GameUseCase) SetRatings(ratings *RatingUseCase) {
+	g.ratings = ratings
+}
+
+// SetUnfinishedGames makes the games started with StartNewGame be stored
+// after every round, so they can be resumed with ResumeGame if the program
+// exits before they are over.
+func (g *GameUseCase) SetUnfinishedGames(repo domain.UnfinishedGameRepository) {
+	g.unfinished = repo
+}
+
+// SetStrategies makes the bot difficulties available. Without them the bot
+// always plays random moves.
+func (g *GameUseCase) SetStrategies(strategies map[domain.Difficulty]domain.Strategy) {
+	g.strategies = strategies
+}
+
+// SetBotDifficulty selects the strategy the bot plays with in the next games.
+func (g *GameUseCase) SetBotDifficulty(difficulty domain.Difficulty) error {
+	if difficulty == domain.Easy {
+		g.botStrategy = nil
+		g.botDifficulty = difficulty
+		return nil
+	}
+
+	strategy, ok := g.strategies[difficulty]
+	if !ok {
+		return fmt.Errorf("difficulty %s is not available", difficulty)
+	}
+	g.botStrategy = strategy
+	g.botDifficulty = difficulty
+	return nil
+}
+
+func (g *GameUseCase) StartNewGame(mode domain.GameType, format domain.MatchFormat, rules *domain.RuleSet, player1, player2 string) error {
+	if err := g.startGame(mode, format, rules, player1, player2); err != nil {
+		return err
+	}
+	g.saveProgress = g.unfinished != nil
+	return nil
+}
+
+// startGame starts a game that is not stored until it is over.
+func (g *GameUseCase) startGame(mode domain.GameType, format domain.MatchFormat, rules *domain.RuleSet, player1, player2 string) error {
 	if err := domain.ValidatePlayerName(player1); err != nil {
 		return fmt.Errorf("invalid player1 name: %w", err)
 	}
 	if err := domain.ValidatePlayerName(player2); err != nil {
 		return fmt.Errorf("invalid player2 name: %w", err)
 	}
+	if err := format.Validate(); err != nil {
+		return fmt.Errorf("invalid match format: %w", err)
+	}
+	if rules == nil {
+		return fmt.Errorf("rule set is required")
+	}
 
 	g.currentGame = &domain.Game{
 		ID:       uuid.New().String(),
 		Player1:  player1,
 		Player2:  player2,
-		PlayedAt: time.Now().Format(time.RFC3339),
+		Mode:     mode,
+		RuleSet:  rules.Name,
+		PlayedAt: time.Now().UTC().Format(time.RFC3339),
 	}
 	g.currentMode = mode
+	g.currentFormat = format
+	g.currentRules = rules
 	g.currentRounds = make([]domain.RoundResult, 0)
+	g.botCommitment = nil
+	g.saveProgress = false
+
+	if mode == domain.PlayerVsBot {
+		g.currentGame.Difficulty = g.botDifficulty
+		if generator, ok := g.randomGenerator.(domain.SeedableGenerator); ok {
+			seed := generator.NextSeed()
+			generator.Reseed(seed)
+			g.currentGame.Seed = &seed
+		}
+		if err := g.commitBotMove(); err != nil {
+			g.currentGame = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// commitBotMove chooses the bot's move for the next round before the
+// player's move is known, and commits to it.
+func (g *GameUseCase) commitBotMove() error {
+	move := g.botMove(g.currentRounds)
+
+	commitment, salt, err := domain.CommitMove(move)
+	if err != nil {
+		return fmt.Errorf("failed to commit bot move: %w", err)
+	}
+
+	g.botCommitment = &domain.RoundResult{
+		Move2:      move,
+		Commitment: commitment,
+		Salt:       salt,
+	}
 	return nil
 }
 
-func (g *GameUseCase) PlayRound(move1, move2 domain.Move) (*domain.Game, error) {
+// botMove chooses the bot's move after the given rounds of the game in
+// progress.
+func (g *GameUseCase) botMove(history []domain.RoundResult) domain.Move {
+	if g.botStrategy != nil {
+		return g.botStrategy.NextMove(g.currentRules, history)
+	}
+	return g.randomGenerator.GenerateMove(g.currentRules)
+}
+
+// GetBotCommitment returns the commitment to the bot's move for the next
+// round, to be shown before the player chooses.
+func (g *GameUseCase) GetBotCommitment() string {
+	if g.botCommitment == nil {
+		return ""
+	}
+	return g.botCommitment.Commitment
+}
+
+// PlayRound plays the next round of the game in progress. The context bounds
+// storing the game once it is over; if it cannot be stored, the round is
+// undone so that it can be played again.
+func (g *GameUseCase) PlayRound(ctx context.Context, move1, move2 domain.Move) (*domain.Game, error) {
 	if g.currentGame == nil {
 		return nil, fmt.Errorf("no game in progress")
 	}
 
+	var commitment, salt string
 	if g.currentMode == domain.PlayerVsBot {
-		move2 = g.randomGenerator.GenerateMove()
+		move2 = g.botCommitment.Move2
+		commitment = g.botCommitment.Commitment
+		salt = g.botCommitment.Salt
 	}
 
-	winner := domain.DetermineWinner(move1, move2)
+	if !g.currentRules.IsValidMove(move1) || !g.currentRules.IsValidMove(move2) {
+		return nil, fmt.Errorf("invalid move for %s", g.currentRules.DisplayName)
+	}
+
+	winner := g.currentRules.DetermineWinner(move1, move2)
 	var winnerName string
 	switch winner {
 	case 0:
@@ -64,64 +190,287 @@ func (g *GameUseCase) PlayRound(move1, move2 domain.Move) (*domain.Game, error)
 	}
 
 	round := domain.RoundResult{
-		Move1:  move1,
-		Move2:  move2,
-		Winner: winnerName,
+		Move1:      move1,
+		Move2:      move2,
+		Winner:     winnerName,
+		Commitment: commitment,
+		Salt:       salt,
 	}
 
+	committed := g.botCommitment
 	g.currentRounds = append(g.currentRounds, round)
+	g.currentGame.Rounds = g.currentRounds
+	g.botCommitment = nil
 
-	if len(g.currentRounds) == 2 {
-		if g.currentRounds[0].Winner == g.currentRounds[1].Winner &&
-			g.currentRounds[0].Winner != "Draw" {
-			g.currentGame.Winner = g.currentRounds[0].Winner
-			if err := g.repository.SaveGame(g.currentGame); err != nil {
-				return nil, fmt.Errorf("failed to save game: %w", err)
+	gameWinner, finished := g.currentFormat.Result(g.currentRounds, g.currentGame.Player1, g.currentGame.Player2)
+	if !finished {
+		if g.currentMode == domain.PlayerVsBot {
+			if err := g.commitBotMove(); err != nil {
+				return nil, err
+			}
+		}
+		if g.saveProgress {
+			unfinished := &domain.UnfinishedGame{Game: g.currentGame, Format: g.currentFormat}
+			if err := g.unfinished.SaveUnfinishedGame(unfinished); err != nil {
+				return nil, fmt.Errorf("failed to save unfinished game: %w", err)
 			}
-			result := g.currentGame
-			g.currentGame = nil
-			g.currentRounds = nil
-			return result, nil
 		}
+		return g.currentGame, nil
 	}
 
-	if len(g.currentRounds) == 3 {
-		p1Wins := 0
-		p2Wins := 0
-		draws := 0
+	g.currentGame.Winner = gameWinner
+	if err := g.repository.SaveGame(ctx, g.currentGame); err != nil {
+		g.currentGame.Winner = ""
+		g.currentRounds = g.currentRounds[:len(g.currentRounds)-1]
+		g.currentGame.Rounds = g.currentRounds
+		g.botCommitment = committed
+		return nil, fmt.Errorf("failed to save game: %w", err)
+	}
+	result := g.currentGame
+	g.currentGame = nil
+	g.currentRounds = nil
 
-		for _, r := range g.currentRounds {
-			if r.Winner == g.currentGame.Player1 {
-				p1Wins++
-			} else if r.Winner == g.currentGame.Player2 {
-				p2Wins++
-			} else {
-				draws++
-			}
+	// The game is stored, so failing to clean up after it is only logged:
+	// failing the round would lose a game that is already in the history.
+	if g.saveProgress {
+		if err := g.unfinished.DeleteUnfinishedGame(result.ID); err != nil {
+			log.Printf("Failed to delete unfinished game %s: %v", result.ID, err)
 		}
+	}
 
-		if p1Wins > p2Wins {
-			g.currentGame.Winner = g.currentGame.Player1
-		} else if p2Wins > p1Wins {
-			g.currentGame.Winner = g.currentGame.Player2
-		} else {
-			g.currentGame.Winner = "Draw"
+	if g.ratings != nil {
+		if err := g.ratings.RecordGame(ctx, result); err != nil {
+			log.Printf("Failed to update ratings for game %s: %v", result.ID, err)
 		}
+	}
+
+	return result, nil
+}
+
+// GetUnfinishedGames returns the games left in progress when the program
+// last exited.
+func (g *GameUseCase) GetUnfinishedGames() ([]*domain.UnfinishedGame, error) {
+	if g.unfinished == nil {
+		return nil, nil
+	}
+	games, err := g.unfinished.GetUnfinishedGames()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unfinished games: %w", err)
+	}
+	return games, nil
+}
+
+func (g *GameUseCase) getUnfinishedGame(gameID string) (*domain.UnfinishedGame, error) {
+	games, err := g.GetUnfinishedGames()
+	if err != nil {
+		return nil, err
+	}
+	for _, unfinished := range games {
+		if unfinished.Game.ID == gameID {
+			return unfinished, nil
+		}
+	}
+	return nil, fmt.Errorf("unfinished game %s %w", gameID, domain.ErrNotFound)
+}
+
+// ResumeGame makes an unfinished game the game in progress, to be played
+// from the round after the last one stored. The bot plays with the
+// difficulty the game was started with and, when seeded, picks up its moves
+// where it left them, so the game can still be replayed from its seed.
+func (g *GameUseCase) ResumeGame(gameID string) error {
+	unfinished, err := g.getUnfinishedGame(gameID)
+	if err != nil {
+		return err
+	}
+	game := unfinished.Game
+
+	rules, err := domain.RuleSetByName(game.RuleSet)
+	if err != nil {
+		return err
+	}
+	if game.Mode == domain.PlayerVsBot {
+		if err := g.SetBotDifficulty(game.Difficulty); err != nil {
+			return err
+		}
+	}
 
-		if err := g.repository.SaveGame(g.currentGame); err != nil {
-			return nil, fmt.Errorf("failed to save game: %w", err)
+	g.currentGame = game
+	g.currentMode = game.Mode
+	g.currentFormat = unfinished.Format
+	g.currentRules = rules
+	g.currentRounds = append(make([]domain.RoundResult, 0, len(game.Rounds)), game.Rounds...)
+	g.botCommitment = nil
+	g.saveProgress = true
+
+	if game.Mode == domain.PlayerVsBot {
+		if game.Seed != nil {
+			if generator, ok := g.randomGenerator.(domain.SeedableGenerator); ok {
+				generator.Reseed(*game.Seed)
+				for i := range g.currentRounds {
+					g.botMove(g.currentRounds[:i])
+				}
+			} else {
+				game.Seed = nil
+			}
+		}
+		if err := g.commitBotMove(); err != nil {
+			g.currentGame = nil
+			return err
 		}
-		result := g.currentGame
+	}
+	return nil
+}
+
+// ForfeitGame ends an unfinished game abandoned by one of its players, who
+// loses it. The game is stored with the rounds played so far.
+func (g *GameUseCase) ForfeitGame(ctx context.Context, gameID, player string) (*domain.Game, error) {
+	unfinished, err := g.getUnfinishedGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	game := unfinished.Game
+
+	switch player {
+	case game.Player1:
+		game.Winner = game.Player2
+	case game.Player2:
+		game.Winner = game.Player1
+	default:
+		return nil, fmt.Errorf("%s is not a player of game %s", player, gameID)
+	}
+	game.Forfeit = true
+
+	if err := g.repository.SaveGame(ctx, game); err != nil {
+		return nil, fmt.Errorf("failed to save game: %w", err)
+	}
+	if g.currentGame != nil && g.currentGame.ID == gameID {
 		g.currentGame = nil
 		g.currentRounds = nil
-		return result, nil
 	}
 
-	return g.currentGame, nil
+	// As in PlayRound, the game is stored and cleaning up after it is only
+	// logged.
+	if err := g.unfinished.DeleteUnfinishedGame(gameID); err != nil {
+		log.Printf("Failed to delete unfinished game %s: %v", gameID, err)
+	}
+	if g.ratings != nil {
+		if err := g.ratings.RecordGame(ctx, game); err != nil {
+			log.Printf("Failed to update ratings for game %s: %v", gameID, err)
+		}
+	}
+	return game, nil
+}
+
+// ImportGames stores finished games played elsewhere, like those of an
+// export. Games without an ID, a date or a rule set get one. A backend that
+// stores many games at once, like the chain, stores them together.
+func (g *GameUseCase) ImportGames(ctx context.Context, games []*domain.Game) error {
+	for i, game := range games {
+		if err := validateImportedGame(game); err != nil {
+			return fmt.Errorf("invalid game %d: %w", i+1, err)
+		}
+		if game.ID == "" {
+			game.ID = uuid.New().String()
+		}
+		if game.PlayedAt == "" {
+			game.PlayedAt = time.Now().UTC().Format(time.RFC3339)
+		}
+		if game.RuleSet == "" {
+			game.RuleSet = domain.DefaultRuleSet.Name
+		}
+	}
+
+	if batch, ok := g.repository.(domain.GameBatchRepository); ok {
+		if err := batch.SaveGames(ctx, games); err != nil {
+			return fmt.Errorf("failed to save games: %w", err)
+		}
+	} else {
+		for _, game := range games {
+			if err := g.repository.SaveGame(ctx, game); err != nil {
+				return fmt.Errorf("failed to save game %s: %w", game.ID, err)
+			}
+		}
+	}
+
+	if g.ratings != nil {
+		for _, game := range games {
+			if err := g.ratings.RecordGame(ctx, game); err != nil {
+				return fmt.Errorf("failed to update ratings: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateImportedGame(game *domain.Game) error {
+	if err := domain.ValidatePlayerName(game.Player1); err != nil {
+		return fmt.Errorf("invalid player1 name: %w", err)
+	}
+	if err := domain.ValidatePlayerName(game.Player2); err != nil {
+		return fmt.Errorf("invalid player2 name: %w", err)
+	}
+	if game.Winner != game.Player1 && game.Winner != game.Player2 && game.Winner != "Draw" {
+		return fmt.Errorf("winner %q is not a player of the game", game.Winner)
+	}
+	if game.RuleSet != "" {
+		if _, err := domain.RuleSetByName(game.RuleSet); err != nil {
+			return err
+		}
+	}
+	if game.PlayedAt != "" {
+		if _, err := time.Parse(time.RFC3339, game.PlayedAt); err != nil {
+			return fmt.Errorf("invalid date %q: %w", game.PlayedAt, err)
+		}
+	}
+	return nil
+}
+
+func (g *GameUseCase) GetHistory(ctx context.Context) ([]*domain.Game, error) {
+	return g.repository.GetGameHistory(ctx)
+}
+
+// QueryHistory returns a page of the stored games matching the query.
+func (g *GameUseCase) QueryHistory(ctx context.Context, query domain.HistoryQuery) (*domain.HistoryPage, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return g.repository.QueryGameHistory(ctx, query)
+}
+
+// GetGame returns a stored game by ID.
+func (g *GameUseCase) GetGame(ctx context.Context, gameID string) (*domain.Game, error) {
+	return g.repository.GetGame(ctx, gameID)
+}
+
+// VerifyGame checks that every move the bot played in a stored game matches
+// the commitment it made before the round.
+func (g *GameUseCase) VerifyGame(ctx context.Context, gameID string) (*domain.Game, error) {
+	game, err := g.GetGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Mode != domain.PlayerVsBot {
+		return game, fmt.Errorf("only games against the bot have commitments")
+	}
+	if len(game.Rounds) == 0 {
+		return game, fmt.Errorf("no rounds stored for this game")
+	}
+	for i, round := range game.Rounds {
+		if err := round.VerifyCommitment(); err != nil {
+			return game, fmt.Errorf("round %d: %w", i+1, err)
+		}
+	}
+	return game, nil
+}
+
+// GetCurrentGame returns the game in progress, or nil once it is finished.
+func (g *GameUseCase) GetCurrentGame() *domain.Game {
+	return g.currentGame
 }
 
-func (g *GameUseCase) GetHistory() ([]*domain.Game, error) {
-	return g.repository.GetGameHistory()
+func (g *GameUseCase) GetCurrentFormat() domain.MatchFormat {
+	return g.currentFormat
 }
 
 func (g *GameUseCase) GetCurrentRounds() []domain.RoundResult {
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -16,7 +17,7 @@ func TestStartNewGame(t *testing.T) {
 	randGen := randomness.NewMockRandomGenerator([]domain.Move{})
 	gameUseCase := NewGameUseCase(repo, randGen)
 
-	err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, "Player1", "Player2")
+	err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Player1", "Player2")
 	assert.NoError(t, err)
 
 	assert.NotNil(t, gameUseCase.currentGame)
@@ -70,7 +71,7 @@ func TestStartNewGameWithInvalidNames(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, tt.player1, tt.player2)
+			err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, tt.player1, tt.player2)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Equal(t, tt.errMsg, err.Error())
@@ -87,22 +88,29 @@ func TestPlayRoundPlayerVsPlayer(t *testing.T) {
 	randGen := randomness.NewMockRandomGenerator([]domain.Move{})
 	gameUseCase := NewGameUseCase(repo, randGen)
 
-	err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, "Player1", "Player2")
+	err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Player1", "Player2")
 	assert.NoError(t, err)
 
 	// Test first round - Player1 wins
-	result, err := gameUseCase.PlayRound(domain.Rock, domain.Scissors)
+	result, err := gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Scissors)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Empty(t, result.Winner)
 	assert.Len(t, gameUseCase.currentRounds, 1)
 
 	// Test second round - Player1 wins again
-	result, err = gameUseCase.PlayRound(domain.Paper, domain.Rock)
+	result, err = gameUseCase.PlayRound(context.Background(), domain.Paper, domain.Rock)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, "Player1", result.Winner)
 	assert.Empty(t, gameUseCase.currentGame)
+
+	// Rounds are saved with the game
+	assert.Len(t, repo.Games, 1)
+	assert.Equal(t, []domain.RoundResult{
+		{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Player1"},
+		{Move1: domain.Paper, Move2: domain.Rock, Winner: "Player1"},
+	}, repo.Games[0].Rounds)
 }
 
 func TestPlayRoundPlayerVsBot(t *testing.T) {
@@ -110,53 +118,322 @@ func TestPlayRoundPlayerVsBot(t *testing.T) {
 	randGen := randomness.NewMockRandomGenerator([]domain.Move{domain.Rock, domain.Rock, domain.Rock})
 	gameUseCase := NewGameUseCase(repo, randGen)
 
-	err := gameUseCase.StartNewGame(domain.PlayerVsBot, "Player1", "Bot")
+	err := gameUseCase.StartNewGame(domain.PlayerVsBot, domain.DefaultMatchFormat, domain.RockPaperScissors, "Player1", "Bot")
 	assert.NoError(t, err)
 
 	// Test first round - Player1 wins (Paper beats Rock)
-	result, err := gameUseCase.PlayRound(domain.Paper, 0)
+	result, err := gameUseCase.PlayRound(context.Background(), domain.Paper, 0)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Empty(t, result.Winner)
 	assert.Len(t, gameUseCase.currentRounds, 1)
 
 	// Test second round - Player1 wins (Paper beats Rock)
-	result, err = gameUseCase.PlayRound(domain.Paper, 0)
+	result, err = gameUseCase.PlayRound(context.Background(), domain.Paper, 0)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, "Player1", result.Winner)
 	assert.Empty(t, gameUseCase.currentGame)
 }
 
+func TestPlayRoundUndoneWhenGameCannotBeSaved(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{domain.Rock, domain.Rock})
+	gameUseCase := NewGameUseCase(repo, randGen)
+
+	err := gameUseCase.StartNewGame(domain.PlayerVsBot, domain.DefaultMatchFormat, domain.RockPaperScissors, "Player1", "Bot")
+	assert.NoError(t, err)
+	_, err = gameUseCase.PlayRound(context.Background(), domain.Paper, 0)
+	assert.NoError(t, err)
+	commitment := gameUseCase.GetBotCommitment()
+
+	// The storage gives up on the last round, which can be played again
+	// against the same committed move
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = gameUseCase.PlayRound(ctx, domain.Paper, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, gameUseCase.GetCurrentRounds(), 1)
+	assert.Empty(t, gameUseCase.GetCurrentGame().Winner)
+	assert.Equal(t, commitment, gameUseCase.GetBotCommitment())
+	assert.Empty(t, repo.Games)
+
+	result, err := gameUseCase.PlayRound(context.Background(), domain.Paper, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Player1", result.Winner)
+	assert.Len(t, result.Rounds, 2)
+	assert.Len(t, repo.Games, 1)
+}
+
 func TestPlayRoundDraw(t *testing.T) {
 	repo := repository.NewMockRepository()
 	randGen := randomness.NewMockRandomGenerator([]domain.Move{})
 	gameUseCase := NewGameUseCase(repo, randGen)
 
-	gameUseCase.StartNewGame(domain.PlayerVsPlayer, "Player1", "Player2")
+	gameUseCase.StartNewGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Player1", "Player2")
 
 	// Test first round - Draw
-	result, err := gameUseCase.PlayRound(domain.Rock, domain.Rock)
+	result, err := gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Rock)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Empty(t, result.Winner)
 	assert.Len(t, gameUseCase.currentRounds, 1)
 
 	// Test second round - Draw
-	result, err = gameUseCase.PlayRound(domain.Paper, domain.Paper)
+	result, err = gameUseCase.PlayRound(context.Background(), domain.Paper, domain.Paper)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Empty(t, result.Winner)
 	assert.Len(t, gameUseCase.currentRounds, 2)
 
 	// Test third round - Draw
-	result, err = gameUseCase.PlayRound(domain.Scissors, domain.Scissors)
+	result, err = gameUseCase.PlayRound(context.Background(), domain.Scissors, domain.Scissors)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, "Draw", result.Winner)
 	assert.Empty(t, gameUseCase.currentGame)
 }
 
+func TestPlayRoundMatchFormats(t *testing.T) {
+	win := [2]domain.Move{domain.Rock, domain.Scissors}
+	loss := [2]domain.Move{domain.Scissors, domain.Rock}
+	draw := [2]domain.Move{domain.Paper, domain.Paper}
+
+	tests := []struct {
+		name   string
+		format domain.MatchFormat
+		rounds [][2]domain.Move
+		winner string
+	}{
+		{
+			name:   "best of 5 ends once a player wins 3 rounds",
+			format: domain.MatchFormat{Type: domain.BestOf, Rounds: 5},
+			rounds: [][2]domain.Move{win, loss, win, win},
+			winner: "Player1",
+		},
+		{
+			name:   "best of 7 is a draw when tied after all rounds",
+			format: domain.MatchFormat{Type: domain.BestOf, Rounds: 7},
+			rounds: [][2]domain.Move{win, loss, win, loss, draw, win, loss},
+			winner: "Draw",
+		},
+		{
+			name:   "first to 2 ignores draws",
+			format: domain.MatchFormat{Type: domain.FirstTo, Rounds: 2},
+			rounds: [][2]domain.Move{draw, draw, loss, draw, win, loss},
+			winner: "Player2",
+		},
+		{
+			name:   "fixed rounds are always played in full",
+			format: domain.MatchFormat{Type: domain.FixedRounds, Rounds: 3},
+			rounds: [][2]domain.Move{win, win, loss},
+			winner: "Player1",
+		},
+		{
+			name:   "sudden death continues after a tie",
+			format: domain.MatchFormat{Type: domain.SuddenDeath, Rounds: 3},
+			rounds: [][2]domain.Move{win, loss, draw, draw, loss},
+			winner: "Player2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMockRepository()
+			randGen := randomness.NewMockRandomGenerator([]domain.Move{})
+			gameUseCase := NewGameUseCase(repo, randGen)
+
+			err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, tt.format, domain.RockPaperScissors, "Player1", "Player2")
+			assert.NoError(t, err)
+
+			var result *domain.Game
+			for i, moves := range tt.rounds {
+				result, err = gameUseCase.PlayRound(context.Background(), moves[0], moves[1])
+				assert.NoError(t, err)
+				if i < len(tt.rounds)-1 {
+					assert.Empty(t, result.Winner, "game ended early after round %d", i+1)
+				}
+			}
+
+			assert.Equal(t, tt.winner, result.Winner)
+			assert.Nil(t, gameUseCase.currentGame)
+			assert.Len(t, repo.Games, 1)
+		})
+	}
+}
+
+func TestStartNewGameWithInvalidFormat(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{})
+	gameUseCase := NewGameUseCase(repo, randGen)
+
+	err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, domain.MatchFormat{Type: domain.BestOf}, domain.RockPaperScissors, "Player1", "Player2")
+	assert.EqualError(t, err, "invalid match format: number of rounds must be at least 1")
+	assert.Nil(t, gameUseCase.currentGame)
+}
+
+func TestPlayRoundRockPaperScissorsLizardSpock(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{domain.Spock, domain.Rock})
+	gameUseCase := NewGameUseCase(repo, randGen)
+
+	err := gameUseCase.StartNewGame(domain.PlayerVsBot, domain.DefaultMatchFormat, domain.RockPaperScissorsLizardSpock, "Player1", "Bot")
+	assert.NoError(t, err)
+
+	// Lizard poisons Spock
+	result, err := gameUseCase.PlayRound(context.Background(), domain.Lizard, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Winner)
+	assert.Equal(t, "Player1", gameUseCase.currentRounds[0].Winner)
+
+	// Spock vaporizes Rock
+	result, err = gameUseCase.PlayRound(context.Background(), domain.Spock, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Player1", result.Winner)
+	assert.Equal(t, "rpsls", repo.Games[0].RuleSet)
+}
+
+func TestPlayRoundInvalidMoveForRuleSet(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{})
+	gameUseCase := NewGameUseCase(repo, randGen)
+
+	err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Player1", "Player2")
+	assert.NoError(t, err)
+
+	_, err = gameUseCase.PlayRound(context.Background(), domain.Lizard, domain.Rock)
+	assert.EqualError(t, err, "invalid move for Rock Paper Scissors")
+	assert.Empty(t, gameUseCase.currentRounds)
+}
+
+func TestPlayRoundBotStrategySeesHistory(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{domain.Scissors})
+	gameUseCase := NewGameUseCase(repo, randGen)
+	gameUseCase.SetStrategies(randomness.NewStrategies(randGen))
+
+	err := gameUseCase.SetBotDifficulty(domain.Medium)
+	assert.NoError(t, err)
+
+	err = gameUseCase.StartNewGame(domain.PlayerVsBot, domain.MatchFormat{Type: domain.FixedRounds, Rounds: 3}, domain.RockPaperScissors, "Player1", "Bot")
+	assert.NoError(t, err)
+
+	// Without history the bot falls back to the random generator
+	_, err = gameUseCase.PlayRound(context.Background(), domain.Rock, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Scissors, gameUseCase.currentRounds[0].Move2)
+
+	// Then it counters the most frequent move
+	_, err = gameUseCase.PlayRound(context.Background(), domain.Rock, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Paper, gameUseCase.currentRounds[1].Move2)
+
+	result, err := gameUseCase.PlayRound(context.Background(), domain.Rock, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Paper, result.Rounds[2].Move2)
+	assert.Equal(t, "Bot", result.Winner)
+}
+
+func TestSetBotDifficultyUnavailable(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{})
+	gameUseCase := NewGameUseCase(repo, randGen)
+
+	assert.NoError(t, gameUseCase.SetBotDifficulty(domain.Easy))
+	assert.EqualError(t, gameUseCase.SetBotDifficulty(domain.Expert), "difficulty Expert is not available")
+}
+
+func TestBotCommitsBeforeEachRound(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{domain.Scissors, domain.Scissors})
+	gameUseCase := NewGameUseCase(repo, randGen)
+
+	assert.Empty(t, gameUseCase.GetBotCommitment())
+
+	err := gameUseCase.StartNewGame(domain.PlayerVsBot, domain.DefaultMatchFormat, domain.RockPaperScissors, "Player1", "Bot")
+	assert.NoError(t, err)
+
+	commitments := []string{}
+	for i := 0; i < 2; i++ {
+		commitment := gameUseCase.GetBotCommitment()
+		assert.Len(t, commitment, 64)
+		assert.NotContains(t, commitments, commitment)
+		commitments = append(commitments, commitment)
+
+		_, err = gameUseCase.PlayRound(context.Background(), domain.Rock, 0)
+		assert.NoError(t, err)
+	}
+
+	// The game is over, nothing left to commit to
+	assert.Empty(t, gameUseCase.GetBotCommitment())
+
+	game := repo.Games[0]
+	for i, round := range game.Rounds {
+		assert.Equal(t, commitments[i], round.Commitment)
+		assert.NoError(t, round.VerifyCommitment())
+	}
+
+	verified, err := gameUseCase.VerifyGame(context.Background(), game.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, game, verified)
+}
+
+func TestVerifyGameDetectsTampering(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{domain.Rock})
+	gameUseCase := NewGameUseCase(repo, randGen)
+
+	err := gameUseCase.StartNewGame(domain.PlayerVsBot, domain.DefaultMatchFormat, domain.RockPaperScissors, "Player1", "Bot")
+	assert.NoError(t, err)
+	_, err = gameUseCase.PlayRound(context.Background(), domain.Paper, 0)
+	assert.NoError(t, err)
+	result, err := gameUseCase.PlayRound(context.Background(), domain.Paper, 0)
+	assert.NoError(t, err)
+
+	result.Rounds[1].Move2 = domain.Scissors
+	_, err = gameUseCase.VerifyGame(context.Background(), result.ID)
+	assert.EqualError(t, err, "round 2: commitment does not match Scissors")
+
+	_, err = gameUseCase.VerifyGame(context.Background(), "unknown")
+	assert.EqualError(t, err, "game unknown not found")
+
+	repo.Games = append(repo.Games, &domain.Game{ID: "pvp", Mode: domain.PlayerVsPlayer})
+	_, err = gameUseCase.VerifyGame(context.Background(), "pvp")
+	assert.EqualError(t, err, "only games against the bot have commitments")
+}
+
+func TestSeededBotGameReplays(t *testing.T) {
+	for _, difficulty := range []domain.Difficulty{domain.Easy, domain.Expert} {
+		repo := repository.NewMockRepository()
+		randGen := randomness.NewSeededRandomGenerator(42)
+		gameUseCase := NewGameUseCase(repo, randGen)
+		gameUseCase.SetStrategies(randomness.NewStrategies(randGen))
+		assert.NoError(t, gameUseCase.SetBotDifficulty(difficulty))
+
+		format := domain.MatchFormat{Type: domain.FixedRounds, Rounds: 6}
+		for _, rules := range domain.RuleSets() {
+			err := gameUseCase.StartNewGame(domain.PlayerVsBot, format, rules, "Player1", "Bot")
+			assert.NoError(t, err)
+			for i := 0; i < format.Rounds; i++ {
+				_, err = gameUseCase.PlayRound(context.Background(), rules.Moves[i%len(rules.Moves)], 0)
+				assert.NoError(t, err)
+			}
+		}
+
+		assert.Len(t, repo.Games, 2)
+		assert.NotEqual(t, *repo.Games[0].Seed, *repo.Games[1].Seed)
+		for _, game := range repo.Games {
+			assert.Equal(t, difficulty, game.Difficulty)
+
+			moves, err := randomness.ReplayBotMoves(game)
+			assert.NoError(t, err)
+			for i, round := range game.Rounds {
+				assert.Equal(t, round.Move2, moves[i])
+			}
+		}
+	}
+}
+
 func TestGetHistory(t *testing.T) {
 	repo := repository.NewMockRepository()
 	randGen := randomness.NewMockRandomGenerator([]domain.Move{})
@@ -180,13 +457,66 @@ func TestGetHistory(t *testing.T) {
 	}
 
 	for _, game := range testGames {
-		err := repo.SaveGame(game)
+		err := repo.SaveGame(context.Background(), game)
 		assert.NoError(t, err)
 	}
 
-	history, err := gameUseCase.GetHistory()
+	history, err := gameUseCase.GetHistory(context.Background())
 	assert.NoError(t, err)
 	assert.Len(t, history, 2)
 	assert.Equal(t, testGames[0].ID, history[0].ID)
 	assert.Equal(t, testGames[1].ID, history[1].ID)
 }
+
+// batchRepository stores games many at once, and keeps the size of each
+// batch.
+type batchRepository struct {
+	*repository.MockRepository
+	batches []int
+}
+
+func (r *batchRepository) SaveGames(ctx context.Context, games []*domain.Game) error {
+	r.batches = append(r.batches, len(games))
+	for _, game := range games {
+		if err := r.SaveGame(ctx, game); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestImportGames(t *testing.T) {
+	games := func() []*domain.Game {
+		return []*domain.Game{
+			{ID: "game1", Player1: "Alice", Player2: "Bob", Winner: "Bob", RuleSet: "rpsls", PlayedAt: "2025-01-01T10:00:00Z"},
+			{Player1: "Carol", Player2: "Bot", Winner: "Draw", Mode: domain.PlayerVsBot},
+		}
+	}
+
+	batches := &batchRepository{MockRepository: repository.NewMockRepository()}
+	err := NewGameUseCase(batches, randomness.NewMockRandomGenerator(nil)).ImportGames(context.Background(), games())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, batches.batches)
+	assert.Len(t, batches.Games, 2)
+	assert.Equal(t, "game1", batches.Games[0].ID)
+	assert.NotEmpty(t, batches.Games[1].ID)
+	assert.NotEmpty(t, batches.Games[1].PlayedAt)
+	assert.Equal(t, domain.DefaultRuleSet.Name, batches.Games[1].RuleSet)
+
+	repo := repository.NewMockRepository()
+	err = NewGameUseCase(repo, randomness.NewMockRandomGenerator(nil)).ImportGames(context.Background(), games())
+	assert.NoError(t, err)
+	assert.Len(t, repo.Games, 2)
+
+	invalid := games()
+	invalid[1].Winner = "Dave"
+	err = NewGameUseCase(repo, randomness.NewMockRandomGenerator(nil)).ImportGames(context.Background(), invalid)
+	assert.EqualError(t, err, `invalid game 2: winner "Dave" is not a player of the game`)
+	assert.Len(t, repo.Games, 2, "no game is stored when one is invalid")
+
+	invalid = games()
+	invalid[0].PlayedAt = "2025-01-01 10:00"
+	err = NewGameUseCase(repo, randomness.NewMockRandomGenerator(nil)).ImportGames(context.Background(), invalid)
+	assert.ErrorContains(t, err, `invalid game 1: invalid date "2025-01-01 10:00"`)
+	assert.Len(t, repo.Games, 2)
+}
@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"context"
+
+	"protofire-game/internal/domain"
+)
+
+// LiveFeedUseCase follows the games stored by any client of a shared backend,
+// as they are stored.
+type LiveFeedUseCase struct {
+	watcher domain.GameWatcher
+}
+
+func NewLiveFeedUseCase(watcher domain.GameWatcher) *LiveFeedUseCase {
+	return &LiveFeedUseCase{
+		watcher: watcher,
+	}
+}
+
+// Watch calls onGame with each new game, only those of the player when one is
+// given, until the context is done or the feed fails.
+func (u *LiveFeedUseCase) Watch(ctx context.Context, player string, onGame func(*domain.Game)) error {
+	return u.watcher.WatchGames(ctx, func(game *domain.Game) {
+		if player == "" || game.Player1 == player || game.Player2 == player {
+			onGame(game)
+		}
+	})
+}
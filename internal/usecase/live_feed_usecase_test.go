@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"protofire-game/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gamesWatcher reports its games, then fails.
+type gamesWatcher []*domain.Game
+
+var errFeedLost = errors.New("feed lost")
+
+func (w gamesWatcher) WatchGames(ctx context.Context, onGame func(*domain.Game)) error {
+	for _, game := range w {
+		onGame(game)
+	}
+	return errFeedLost
+}
+
+func TestLiveFeedWatch(t *testing.T) {
+	liveFeed := NewLiveFeedUseCase(gamesWatcher{
+		{ID: "1", Player1: "Alice", Player2: "Bob", Winner: "Alice"},
+		{ID: "2", Player1: "Carol", Player2: "Dave", Winner: "Draw"},
+		{ID: "3", Player1: "Dave", Player2: "Alice", Winner: "Dave"},
+	})
+
+	tests := []struct {
+		player string
+		ids    []string
+	}{
+		{"", []string{"1", "2", "3"}},
+		{"Alice", []string{"1", "3"}},
+		{"Erin", nil},
+	}
+	for _, tt := range tests {
+		var ids []string
+		err := liveFeed.Watch(context.Background(), tt.player, func(game *domain.Game) {
+			ids = append(ids, game.ID)
+		})
+		assert.ErrorIs(t, err, errFeedLost)
+		assert.Equal(t, tt.ids, ids, "player %q", tt.player)
+	}
+}
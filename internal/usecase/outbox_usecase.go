@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+
+	"protofire-game/internal/domain"
+)
+
+// OutboxUseCase reports the games queued for a backend reached over the
+// network, and whether it stored them yet.
+type OutboxUseCase struct {
+	repo domain.OutboxRepository
+}
+
+func NewOutboxUseCase(repo domain.OutboxRepository) *OutboxUseCase {
+	return &OutboxUseCase{
+		repo: repo,
+	}
+}
+
+// GetEntries returns the games queued, the most recently queued first, only
+// those with the status when one is given.
+func (u *OutboxUseCase) GetEntries(ctx context.Context, status domain.OutboxStatus) ([]*domain.OutboxEntry, error) {
+	entries, err := u.repo.GetOutboxEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		return entries, nil
+	}
+
+	filtered := []*domain.OutboxEntry{}
+	for _, entry := range entries {
+		if entry.Status == status {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
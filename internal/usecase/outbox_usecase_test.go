@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"protofire-game/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type outboxEntries []*domain.OutboxEntry
+
+func (e outboxEntries) GetOutboxEntries(ctx context.Context) ([]*domain.OutboxEntry, error) {
+	return e, nil
+}
+
+func TestOutboxGetEntries(t *testing.T) {
+	outbox := NewOutboxUseCase(outboxEntries{
+		{Game: &domain.Game{ID: "3"}, Status: domain.OutboxPending, Attempts: 2},
+		{Game: &domain.Game{ID: "2"}, Status: domain.OutboxFailed, TxHash: "0xb2"},
+		{Game: &domain.Game{ID: "1"}, Status: domain.OutboxConfirmed, TxHash: "0xa1"},
+	})
+
+	tests := []struct {
+		status domain.OutboxStatus
+		ids    []string
+	}{
+		{"", []string{"3", "2", "1"}},
+		{domain.OutboxConfirmed, []string{"1"}},
+		{domain.OutboxPending, []string{"3"}},
+	}
+	for _, tt := range tests {
+		entries, err := outbox.GetEntries(context.Background(), tt.status)
+		assert.NoError(t, err)
+		ids := []string{}
+		for _, entry := range entries {
+			ids = append(ids, entry.Game.ID)
+		}
+		assert.Equal(t, tt.ids, ids, "status %q", tt.status)
+	}
+}
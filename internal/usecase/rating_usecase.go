@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"protofire-game/internal/domain"
+)
+
+// RatingUseCase keeps a skill rating per player. When the storage backend
+// cannot persist ratings, they are recomputed from the full game history.
+// It is shared by every game in progress, so updates are serialized.
+type RatingUseCase struct {
+	games       domain.GameRepository
+	ratings     domain.RatingRepository
+	engine      domain.RatingEngine
+	excludeBots bool
+	mu          sync.Mutex
+}
+
+func NewRatingUseCase(games domain.GameRepository, ratings domain.RatingRepository, engine domain.RatingEngine, excludeBots bool) *RatingUseCase {
+	return &RatingUseCase{
+		games:       games,
+		ratings:     ratings,
+		engine:      engine,
+		excludeBots: excludeBots,
+	}
+}
+
+func (r *RatingUseCase) SystemName() string {
+	return r.engine.Name()
+}
+
+func (r *RatingUseCase) RecordGame(ctx context.Context, game *domain.Game) error {
+	if r.ratings == nil || !r.isRated(game) {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Ratings of a backend that just started persisting them are built from
+	// the history, which already includes this game.
+	existing, err := r.ratings.GetRatings(r.engine.Name())
+	if err != nil {
+		return fmt.Errorf("failed to get ratings: %w", err)
+	}
+	if len(existing) == 0 {
+		_, err := r.backfill(ctx)
+		return err
+	}
+
+	player1, err := r.getRating(game.Player1)
+	if err != nil {
+		return err
+	}
+	player2, err := r.getRating(game.Player2)
+	if err != nil {
+		return err
+	}
+
+	player1, player2 = r.engine.Update(player1, player2, score(game))
+
+	if err := r.ratings.SaveRatings(r.engine.Name(), []*domain.Rating{player1, player2}); err != nil {
+		return fmt.Errorf("failed to save ratings: %w", err)
+	}
+	return nil
+}
+
+func (r *RatingUseCase) GetRankings(ctx context.Context) ([]*domain.Rating, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ratings == nil {
+		history, err := r.games.GetGameHistory(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get history: %w", err)
+		}
+		return r.compute(history), nil
+	}
+
+	rankings, err := r.ratings.GetRatings(r.engine.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ratings: %w", err)
+	}
+	if len(rankings) == 0 {
+		return r.backfill(ctx)
+	}
+	return rankings, nil
+}
+
+func (r *RatingUseCase) backfill(ctx context.Context) ([]*domain.Rating, error) {
+	history, err := r.games.GetGameHistory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	rankings := r.compute(history)
+	if len(rankings) == 0 {
+		return rankings, nil
+	}
+	if err := r.ratings.SaveRatings(r.engine.Name(), rankings); err != nil {
+		return nil, fmt.Errorf("failed to save ratings: %w", err)
+	}
+	return rankings, nil
+}
+
+// compute replays every rated game in chronological order.
+func (r *RatingUseCase) compute(history []*domain.Game) []*domain.Rating {
+	games := make([]*domain.Game, 0, len(history))
+	for _, game := range history {
+		if r.isRated(game) {
+			games = append(games, game)
+		}
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		return playedAt(games[i]).Before(playedAt(games[j]))
+	})
+
+	ratingsByPlayer := make(map[string]*domain.Rating)
+	get := func(player string) *domain.Rating {
+		if rating, ok := ratingsByPlayer[player]; ok {
+			return rating
+		}
+		return r.engine.NewRating(player)
+	}
+
+	for _, game := range games {
+		player1, player2 := r.engine.Update(get(game.Player1), get(game.Player2), score(game))
+		ratingsByPlayer[game.Player1] = player1
+		ratingsByPlayer[game.Player2] = player2
+	}
+
+	rankings := make([]*domain.Rating, 0, len(ratingsByPlayer))
+	for _, rating := range ratingsByPlayer {
+		rankings = append(rankings, rating)
+	}
+	sort.Slice(rankings, func(i, j int) bool {
+		if rankings[i].Rating != rankings[j].Rating {
+			return rankings[i].Rating > rankings[j].Rating
+		}
+		return rankings[i].Player < rankings[j].Player
+	})
+
+	return rankings
+}
+
+func (r *RatingUseCase) getRating(player string) (*domain.Rating, error) {
+	rating, err := r.ratings.GetRating(r.engine.Name(), player)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating: %w", err)
+	}
+	if rating == nil {
+		rating = r.engine.NewRating(player)
+	}
+	return rating, nil
+}
+
+func (r *RatingUseCase) isRated(game *domain.Game) bool {
+	if game.Player1 == game.Player2 {
+		return false
+	}
+	return !r.excludeBots || game.Mode != domain.PlayerVsBot
+}
+
+func score(game *domain.Game) float64 {
+	switch game.Winner {
+	case game.Player1:
+		return 1
+	case game.Player2:
+		return 0
+	default:
+		return 0.5
+	}
+}
+
+func playedAt(game *domain.Game) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, game.PlayedAt)
+	return t
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/randomness"
+	"protofire-game/internal/rating"
+	"protofire-game/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatingsUpdatedWhenGameIsSaved(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{})
+	ratingUseCase := NewRatingUseCase(repo, repo, rating.NewElo(32), false)
+	gameUseCase := NewGameUseCase(repo, randGen)
+	gameUseCase.SetRatings(ratingUseCase)
+
+	for i := 0; i < 2; i++ {
+		err := gameUseCase.StartNewGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Alice", "Bob")
+		assert.NoError(t, err)
+		_, err = gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Scissors)
+		assert.NoError(t, err)
+		_, err = gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Scissors)
+		assert.NoError(t, err)
+	}
+
+	rankings, err := ratingUseCase.GetRankings(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, rankings, 2)
+	assert.Equal(t, "Alice", rankings[0].Player)
+	assert.InDelta(t, 1530.5, rankings[0].Rating, 0.1)
+	assert.Equal(t, 2, rankings[0].Games)
+	assert.Equal(t, "Bob", rankings[1].Player)
+	assert.InDelta(t, 1469.5, rankings[1].Rating, 0.1)
+	assert.Equal(t, rankings, mustGetRatings(t, repo, "elo"))
+}
+
+func TestRatingsExcludeBotGames(t *testing.T) {
+	repo := repository.NewMockRepository()
+	randGen := randomness.NewMockRandomGenerator([]domain.Move{domain.Scissors})
+	ratingUseCase := NewRatingUseCase(repo, repo, rating.NewElo(32), true)
+	gameUseCase := NewGameUseCase(repo, randGen)
+	gameUseCase.SetRatings(ratingUseCase)
+
+	err := gameUseCase.StartNewGame(domain.PlayerVsBot, domain.DefaultMatchFormat, domain.RockPaperScissors, "Alice", "Bot")
+	assert.NoError(t, err)
+	_, err = gameUseCase.PlayRound(context.Background(), domain.Rock, 0)
+	assert.NoError(t, err)
+	result, err := gameUseCase.PlayRound(context.Background(), domain.Rock, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", result.Winner)
+
+	rankings, err := ratingUseCase.GetRankings(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, rankings)
+}
+
+func TestRatingsRecomputedFromHistory(t *testing.T) {
+	repo := repository.NewMockRepository()
+	repo.Games = []*domain.Game{
+		{Player1: "Bob", Player2: "Alice", Winner: "Alice", PlayedAt: "2025-01-02T10:00:00Z"},
+		{Player1: "Alice", Player2: "Bot", Winner: "Alice", Mode: domain.PlayerVsBot, PlayedAt: "2025-01-03T10:00:00Z"},
+		{Player1: "Alice", Player2: "Bob", Winner: "Draw", PlayedAt: "2025-01-01T10:00:00Z"},
+	}
+
+	// Without a rating repository, as with the on-chain backend
+	rankings, err := NewRatingUseCase(repo, nil, rating.NewElo(32), true).GetRankings(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, rankings, 2)
+	assert.Equal(t, "Alice", rankings[0].Player)
+	assert.InDelta(t, 1516.0, rankings[0].Rating, 0.1)
+	assert.Empty(t, repo.Ratings)
+
+	// A rating repository without ratings is backfilled
+	ratingUseCase := NewRatingUseCase(repo, repo, rating.NewGlicko2(0.5), false)
+	rankings, err = ratingUseCase.GetRankings(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, rankings, 3)
+	assert.Equal(t, "Alice", rankings[0].Player)
+	assert.Equal(t, 3, rankings[0].Games)
+	assert.Equal(t, rankings, mustGetRatings(t, repo, "glicko2"))
+}
+
+func mustGetRatings(t *testing.T, repo *repository.MockRepository, system string) []*domain.Rating {
+	ratings, err := repo.GetRatings(system)
+	assert.NoError(t, err)
+	return ratings
+}
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"protofire-game/internal/domain"
+)
+
+// DefaultSessionIdleTimeout is how long a game in progress is kept without
+// any round being played.
+const DefaultSessionIdleTimeout = 30 * time.Minute
+
+// SessionState is a snapshot of a game in progress, safe to use after the
+// session moves on.
+type SessionState struct {
+	Game          *domain.Game
+	Format        domain.MatchFormat
+	BotCommitment string
+}
+
+// Finished reports whether the game has a winner and was stored.
+func (s *SessionState) Finished() bool {
+	return s.Game.Winner != ""
+}
+
+type session struct {
+	mu       sync.Mutex
+	game     *GameUseCase
+	lastUsed time.Time
+	closed   bool
+}
+
+// SessionManager runs many games concurrently, each on its own GameUseCase
+// keyed by game ID. Rounds of a game are serialized by the session's lock,
+// rounds of different games run in parallel. Sessions idle for longer than
+// the idle timeout are dropped without storing their game.
+type SessionManager struct {
+	newGame     func() *GameUseCase
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// NewSessionManager creates a manager that plays every game on a use case
+// returned by newGame. The use cases may share repositories, ratings and
+// strategies, which must then be safe for concurrent use.
+func NewSessionManager(newGame func() *GameUseCase, idleTimeout time.Duration) *SessionManager {
+	return &SessionManager{
+		newGame:     newGame,
+		idleTimeout: idleTimeout,
+		now:         time.Now,
+		sessions:    make(map[string]*session),
+	}
+}
+
+// Start starts a new game in its own session. The difficulty is only used
+// against the bot.
+func (m *SessionManager) Start(mode domain.GameType, format domain.MatchFormat, rules *domain.RuleSet, player1, player2 string, difficulty domain.Difficulty) (*SessionState, error) {
+	game := m.newGame()
+	if mode == domain.PlayerVsBot {
+		if err := game.SetBotDifficulty(difficulty); err != nil {
+			return nil, err
+		}
+	}
+	if err := game.StartNewGame(mode, format, rules, player1, player2); err != nil {
+		return nil, err
+	}
+
+	s := &session{game: game, lastUsed: m.now()}
+	state := s.state(game.GetCurrentGame())
+
+	m.mu.Lock()
+	m.sessions[state.Game.ID] = s
+	m.mu.Unlock()
+
+	return state, nil
+}
+
+// PlayRound plays the next round of a game in progress. The session ends
+// once the game is finished.
+func (m *SessionManager) PlayRound(ctx context.Context, gameID string, move1, move2 domain.Move) (*SessionState, error) {
+	s, err := m.get(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The session may have ended while waiting for its lock.
+	if s.closed {
+		return nil, fmt.Errorf("game %s %w", gameID, domain.ErrNotFound)
+	}
+
+	result, err := s.game.PlayRound(ctx, move1, move2)
+	if err != nil {
+		return nil, err
+	}
+	s.lastUsed = m.now()
+
+	state := s.state(result)
+	if state.Finished() {
+		s.closed = true
+		m.remove(gameID)
+	}
+	return state, nil
+}
+
+// Get returns the state of a game in progress.
+func (m *SessionManager) Get(gameID string) (*SessionState, error) {
+	s, err := m.get(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, fmt.Errorf("game %s %w", gameID, domain.ErrNotFound)
+	}
+	return s.state(s.game.GetCurrentGame()), nil
+}
+
+// Len returns the number of games in progress.
+func (m *SessionManager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sessions)
+}
+
+// ExpireIdle drops the sessions idle for longer than the idle timeout and
+// returns how many were dropped.
+func (m *SessionManager) ExpireIdle() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expired := 0
+	for id, s := range m.sessions {
+		// Sessions busy playing a round are not idle.
+		if !s.mu.TryLock() {
+			continue
+		}
+		if m.now().Sub(s.lastUsed) > m.idleTimeout {
+			s.closed = true
+			delete(m.sessions, id)
+			expired++
+		}
+		s.mu.Unlock()
+	}
+	return expired
+}
+
+// RunExpiry expires idle sessions every interval until ctx is done.
+func (m *SessionManager) RunExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.ExpireIdle()
+		}
+	}
+}
+
+func (m *SessionManager) get(gameID string) (*session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[gameID]
+	if !ok {
+		return nil, fmt.Errorf("game %s %w", gameID, domain.ErrNotFound)
+	}
+	return s, nil
+}
+
+func (m *SessionManager) remove(gameID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, gameID)
+}
+
+// state snapshots the game, which the use case keeps updating in place.
+func (s *session) state(game *domain.Game) *SessionState {
+	snapshot := *game
+	snapshot.Rounds = append([]domain.RoundResult(nil), game.Rounds...)
+
+	return &SessionState{
+		Game:          &snapshot,
+		Format:        s.game.GetCurrentFormat(),
+		BotCommitment: s.game.GetBotCommitment(),
+	}
+}
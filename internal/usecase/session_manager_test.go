@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/randomness"
+	"protofire-game/internal/rating"
+	"protofire-game/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSessionManager(repo *repository.MockRepository, seed uint64) *SessionManager {
+	randGen := randomness.NewSeededRandomGenerator(seed)
+	ratings := NewRatingUseCase(repo, repo, rating.NewElo(rating.DefaultEloK), false)
+	return NewSessionManager(func() *GameUseCase {
+		gameRandGen := randGen.Split()
+		game := NewGameUseCase(repo, gameRandGen)
+		game.SetStrategies(randomness.NewStrategies(gameRandGen))
+		game.SetRatings(ratings)
+		return game
+	}, time.Minute)
+}
+
+func TestSessionManagerConcurrentGames(t *testing.T) {
+	repo := repository.NewMockRepository()
+	sessions := newTestSessionManager(repo, 7)
+	format := domain.MatchFormat{Type: domain.FixedRounds, Rounds: 5}
+
+	const games = 20
+	var wg sync.WaitGroup
+	for i := 0; i < games; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			player := fmt.Sprintf("Player%d", i)
+			state, err := sessions.Start(domain.PlayerVsBot, format, domain.RockPaperScissors, player, "Bot", domain.Expert)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for round := 0; round < format.Rounds; round++ {
+				state, err = sessions.PlayRound(context.Background(), state.Game.ID, domain.Move(round%3), 0)
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.Len(t, state.Game.Rounds, round+1)
+			}
+			assert.True(t, state.Finished())
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, sessions.Len())
+	require.Len(t, repo.Games, games)
+
+	// Every game was played from its own seed and can be replayed alone
+	for _, game := range repo.Games {
+		moves, err := randomness.ReplayBotMoves(game)
+		require.NoError(t, err)
+		for i, round := range game.Rounds {
+			assert.Equal(t, round.Move2, moves[i])
+		}
+	}
+
+	rankings, err := repo.GetRatings("elo")
+	require.NoError(t, err)
+	assert.Len(t, rankings, games+1)
+}
+
+func TestSessionManagerRoundsOfOneGame(t *testing.T) {
+	repo := repository.NewMockRepository()
+	sessions := newTestSessionManager(repo, 1)
+
+	format := domain.MatchFormat{Type: domain.FixedRounds, Rounds: 10}
+	state, err := sessions.Start(domain.PlayerVsPlayer, format, domain.RockPaperScissors, "Alice", "Bob", domain.Easy)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < format.Rounds; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sessions.PlayRound(context.Background(), state.Game.ID, domain.Rock, domain.Scissors)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, repo.Games, 1)
+	assert.Len(t, repo.Games[0].Rounds, format.Rounds)
+
+	_, err = sessions.PlayRound(context.Background(), state.Game.ID, domain.Rock, domain.Rock)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestSessionManagerExpiresIdleSessions(t *testing.T) {
+	repo := repository.NewMockRepository()
+	sessions := newTestSessionManager(repo, 1)
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	sessions.now = func() time.Time { return now }
+
+	idle, err := sessions.Start(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Alice", "Bob", domain.Easy)
+	require.NoError(t, err)
+	active, err := sessions.Start(domain.PlayerVsBot, domain.DefaultMatchFormat, domain.RockPaperScissors, "Carol", "Bot", domain.Medium)
+	require.NoError(t, err)
+	assert.NotEmpty(t, active.BotCommitment)
+
+	now = now.Add(45 * time.Second)
+	_, err = sessions.PlayRound(context.Background(), active.Game.ID, domain.Rock, 0)
+	require.NoError(t, err)
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 1, sessions.ExpireIdle())
+	assert.Equal(t, 1, sessions.Len())
+
+	_, err = sessions.Get(idle.Game.ID)
+	assert.EqualError(t, err, fmt.Sprintf("game %s not found", idle.Game.ID))
+
+	state, err := sessions.Get(active.Game.ID)
+	require.NoError(t, err)
+	assert.Len(t, state.Game.Rounds, 1)
+	assert.Equal(t, domain.Medium, state.Game.Difficulty)
+	assert.Empty(t, repo.Games)
+}
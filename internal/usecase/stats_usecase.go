@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"protofire-game/internal/domain"
+)
+
+type StatsUseCase struct {
+	repository domain.StatsRepository
+}
+
+func NewStatsUseCase(repo domain.StatsRepository) *StatsUseCase {
+	return &StatsUseCase{
+		repository: repo,
+	}
+}
+
+func (s *StatsUseCase) GetLeaderboard(ctx context.Context, limit int) ([]*domain.PlayerStats, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("limit cannot be negative")
+	}
+	return s.repository.GetLeaderboard(ctx, limit)
+}
+
+func (s *StatsUseCase) GetPlayerStats(ctx context.Context, player string) (*domain.PlayerStats, []*domain.HeadToHead, error) {
+	if err := domain.ValidatePlayerName(player); err != nil {
+		return nil, nil, fmt.Errorf("invalid player name: %w", err)
+	}
+
+	stats, err := s.repository.GetPlayerStats(ctx, player)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get player stats: %w", err)
+	}
+
+	headToHead, err := s.repository.GetHeadToHead(ctx, player)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get head-to-head records: %w", err)
+	}
+
+	return stats, headToHead, nil
+}
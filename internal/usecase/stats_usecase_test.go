@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStatsTestRepository() *repository.MockRepository {
+	repo := repository.NewMockRepository()
+	repo.Games = []*domain.Game{
+		{ID: "1", Player1: "Alice", Player2: "Bob", Winner: "Alice", PlayedAt: "2025-01-01T10:00:00Z",
+			Rounds: []domain.RoundResult{
+				{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Alice"},
+				{Move1: domain.Rock, Move2: domain.Scissors, Winner: "Alice"},
+			}},
+		{ID: "2", Player1: "Bob", Player2: "Alice", Winner: "Alice", PlayedAt: "2025-01-02T10:00:00Z",
+			Rounds: []domain.RoundResult{
+				{Move1: domain.Scissors, Move2: domain.Paper, Winner: "Bob"},
+				{Move1: domain.Scissors, Move2: domain.Rock, Winner: "Alice"},
+				{Move1: domain.Paper, Move2: domain.Scissors, Winner: "Alice"},
+			}},
+		{ID: "3", Player1: "Alice", Player2: "Carol", Winner: "Carol", PlayedAt: "2025-01-03T10:00:00Z"},
+		{ID: "4", Player1: "Alice", Player2: "Bob", Winner: "Draw", PlayedAt: "2025-01-04T10:00:00Z"},
+		{ID: "5", Player1: "Carol", Player2: "Bob", Winner: "Carol", PlayedAt: "2025-01-05T10:00:00Z"},
+		{ID: "6", Player1: "Alice", Player2: "Carol", Winner: "Alice", PlayedAt: "2025-01-06T10:00:00Z"},
+	}
+	return repo
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	statsUseCase := NewStatsUseCase(newStatsTestRepository())
+
+	leaderboard, err := statsUseCase.GetLeaderboard(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*domain.PlayerStats{
+		{Player: "Alice", Games: 5, Wins: 3, Losses: 1, Draws: 1, LongestStreak: 2, FavoriteMove: "Rock"},
+		{Player: "Carol", Games: 3, Wins: 2, Losses: 1, Draws: 0, LongestStreak: 2},
+		{Player: "Bob", Games: 4, Wins: 0, Losses: 3, Draws: 1, LongestStreak: 0, FavoriteMove: "Scissors"},
+	}, leaderboard)
+
+	leaderboard, err = statsUseCase.GetLeaderboard(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, leaderboard, 1)
+
+	_, err = statsUseCase.GetLeaderboard(context.Background(), -1)
+	assert.Error(t, err)
+}
+
+func TestGetPlayerStats(t *testing.T) {
+	statsUseCase := NewStatsUseCase(newStatsTestRepository())
+
+	stats, headToHead, err := statsUseCase.GetPlayerStats(context.Background(), "Alice")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.Wins)
+	assert.InDelta(t, 0.6, stats.WinRate(), 0.0001)
+	assert.Equal(t, []*domain.HeadToHead{
+		{Player: "Alice", Opponent: "Bob", Wins: 2, Losses: 0, Draws: 1},
+		{Player: "Alice", Opponent: "Carol", Wins: 1, Losses: 1, Draws: 0},
+	}, headToHead)
+
+	stats, headToHead, err = statsUseCase.GetPlayerStats(context.Background(), "Nobody")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.Games)
+	assert.Empty(t, headToHead)
+
+	_, _, err = statsUseCase.GetPlayerStats(context.Background(), "")
+	assert.EqualError(t, err, "invalid player name: name cannot be empty")
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"protofire-game/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// TournamentUseCase runs tournaments whose matches are Player vs Player
+// games played on a GameUseCase. The tournament is stored after every
+// match, so it can be resumed after a restart.
+type TournamentUseCase struct {
+	repository domain.TournamentRepository
+	games      *GameUseCase
+}
+
+func NewTournamentUseCase(repo domain.TournamentRepository, games *GameUseCase) *TournamentUseCase {
+	return &TournamentUseCase{
+		repository: repo,
+		games:      games,
+	}
+}
+
+// CreateTournament seeds the players in the given order and pairs the first
+// round. A Swiss tournament without a number of rounds plays enough rounds
+// to single out a winner.
+func (u *TournamentUseCase) CreateTournament(name string, format domain.TournamentFormat, matchFormat domain.MatchFormat, rules *domain.RuleSet, players []string, swissRounds int) (*domain.Tournament, error) {
+	if name == "" {
+		return nil, fmt.Errorf("tournament name cannot be empty")
+	}
+	if rules == nil {
+		return nil, fmt.Errorf("rule set is required")
+	}
+
+	tournament, err := domain.NewTournament(uuid.New().String(), name, format, matchFormat, rules, players, swissRounds, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.repository.SaveTournament(tournament); err != nil {
+		return nil, fmt.Errorf("failed to save tournament: %w", err)
+	}
+	return tournament, nil
+}
+
+func (u *TournamentUseCase) GetTournament(id string) (*domain.Tournament, error) {
+	tournament, err := u.repository.GetTournament(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tournament: %w", err)
+	}
+	if tournament == nil {
+		return nil, fmt.Errorf("tournament %s %w", id, domain.ErrNotFound)
+	}
+	return tournament, nil
+}
+
+func (u *TournamentUseCase) ListTournaments() ([]*domain.Tournament, error) {
+	tournaments, err := u.repository.ListTournaments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tournaments: %w", err)
+	}
+	return tournaments, nil
+}
+
+// StartMatch starts the game of a pending match. Its rounds are played on
+// the game use case as any other game, then FinishMatch records the result.
+// The game is only stored once over: a match interrupted by an exit is
+// played again when the tournament is resumed.
+func (u *TournamentUseCase) StartMatch(tournament *domain.Tournament, match *domain.TournamentMatch) error {
+	if match.Played() {
+		return fmt.Errorf("match %s vs %s was already played", match.Player1, match.Player2)
+	}
+
+	rules, err := domain.RuleSetByName(tournament.RuleSet)
+	if err != nil {
+		return err
+	}
+	return u.games.startGame(domain.PlayerVsPlayer, tournament.MatchFormat, rules, match.Player1, match.Player2)
+}
+
+// FinishMatch records the finished game of a match and stores the
+// tournament. A drawn elimination match stays pending and is played again.
+func (u *TournamentUseCase) FinishMatch(tournament *domain.Tournament, match *domain.TournamentMatch, game *domain.Game) error {
+	if game == nil || game.Winner == "" {
+		return errors.New("game is not finished")
+	}
+	if game.Player1 != match.Player1 || game.Player2 != match.Player2 {
+		return fmt.Errorf("game %s was not played by %s and %s", game.ID, match.Player1, match.Player2)
+	}
+
+	if err := tournament.Record(match, game.Winner, game.ID); err != nil {
+		return err
+	}
+	if err := u.repository.SaveTournament(tournament); err != nil {
+		return fmt.Errorf("failed to save tournament: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/randomness"
+	"protofire-game/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTournamentUseCase(repo *repository.MockRepository) (*TournamentUseCase, *GameUseCase) {
+	games := NewGameUseCase(repo, randomness.NewMockRandomGenerator([]domain.Move{}))
+	return NewTournamentUseCase(repo, games), games
+}
+
+// playMatch plays a match where winner wins every round, or draws every
+// round when winner is "Draw".
+func playMatch(t *testing.T, tournaments *TournamentUseCase, games *GameUseCase, tournament *domain.Tournament, match *domain.TournamentMatch, winner string) {
+	t.Helper()
+	require.NoError(t, tournaments.StartMatch(tournament, match))
+
+	move1, move2 := domain.Rock, domain.Scissors
+	switch winner {
+	case match.Player2:
+		move1, move2 = domain.Scissors, domain.Rock
+	case "Draw":
+		move2 = domain.Rock
+	}
+
+	for {
+		game, err := games.PlayRound(context.Background(), move1, move2)
+		require.NoError(t, err)
+		if game.Winner != "" {
+			require.NoError(t, tournaments.FinishMatch(tournament, match, game))
+			return
+		}
+	}
+}
+
+// playTournament plays every match until the tournament is over, the better
+// seed always winning.
+func playTournament(t *testing.T, tournaments *TournamentUseCase, games *GameUseCase, tournament *domain.Tournament) {
+	t.Helper()
+	seeds := make(map[string]int)
+	for i, player := range tournament.Players {
+		seeds[player] = i
+	}
+
+	for !tournament.Finished() {
+		pending := tournament.Pending()
+		require.NotEmpty(t, pending)
+		for _, match := range pending {
+			winner := match.Player1
+			if seeds[match.Player2] < seeds[match.Player1] {
+				winner = match.Player2
+			}
+			playMatch(t, tournaments, games, tournament, match, winner)
+		}
+	}
+}
+
+func countGames(tournament *domain.Tournament) int {
+	games := 0
+	for _, match := range tournament.Matches {
+		if !match.IsBye() {
+			games++
+		}
+	}
+	return games
+}
+
+func TestSingleEliminationTournament(t *testing.T) {
+	repo := repository.NewMockRepository()
+	tournaments, games := newTestTournamentUseCase(repo)
+
+	players := []string{"Alice", "Bob", "Carol", "Dave", "Erin"}
+	tournament, err := tournaments.CreateTournament("Cup", domain.SingleElimination, domain.MatchFormat{Type: domain.FirstTo, Rounds: 1}, domain.RockPaperScissors, players, 0)
+	require.NoError(t, err)
+
+	// The top seeds get the byes of a bracket of 8
+	var first []string
+	for _, match := range tournament.Round(1) {
+		first = append(first, match.Player1+"-"+match.Player2)
+	}
+	assert.Equal(t, []string{"Alice-", "Dave-Erin", "Bob-", "Carol-"}, first)
+
+	playTournament(t, tournaments, games, tournament)
+
+	assert.Equal(t, "Alice", tournament.Winner)
+	assert.Equal(t, 3, tournament.CurrentRound())
+	assert.Equal(t, len(players)-1, countGames(tournament))
+	assert.Len(t, repo.Games, len(players)-1)
+
+	standings := tournament.Standings()
+	assert.Equal(t, "Alice", standings[0].Player)
+	assert.False(t, standings[0].Eliminated)
+	assert.Equal(t, "Bob", standings[1].Player)
+	assert.True(t, standings[1].Eliminated)
+}
+
+func TestDoubleEliminationTournament(t *testing.T) {
+	repo := repository.NewMockRepository()
+	tournaments, games := newTestTournamentUseCase(repo)
+
+	players := []string{"Alice", "Bob", "Carol", "Dave"}
+	tournament, err := tournaments.CreateTournament("Cup", domain.DoubleElimination, domain.MatchFormat{Type: domain.FirstTo, Rounds: 1}, domain.RockPaperScissors, players, 0)
+	require.NoError(t, err)
+
+	playTournament(t, tournaments, games, tournament)
+
+	assert.Equal(t, "Alice", tournament.Winner)
+	assert.Equal(t, 2*len(players)-2, countGames(tournament))
+
+	last := tournament.Matches[len(tournament.Matches)-1]
+	assert.Equal(t, domain.GrandFinal, last.Bracket)
+	assert.Equal(t, "Alice", last.Player1)
+	assert.Equal(t, "Bob", last.Player2)
+
+	// Everyone but the winner lost twice
+	for _, standing := range tournament.Standings()[1:] {
+		assert.Equal(t, 2, standing.Losses, standing.Player)
+	}
+}
+
+func TestDoubleEliminationGrandFinalReset(t *testing.T) {
+	repo := repository.NewMockRepository()
+	tournaments, games := newTestTournamentUseCase(repo)
+
+	tournament, err := tournaments.CreateTournament("Cup", domain.DoubleElimination, domain.MatchFormat{Type: domain.FirstTo, Rounds: 1}, domain.RockPaperScissors, []string{"Alice", "Bob"}, 0)
+	require.NoError(t, err)
+
+	playMatch(t, tournaments, games, tournament, tournament.Pending()[0], "Alice")
+	final := tournament.Pending()
+	require.Len(t, final, 1)
+	assert.Equal(t, domain.GrandFinal, final[0].Bracket)
+
+	// Bob comes back from the losers bracket, so the final is played again
+	playMatch(t, tournaments, games, tournament, final[0], "Bob")
+	reset := tournament.Pending()
+	require.Len(t, reset, 1)
+	assert.Equal(t, domain.GrandFinal, reset[0].Bracket)
+
+	playMatch(t, tournaments, games, tournament, reset[0], "Bob")
+	assert.Equal(t, "Bob", tournament.Winner)
+}
+
+func TestRoundRobinTournament(t *testing.T) {
+	repo := repository.NewMockRepository()
+	tournaments, games := newTestTournamentUseCase(repo)
+
+	players := []string{"Alice", "Bob", "Carol", "Dave", "Erin"}
+	tournament, err := tournaments.CreateTournament("League", domain.RoundRobin, domain.DefaultMatchFormat, domain.RockPaperScissors, players, 0)
+	require.NoError(t, err)
+
+	playTournament(t, tournaments, games, tournament)
+
+	// Everyone met everyone once, in 5 rounds of 2 games
+	assert.Equal(t, 5, tournament.CurrentRound())
+	met := make(map[[2]string]int)
+	for _, match := range tournament.Matches {
+		pair := [2]string{match.Player1, match.Player2}
+		if pair[1] < pair[0] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		met[pair]++
+	}
+	assert.Len(t, met, 10)
+	for pair, count := range met {
+		assert.Equal(t, 1, count, pair)
+	}
+
+	standings := tournament.Standings()
+	for i, player := range players {
+		assert.Equal(t, player, standings[i].Player)
+		assert.Equal(t, float64(len(players)-1-i), standings[i].Points)
+	}
+	assert.Equal(t, "Alice", tournament.Winner)
+}
+
+func TestSwissTournament(t *testing.T) {
+	repo := repository.NewMockRepository()
+	tournaments, games := newTestTournamentUseCase(repo)
+
+	players := []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace"}
+	tournament, err := tournaments.CreateTournament("Open", domain.Swiss, domain.DefaultMatchFormat, domain.RockPaperScissors, players, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, tournament.SwissRounds)
+
+	playTournament(t, tournaments, games, tournament)
+
+	assert.Equal(t, 3, tournament.CurrentRound())
+	met := make(map[[2]string]bool)
+	byes := make(map[string]bool)
+	for _, match := range tournament.Matches {
+		if match.IsBye() {
+			assert.False(t, byes[match.Player1], "second bye for %s", match.Player1)
+			byes[match.Player1] = true
+			continue
+		}
+		pair := [2]string{match.Player1, match.Player2}
+		if pair[1] < pair[0] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		assert.False(t, met[pair], "rematch %v", pair)
+		met[pair] = true
+	}
+	assert.Len(t, byes, 3)
+
+	standings := tournament.Standings()
+	assert.Equal(t, "Alice", standings[0].Player)
+	assert.Equal(t, 3.0, standings[0].Points)
+	assert.Equal(t, "Alice", tournament.Winner)
+}
+
+func TestDrawnEliminationMatchIsReplayed(t *testing.T) {
+	repo := repository.NewMockRepository()
+	tournaments, games := newTestTournamentUseCase(repo)
+
+	tournament, err := tournaments.CreateTournament("Cup", domain.SingleElimination, domain.MatchFormat{Type: domain.FixedRounds, Rounds: 1}, domain.RockPaperScissors, []string{"Alice", "Bob"}, 0)
+	require.NoError(t, err)
+
+	match := tournament.Pending()[0]
+	playMatch(t, tournaments, games, tournament, match, "Draw")
+	assert.False(t, match.Played())
+	assert.Equal(t, repo.Games[0].ID, match.GameID)
+
+	playMatch(t, tournaments, games, tournament, match, "Bob")
+	assert.Equal(t, "Bob", tournament.Winner)
+	assert.Equal(t, repo.Games[1].ID, match.GameID)
+}
+
+func TestTournamentResumesFromRepository(t *testing.T) {
+	repo := repository.NewMockRepository()
+	tournaments, games := newTestTournamentUseCase(repo)
+
+	players := []string{"Alice", "Bob", "Carol", "Dave"}
+	tournament, err := tournaments.CreateTournament("League", domain.RoundRobin, domain.DefaultMatchFormat, domain.RockPaperScissors, players, 0)
+	require.NoError(t, err)
+	playMatch(t, tournaments, games, tournament, tournament.Pending()[0], "Alice")
+
+	// A new process picks the tournament up where it was left
+	tournaments, games = newTestTournamentUseCase(repo)
+	resumed, err := tournaments.GetTournament(tournament.ID)
+	require.NoError(t, err)
+	assert.Equal(t, tournament, resumed)
+	require.Len(t, resumed.Pending(), 1)
+
+	playTournament(t, tournaments, games, resumed)
+	assert.Equal(t, "Alice", resumed.Winner)
+
+	stored, err := tournaments.ListTournaments()
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "Alice", stored[0].Winner)
+	assert.Len(t, stored[0].Matches, 6)
+}
+
+func TestCreateTournamentErrors(t *testing.T) {
+	tournaments, _ := newTestTournamentUseCase(repository.NewMockRepository())
+
+	tests := []struct {
+		name        string
+		format      domain.TournamentFormat
+		players     []string
+		swissRounds int
+		wantErr     string
+	}{
+		{"one player", domain.RoundRobin, []string{"Alice"}, 0, "a tournament needs at least 2 players"},
+		{"duplicate player", domain.RoundRobin, []string{"Alice", "Bob", "alice"}, 0, "player alice is entered twice"},
+		{"invalid name", domain.SingleElimination, []string{"Alice", ""}, 0, `invalid player name "": name cannot be empty`},
+		{"too many Swiss rounds", domain.Swiss, []string{"Alice", "Bob", "Carol", "Dave"}, 4, "a Swiss tournament of 4 players needs between 1 and 3 rounds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tournaments.CreateTournament("Cup", tt.format, domain.DefaultMatchFormat, domain.RockPaperScissors, tt.players, tt.swissRounds)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	_, err := tournaments.GetTournament("missing")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+
+	"protofire-game/internal/domain"
+	"protofire-game/internal/randomness"
+	"protofire-game/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnfinishedGameIsStoredAfterEveryRound(t *testing.T) {
+	repo := repository.NewMockRepository()
+	gameUseCase := NewGameUseCase(repo, randomness.NewMockRandomGenerator([]domain.Move{}))
+	gameUseCase.SetUnfinishedGames(repo)
+
+	format := domain.MatchFormat{Type: domain.BestOf, Rounds: 3}
+	require.NoError(t, gameUseCase.StartNewGame(domain.PlayerVsPlayer, format, domain.RockPaperScissors, "Alice", "Bob"))
+	_, err := gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Scissors)
+	require.NoError(t, err)
+
+	games, err := gameUseCase.GetUnfinishedGames()
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+	assert.Equal(t, format, games[0].Format)
+	assert.Equal(t, "Alice", games[0].Game.Player1)
+	assert.Len(t, games[0].Game.Rounds, 1)
+
+	_, err = gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Scissors)
+	require.NoError(t, err)
+
+	// Finished games are stored once, and no longer unfinished
+	assert.Len(t, repo.Games, 1)
+	assert.Empty(t, repo.Unfinished)
+}
+
+// failingUnfinishedRepository stores unfinished games but cannot delete
+// them.
+type failingUnfinishedRepository struct {
+	*repository.MockRepository
+}
+
+func (r failingUnfinishedRepository) DeleteUnfinishedGame(id string) error {
+	return errors.New("database is locked")
+}
+
+func TestFinishedGameIsReturnedWhenUnfinishedCannotBeDeleted(t *testing.T) {
+	repo := repository.NewMockRepository()
+	gameUseCase := NewGameUseCase(repo, randomness.NewMockRandomGenerator([]domain.Move{}))
+	gameUseCase.SetUnfinishedGames(failingUnfinishedRepository{repo})
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	format := domain.MatchFormat{Type: domain.BestOf, Rounds: 3}
+	require.NoError(t, gameUseCase.StartNewGame(domain.PlayerVsPlayer, format, domain.RockPaperScissors, "Alice", "Bob"))
+	_, err := gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Scissors)
+	require.NoError(t, err)
+	gameID := gameUseCase.GetCurrentGame().ID
+
+	game, err := gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Scissors)
+	require.NoError(t, err)
+	require.NotNil(t, game)
+	assert.Equal(t, "Alice", game.Winner)
+	assert.Nil(t, gameUseCase.GetCurrentGame())
+	assert.Len(t, repo.Games, 1)
+	assert.Contains(t, logs.String(), "Failed to delete unfinished game "+gameID+": database is locked")
+}
+
+func TestResumeGame(t *testing.T) {
+	repo := repository.NewMockRepository()
+	gameUseCase := NewGameUseCase(repo, randomness.NewMockRandomGenerator([]domain.Move{}))
+	gameUseCase.SetUnfinishedGames(repo)
+
+	format := domain.MatchFormat{Type: domain.BestOf, Rounds: 3}
+	require.NoError(t, gameUseCase.StartNewGame(domain.PlayerVsPlayer, format, domain.RockPaperScissors, "Alice", "Bob"))
+	_, err := gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Scissors)
+	require.NoError(t, err)
+	gameID := gameUseCase.GetCurrentGame().ID
+
+	// A new process picks the game up after the rounds played
+	gameUseCase = NewGameUseCase(repo, randomness.NewMockRandomGenerator([]domain.Move{}))
+	gameUseCase.SetUnfinishedGames(repo)
+	require.NoError(t, gameUseCase.ResumeGame(gameID))
+	assert.Len(t, gameUseCase.GetCurrentRounds(), 1)
+	assert.Equal(t, format, gameUseCase.GetCurrentFormat())
+
+	_, err = gameUseCase.PlayRound(context.Background(), domain.Paper, domain.Scissors)
+	require.NoError(t, err)
+	assert.Len(t, repo.Unfinished[gameID].Game.Rounds, 2)
+
+	game, err := gameUseCase.PlayRound(context.Background(), domain.Paper, domain.Rock)
+	require.NoError(t, err)
+	assert.Equal(t, gameID, game.ID)
+	assert.Equal(t, "Alice", game.Winner)
+	assert.Len(t, game.Rounds, 3)
+	assert.Empty(t, repo.Unfinished)
+
+	err = gameUseCase.ResumeGame(gameID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestResumeSeededBotGameReplays(t *testing.T) {
+	repo := repository.NewMockRepository()
+	newGameUseCase := func(masterSeed uint64) *GameUseCase {
+		randGen := randomness.NewSeededRandomGenerator(masterSeed)
+		gameUseCase := NewGameUseCase(repo, randGen)
+		gameUseCase.SetStrategies(randomness.NewStrategies(randGen))
+		gameUseCase.SetUnfinishedGames(repo)
+		return gameUseCase
+	}
+
+	gameUseCase := newGameUseCase(42)
+	require.NoError(t, gameUseCase.SetBotDifficulty(domain.Hard))
+	format := domain.MatchFormat{Type: domain.FixedRounds, Rounds: 6}
+	require.NoError(t, gameUseCase.StartNewGame(domain.PlayerVsBot, format, domain.RockPaperScissors, "Alice", "Bot"))
+	for i := 0; i < 3; i++ {
+		_, err := gameUseCase.PlayRound(context.Background(), domain.Rock, 0)
+		require.NoError(t, err)
+	}
+	gameID := gameUseCase.GetCurrentGame().ID
+
+	// The bot keeps the difficulty of the game, not the one selected since
+	gameUseCase = newGameUseCase(7)
+	require.NoError(t, gameUseCase.ResumeGame(gameID))
+	assert.NotEmpty(t, gameUseCase.GetBotCommitment())
+	for i := 0; i < 3; i++ {
+		_, err := gameUseCase.PlayRound(context.Background(), domain.Paper, 0)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, repo.Games, 1)
+	game := repo.Games[0]
+	assert.Equal(t, domain.Hard, game.Difficulty)
+	require.NotNil(t, game.Seed)
+
+	moves, err := randomness.ReplayBotMoves(game)
+	require.NoError(t, err)
+	for i, round := range game.Rounds {
+		assert.Equal(t, round.Move2, moves[i], "round %d", i+1)
+		assert.NoError(t, round.VerifyCommitment())
+	}
+}
+
+func TestForfeitGame(t *testing.T) {
+	repo := repository.NewMockRepository()
+	gameUseCase := NewGameUseCase(repo, randomness.NewMockRandomGenerator([]domain.Move{}))
+	gameUseCase.SetUnfinishedGames(repo)
+
+	require.NoError(t, gameUseCase.StartNewGame(domain.PlayerVsPlayer, domain.DefaultMatchFormat, domain.RockPaperScissors, "Alice", "Bob"))
+	_, err := gameUseCase.PlayRound(context.Background(), domain.Rock, domain.Scissors)
+	require.NoError(t, err)
+	gameID := gameUseCase.GetCurrentGame().ID
+
+	_, err = gameUseCase.ForfeitGame(context.Background(), gameID, "Carol")
+	assert.EqualError(t, err, "Carol is not a player of game "+gameID)
+
+	game, err := gameUseCase.ForfeitGame(context.Background(), gameID, "Alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", game.Winner)
+	assert.True(t, game.Forfeit)
+	assert.Len(t, game.Rounds, 1)
+	assert.Nil(t, gameUseCase.GetCurrentGame())
+
+	assert.Equal(t, []*domain.Game{game}, repo.Games)
+	assert.Empty(t, repo.Unfinished)
+}
+
+func TestTournamentMatchesAreNotStoredUnfinished(t *testing.T) {
+	repo := repository.NewMockRepository()
+	tournaments, games := newTestTournamentUseCase(repo)
+	games.SetUnfinishedGames(repo)
+
+	tournament, err := tournaments.CreateTournament("Cup", domain.SingleElimination, domain.DefaultMatchFormat, domain.RockPaperScissors, []string{"Alice", "Bob"}, 0)
+	require.NoError(t, err)
+	require.NoError(t, tournaments.StartMatch(tournament, tournament.Pending()[0]))
+	_, err = games.PlayRound(context.Background(), domain.Rock, domain.Scissors)
+	require.NoError(t, err)
+
+	assert.Empty(t, repo.Unfinished)
+}